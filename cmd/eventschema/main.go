@@ -0,0 +1,32 @@
+// eventschema пишет JSON Schema real-time событий (см. event.Registry) для фронта.
+// Запускается через go generate ./internal/lib/event.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+)
+
+func main() {
+	out := flag.String("o", "", "куда писать схему (по умолчанию stdout)")
+	flag.Parse()
+
+	schema, err := event.JSONSchema()
+	if err != nil {
+		log.Fatalf("build schema: %v", err)
+	}
+	schema = append(schema, '\n')
+
+	if *out == "" {
+		if _, err := os.Stdout.Write(schema); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := os.WriteFile(*out, schema, 0o644); err != nil {
+		log.Fatalf("write schema: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+// filegc — один проход уборки файлов-сирот с отчётом в stdout (JSON).
+// По умолчанию ничего не удаляет: go run ./cmd/filegc, удалить — go run ./cmd/filegc -dry-run=false
+package main
+
+import (
+	"context"
+	"flag"
+	goLog "log"
+	"os"
+	"os/signal"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/config"
+	"github.com/onionfriend2004/threadbook_backend/internal/app"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/logger"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", true, "только отчёт, ничего не удалять")
+	configDir := flag.String("config", "./config", "каталог с конфигом")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configDir)
+	if err != nil {
+		goLog.Fatalf("failed to load config: %v", err)
+	}
+	log := logger.New(cfg)
+	defer log.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := app.RunFileGC(ctx, cfg, *dryRun, log)
+	if report != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(report); encErr != nil {
+			goLog.Printf("failed to encode report: %v", encErr)
+		}
+	}
+	if err != nil {
+		goLog.Fatalf("file gc failed: %v", err)
+	}
+}
@@ -66,6 +66,10 @@ type Config struct {
 		AdminAPIKey string `mapstructure:"admin_api_key"` // ключ для административного API (если нужно)
 		Namespace   string `mapstructure:"namespace"`     // например, "chat"
 		TTL         uint32 `mapstructure:"ttl"`           // TTL токена подключения пользователя в секундах
+		ProxySecret string `mapstructure:"proxy_secret"`  // секрет в X-Centrifugo-Proxy-Secret от proxy-запросов, обязателен: без него сервер не стартует
+
+		PublishBatchSize    int `mapstructure:"publish_batch_size"`     // сколько публикаций максимум в одном pipe-запросе
+		PublishBatchDelayMs int `mapstructure:"publish_batch_delay_ms"` // В миллисекундах, сколько копим параллельные публикации перед отправкой
 	} `mapstructure:"centrifugo"`
 
 	UserSession struct {
@@ -102,14 +106,50 @@ type Config struct {
 	} `mapstructure:"livekit"`
 
 	Room struct {
-		EmptyTTL        uint32 `mapstructure:"empty_ttl"`        // В секундах
-		MaxParticipants uint32 `mapstructure:"max_participants"` // в штуках)
+		EmptyTTL        uint32 `mapstructure:"empty_ttl"`         // В секундах
+		MaxParticipants uint32 `mapstructure:"max_participants"`  // в штуках)
+		StageMinMembers int    `mapstructure:"stage_min_members"` // тред с таким числом участников сам становится сценой, 0 — только руками
+
+		// Что можно публиковать и сколько живёт токен, в зависимости от роли в спуле
+		Roles struct {
+			Owner     VoiceRoleConfig `mapstructure:"owner"`     // создатель спула
+			Moderator VoiceRoleConfig `mapstructure:"moderator"` // создатель треда
+			Member    VoiceRoleConfig `mapstructure:"member"`    // все остальные, на сцене они слушатели
+		} `mapstructure:"roles"`
 	} `mapstructure:"room"`
 
+	Recording struct {
+		S3Endpoint string `mapstructure:"s3_endpoint"` // MinIO глазами контейнера egress; пусто — http(s)://minio.host:port
+		Region     string `mapstructure:"region"`      // MinIO всё равно, но S3-клиенту egress нужен
+		AudioOnly  bool   `mapstructure:"audio_only"`  // только звук (ogg) вместо видео с экраном (mp4)
+	} `mapstructure:"recording"`
+
 	Log struct {
 		Level string `mapstructure:"level"` // e.g. "debug", "info"
 	} `mapstructure:"log"`
 
+	Presence struct {
+		TTL           int `mapstructure:"ttl"`            // В секундах, сколько живёт heartbeat (клиент шлёт раз в ttl/2)
+		SweepInterval int `mapstructure:"sweep_interval"` // В секундах, как часто ищем протухших и шлём offline
+		TypingTTL     int `mapstructure:"typing_ttl"`     // В секундах, не чаще одного "печатает" от пользователя в тред
+	} `mapstructure:"presence"`
+
+	Outbox struct {
+		PollIntervalMs int `mapstructure:"poll_interval_ms"` // В миллисекундах, как часто релей заглядывает в outbox без пинка
+		BatchSize      int `mapstructure:"batch_size"`       // сколько событий отправляем за один проход
+		MaxAttempts    int `mapstructure:"max_attempts"`     // после стольких неудач событие считается мёртвым
+		BaseBackoffMs  int `mapstructure:"base_backoff_ms"`  // В миллисекундах, пауза после первой неудачи (дальше x2)
+		MaxBackoff     int `mapstructure:"max_backoff"`      // В секундах, потолок паузы между попытками
+		Retention      int `mapstructure:"retention"`        // В часах, сколько храним уже отправленные события
+	} `mapstructure:"outbox"`
+
+	SSE struct {
+		Subject      string `mapstructure:"subject"`       // префикс NATS-субъектов, в которые зеркалим публикации: <subject>.user#1
+		BufferSize   int    `mapstructure:"buffer_size"`   // сколько событий копим на соединение; не успевает читать — рвём, клиент догонит по Last-Event-ID
+		ReplayLimit  int    `mapstructure:"replay_limit"`  // больше пропущенных событий не догоняем, а шлём resync
+		HeartbeatSec int    `mapstructure:"heartbeat_sec"` // В секундах, пинг комментарием, чтобы прокси не закрывали соединение
+	} `mapstructure:"sse"`
+
 	Upload struct {
 		Common struct {
 			AllowedFormats []string `mapstructure:"allowed_formats"` // глобально разрешённые форматы (png, jpg, webp, mp4 и т.д.)
@@ -119,8 +159,62 @@ type Config struct {
 		Spool struct {
 			MaxBannerSizeMB int `mapstructure:"max_banner_size_mb"`
 		} `mapstructure:"spool"`
+
+		Message struct {
+			MaxAttachmentSizeMB int      `mapstructure:"max_attachment_size_mb"`
+			AllowedFormats      []string `mapstructure:"allowed_formats"` // если пусто — берём common.allowed_formats
+			Bucket              string   `mapstructure:"bucket"`          // бакет для вложений (по умолчанию "attachments")
+		} `mapstructure:"message"`
+
+		Resumable struct {
+			MaxSizeMB     int `mapstructure:"max_size_mb"`    // докачиваемое вложение не больше этого
+			PartSizeMB    int `mapstructure:"part_size_mb"`   // размер части; меньше 5 MB S3 не примет
+			TTL           int `mapstructure:"ttl"`            // В часах: не дописали за это время — загрузку бросаем
+			SweepInterval int `mapstructure:"sweep_interval"` // В минутах, как часто убирать брошенные загрузки из хранилища
+		} `mapstructure:"resumable"`
 	}
 
+	Storage struct {
+		Backend  string `mapstructure:"backend"`   // где лежат файлы: minio (по умолчанию), local — каталог на диске, memory — в памяти (тесты)
+		LocalDir string `mapstructure:"local_dir"` // корень для backend=local, внутри по каталогу на бакет
+	} `mapstructure:"storage"`
+
+	Scanner struct {
+		Backend          string `mapstructure:"backend"`           // none (по умолчанию) или clamav
+		Address          string `mapstructure:"address"`           // clamd: "127.0.0.1:3310" или путь к unix-сокету
+		Timeout          int    `mapstructure:"timeout"`           // В секундах, на проверку одного файла
+		FailOpen         bool   `mapstructure:"fail_open"`         // clamd лежит — принимать файлы без проверки (по умолчанию нет)
+		QuarantineBucket string `mapstructure:"quarantine_bucket"` // куда уезжают заражённые файлы
+	} `mapstructure:"scanner"`
+
+	Quota struct {
+		UserMB  int64 `mapstructure:"user_mb"`  // сколько места можно занять одному пользователю; 0 — без лимита
+		SpoolMB int64 `mapstructure:"spool_mb"` // сколько места занимают файлы тредов спула; 0 — без лимита
+	} `mapstructure:"quota"`
+
+	FileGC struct {
+		Enabled     bool `mapstructure:"enabled"`      // фоновая уборка файлов, на которые никто не ссылается
+		Interval    int  `mapstructure:"interval"`     // В минутах, между проходами
+		GracePeriod int  `mapstructure:"grace_period"` // В часах: объекты моложе не трогаем
+		DryRun      bool `mapstructure:"dry_run"`      // только отчёт, ничего не удаляем. По умолчанию true: удаление включается явно
+		BatchSize   int  `mapstructure:"batch_size"`   // столько ключей сверяем с БД за раз
+	} `mapstructure:"file_gc"`
+
+	Files struct {
+		LinkSecret  string `mapstructure:"link_secret"`   // HMAC для ссылок на скачивание без куки; пусто — выдаём только presigned
+		LinkTTL     int    `mapstructure:"link_ttl"`      // В секундах, сколько живёт выданная ссылка
+		LinkBaseURL string `mapstructure:"link_base_url"` // префикс, под которым снаружи виден API, например "https://threadbook.ru/api"
+	} `mapstructure:"files"`
+
+	Images struct {
+		Subject     string `mapstructure:"subject"`      // NATS-субъект задач на ресайз
+		AvatarSizes []int  `mapstructure:"avatar_sizes"` // стороны квадратных копий аватарок, px
+		BannerSizes []int  `mapstructure:"banner_sizes"` // ширины копий баннеров, px
+		MaxPixels   int    `mapstructure:"max_pixels"`   // больше пикселей — не декодируем
+		MaxSizeMB   int    `mapstructure:"max_size_mb"`  // больше — не качаем из MinIO
+		Quality     int    `mapstructure:"quality"`      // качество jpeg у копий
+	} `mapstructure:"images"`
+
 	CORS struct {
 		AllowedOrigins   []string `mapstructure:"allowed_origins"`   // e.g. ["http://localhost:3000", "https://yourdomain.com"]
 		AllowedMethods   []string `mapstructure:"allowed_methods"`   // e.g. ["GET", "POST", "PUT", "DELETE", "OPTIONS"]
@@ -135,6 +229,11 @@ type Config struct {
 	} `mapstructure:"attempts_resend"`
 }
 
+type VoiceRoleConfig struct {
+	TokenTTL int      `mapstructure:"token_ttl"` // В секундах
+	Sources  []string `mapstructure:"sources"`   // camera, microphone, screen_share, screen_share_audio
+}
+
 // LoadConfig загружает конфигурацию из файла YAML и переменных среды.
 func LoadConfig(path string) (*Config, error) {
 	viper.AddConfigPath(path)
@@ -147,6 +246,53 @@ func LoadConfig(path string) (*Config, error) {
 
 	// Установка разумных значений (дефолтов) по умолчанию
 	viper.SetDefault("log.level", "info")
+	viper.SetDefault("upload.message.bucket", "attachments")
+	viper.SetDefault("upload.resumable.max_size_mb", 2048)
+	viper.SetDefault("upload.resumable.part_size_mb", 8)
+	viper.SetDefault("upload.resumable.ttl", 24)
+	viper.SetDefault("upload.resumable.sweep_interval", 60)
+	viper.SetDefault("presence.ttl", 60)
+	viper.SetDefault("presence.sweep_interval", 15)
+	viper.SetDefault("presence.typing_ttl", 3)
+	viper.SetDefault("centrifugo.publish_batch_size", 100)
+	viper.SetDefault("centrifugo.publish_batch_delay_ms", 5)
+	viper.SetDefault("outbox.poll_interval_ms", 1000)
+	viper.SetDefault("outbox.batch_size", 100)
+	viper.SetDefault("outbox.max_attempts", 10)
+	viper.SetDefault("outbox.base_backoff_ms", 500)
+	viper.SetDefault("outbox.max_backoff", 300)
+	viper.SetDefault("outbox.retention", 24)
+	viper.SetDefault("sse.subject", "realtime")
+	viper.SetDefault("sse.buffer_size", 256)
+	viper.SetDefault("sse.replay_limit", 1000)
+	viper.SetDefault("sse.heartbeat_sec", 25)
+	viper.SetDefault("room.roles.owner.token_ttl", 3600)
+	viper.SetDefault("room.roles.owner.sources", []string{"camera", "microphone", "screen_share", "screen_share_audio"})
+	viper.SetDefault("room.roles.moderator.token_ttl", 3600)
+	viper.SetDefault("room.roles.moderator.sources", []string{"camera", "microphone", "screen_share", "screen_share_audio"})
+	viper.SetDefault("room.roles.member.token_ttl", 900)
+	viper.SetDefault("room.roles.member.sources", []string{"camera", "microphone", "screen_share"})
+	viper.SetDefault("recording.region", "us-east-1")
+	viper.SetDefault("storage.backend", "minio")
+	viper.SetDefault("storage.local_dir", "./data/files")
+	viper.SetDefault("scanner.backend", "none")
+	viper.SetDefault("scanner.timeout", 30)
+	viper.SetDefault("scanner.quarantine_bucket", "quarantine")
+	viper.SetDefault("quota.user_mb", 2048)
+	viper.SetDefault("quota.spool_mb", 10240)
+	viper.SetDefault("file_gc.enabled", true)
+	viper.SetDefault("file_gc.dry_run", true)
+	viper.SetDefault("file_gc.interval", 360)
+	viper.SetDefault("file_gc.grace_period", 24)
+	viper.SetDefault("file_gc.batch_size", 500)
+	viper.SetDefault("files.link_ttl", 300)
+	viper.SetDefault("files.link_base_url", "/api")
+	viper.SetDefault("images.subject", "files.images")
+	viper.SetDefault("images.avatar_sizes", []int{64, 128, 512})
+	viper.SetDefault("images.banner_sizes", []int{1200})
+	viper.SetDefault("images.max_pixels", 40_000_000)
+	viper.SetDefault("images.max_size_mb", 30)
+	viper.SetDefault("images.quality", 85)
 
 	// Чтение конфига
 	if err := viper.ReadInConfig(); err != nil {
@@ -1,11 +1,16 @@
 package config
 
 import (
-	"mime"
+	"errors"
+	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 )
 
+// ErrContentTypeMismatch — содержимое файла не то, что обещает расширение
+var ErrContentTypeMismatch = errors.New("file content does not match its extension")
+
 type FileConfig struct {
 	Common struct {
 		AllowedFormats []string
@@ -14,6 +19,13 @@ type FileConfig struct {
 	Spool struct {
 		MaxBannerSizeBytes int64
 	}
+	Message struct {
+		AllowedFormats         []string
+		MaxAttachmentSizeBytes int64
+	}
+	Resumable struct {
+		MaxSizeBytes int64
+	}
 }
 
 func NewFileConfig(cfg *Config) *FileConfig {
@@ -30,12 +42,33 @@ func NewFileConfig(cfg *Config) *FileConfig {
 		}{
 			MaxBannerSizeBytes: int64(cfg.Upload.Spool.MaxBannerSizeMB) << 20,
 		},
+		Message: struct {
+			AllowedFormats         []string
+			MaxAttachmentSizeBytes int64
+		}{
+			AllowedFormats:         cfg.Upload.Message.AllowedFormats,
+			MaxAttachmentSizeBytes: int64(cfg.Upload.Message.MaxAttachmentSizeMB) << 20,
+		},
+		Resumable: struct {
+			MaxSizeBytes int64
+		}{
+			MaxSizeBytes: int64(cfg.Upload.Resumable.MaxSizeMB) << 20,
+		},
 	}
 }
 
 func (f *FileConfig) IsAllowedFormat(filename string) bool {
+	return isFormatInList(filename, f.Common.AllowedFormats)
+}
+
+// IsAllowedFormatFor проверяет расширение по списку форматов конкретного типа файла
+func (f *FileConfig) IsAllowedFormatFor(fileType, filename string) bool {
+	return isFormatInList(filename, f.GetAllowedFormatsFor(fileType))
+}
+
+func isFormatInList(filename string, formats []string) bool {
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
-	for _, format := range f.Common.AllowedFormats {
+	for _, format := range formats {
 		if strings.ToLower(format) == ext {
 			return true
 		}
@@ -43,24 +76,33 @@ func (f *FileConfig) IsAllowedFormat(filename string) bool {
 	return false
 }
 
-func (f *FileConfig) GetContentTypeByExtension(filename string) string {
-	ext := filepath.Ext(filename)
-	return mime.TypeByExtension(ext)
+// DetectContentType определяет тип по первым байтам файла, а не по имени, и сверяет его с расширением.
+// Файл перематывается обратно в начало
+func (f *FileConfig) DetectContentType(file io.ReadSeeker, filename string) (string, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read file header: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind file: %w", err)
+	}
+	return detectContentType(head[:n], filename)
 }
 
 func (f *FileConfig) ValidateSize(fileType string, size int64) bool {
-	switch fileType {
-	case "spool_banner":
-		return size <= f.Spool.MaxBannerSizeBytes
-	default:
-		return size <= f.Common.MaxSizeBytes
-	}
+	return size <= f.GetMaxSize(fileType)
 }
 
 func (f *FileConfig) GetMaxSize(fileType string) int64 {
 	switch fileType {
 	case "spool_banner":
 		return f.Spool.MaxBannerSizeBytes
+	case "message_attachment":
+		if f.Message.MaxAttachmentSizeBytes > 0 {
+			return f.Message.MaxAttachmentSizeBytes
+		}
+		return f.Common.MaxSizeBytes
 	default:
 		return f.Common.MaxSizeBytes
 	}
@@ -69,3 +111,15 @@ func (f *FileConfig) GetMaxSize(fileType string) int64 {
 func (f *FileConfig) GetAllowedFormats() []string {
 	return f.Common.AllowedFormats
 }
+
+func (f *FileConfig) GetAllowedFormatsFor(fileType string) []string {
+	switch fileType {
+	case "message_attachment":
+		if len(f.Message.AllowedFormats) > 0 {
+			return f.Message.AllowedFormats
+		}
+		return f.Common.AllowedFormats
+	default:
+		return f.Common.AllowedFormats
+	}
+}
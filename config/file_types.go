@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen — столько байт смотрит http.DetectContentType, больше читать незачем
+const sniffLen = 512
+
+// sniffRule — что может показать содержимое файла с таким расширением и какой тип тогда отдаём
+type sniffRule struct {
+	accept      []string // что допустимо получить из sniffContentType
+	contentType string   // что сохраняем; пусто — то, что определилось по содержимому
+}
+
+var (
+	textSniff = []string{"text/plain; charset=utf-8", "text/plain; charset=utf-16be", "text/plain; charset=utf-16le"}
+	zipSniff  = []string{"application/zip"}
+)
+
+// sniffRules — расширение → допустимое содержимое. Расширения вне таблицы пропускаем,
+// если содержимое не html (см. detectContentType)
+var sniffRules = map[string]sniffRule{
+	"png":  {accept: []string{"image/png"}},
+	"jpg":  {accept: []string{"image/jpeg"}},
+	"jpeg": {accept: []string{"image/jpeg"}},
+	"gif":  {accept: []string{"image/gif"}},
+	"webp": {accept: []string{"image/webp"}},
+	"bmp":  {accept: []string{"image/bmp"}},
+	"ico":  {accept: []string{"image/x-icon"}},
+	"heic": {accept: []string{"image/heic"}},
+	"heif": {accept: []string{"image/heic"}, contentType: "image/heif"},
+	"avif": {accept: []string{"image/avif"}},
+	"svg":  {accept: append([]string{"text/xml; charset=utf-8"}, textSniff...), contentType: "image/svg+xml"},
+
+	"mp4":  {accept: []string{"video/mp4"}},
+	"m4v":  {accept: []string{"video/mp4"}, contentType: "video/x-m4v"},
+	"mov":  {accept: []string{"video/quicktime", "video/mp4"}, contentType: "video/quicktime"},
+	"webm": {accept: []string{"video/webm"}},
+	"mkv":  {accept: []string{"video/webm"}, contentType: "video/x-matroska"},
+	"avi":  {accept: []string{"video/avi"}},
+
+	"mp3":  {accept: []string{"audio/mpeg"}},
+	"ogg":  {accept: []string{"application/ogg"}, contentType: "audio/ogg"},
+	"oga":  {accept: []string{"application/ogg"}, contentType: "audio/ogg"},
+	"opus": {accept: []string{"application/ogg"}, contentType: "audio/ogg"},
+	"wav":  {accept: []string{"audio/wave"}, contentType: "audio/wav"},
+	"m4a":  {accept: []string{"audio/mp4", "video/mp4"}, contentType: "audio/mp4"},
+	"flac": {accept: []string{"audio/flac"}},
+
+	"pdf":  {accept: []string{"application/pdf"}},
+	"zip":  {accept: zipSniff},
+	"docx": {accept: zipSniff, contentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	"xlsx": {accept: zipSniff, contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	"pptx": {accept: zipSniff, contentType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	"gz":   {accept: []string{"application/x-gzip"}, contentType: "application/gzip"},
+	"rar":  {accept: []string{"application/x-rar-compressed"}, contentType: "application/vnd.rar"},
+	"7z":   {accept: []string{"application/x-7z-compressed"}},
+
+	"txt":  {accept: textSniff, contentType: "text/plain; charset=utf-8"},
+	"md":   {accept: textSniff, contentType: "text/markdown; charset=utf-8"},
+	"csv":  {accept: textSniff, contentType: "text/csv; charset=utf-8"},
+	"json": {accept: textSniff, contentType: "application/json"},
+	"log":  {accept: textSniff, contentType: "text/plain; charset=utf-8"},
+}
+
+// sniffContentType — http.DetectContentType плюс то, чего он не знает: бренды ftyp (mov, m4a, heic, avif), flac и 7z
+func sniffContentType(head []byte) string {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		switch string(head[8:12]) {
+		case "qt  ":
+			return "video/quicktime"
+		case "M4A ", "M4B ":
+			return "audio/mp4"
+		case "heic", "heix", "hevc", "hevx", "mif1", "msf1":
+			return "image/heic"
+		case "avif", "avis":
+			return "image/avif"
+		}
+	}
+	if bytes.HasPrefix(head, []byte("fLaC")) {
+		return "audio/flac"
+	}
+	if bytes.HasPrefix(head, []byte("7z\xBC\xAF\x27\x1C")) {
+		return "application/x-7z-compressed"
+	}
+	sniffed := http.DetectContentType(head)
+	// mp3 без ID3-тега начинается сразу с кадра, DetectContentType такие не узнаёт
+	if sniffed == "application/octet-stream" && len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 {
+		return "audio/mpeg"
+	}
+	return sniffed
+}
+
+// detectContentType сверяет содержимое с расширением и возвращает тип, который можно отдавать клиентам
+func detectContentType(head []byte, filename string) (string, error) {
+	sniffed := sniffContentType(head)
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+
+	rule, ok := sniffRules[ext]
+	if !ok {
+		// html под чужим расширением — классика для XSS через вложения
+		if strings.HasPrefix(sniffed, "text/html") {
+			return "", ErrContentTypeMismatch
+		}
+		return sniffed, nil
+	}
+
+	for _, accepted := range rule.accept {
+		if sniffed == accepted {
+			if rule.contentType != "" {
+				return rule.contentType, nil
+			}
+			return sniffed, nil
+		}
+	}
+	return "", ErrContentTypeMismatch
+}
@@ -35,14 +35,155 @@ func PostgresConnect(cfg *config.Config) (*gorm.DB, error) {
 		&gdomain.ThreadUser{},
 		&gdomain.Message{},
 		&gdomain.MessagePayload{},
+		&gdomain.MessageMention{},
 		&gdomain.Profile{},
+		&gdomain.OutboxEvent{},
+		&gdomain.ThreadEvent{},
+		&gdomain.VoiceRecording{},
+		&gdomain.File{},
+		&gdomain.StorageUsage{},
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	// db.Exec(``) Кастомные запросы DDL
+	// Разовые переносы данных — до DDL, который на них опирается
+	if err := migrateOnce(db); err != nil {
+		return nil, fmt.Errorf("failed to run one-shot migrations: %w", err)
+	}
+	// Кастомные запросы DDL
+	if err := migrateCustomDDL(db); err != nil {
+		return nil, fmt.Errorf("failed to run custom migrations: %w", err)
+	}
 
 	return db, nil
 }
+
+// oneShotMigrations — переносы данных, которые нельзя гонять на каждом старте: повтор вернул бы
+// то, что с тех пор удалили. Сделанное отмечается в schema_migrations, имя менять нельзя.
+var oneShotMigrations = []struct {
+	name  string
+	stmts []string
+}{
+	{
+		// Метаданные для файлов, загруженных до появления таблицы files. Бакеты avatars/uploads
+		// захардкожены так же, как в роутере; attachments берём из самих вложений
+		name: "backfill_files",
+		stmts: []string{
+			`INSERT INTO files (bucket, object_key, owner_id, kind, thread_id, message_id, content_type, size, created_at)
+				SELECT bucket, file_link, uploader_id, 'message_attachment', thread_id, message_id, content_type, size, created_at
+				FROM message_payloads WHERE bucket <> '' AND file_link <> ''
+				ON CONFLICT DO NOTHING`,
+			`INSERT INTO files (bucket, object_key, owner_id, kind, content_type, size, created_at)
+				SELECT 'avatars', avatar_link, user_id, 'avatar', '', 0, created_at
+				FROM profiles WHERE avatar_link <> ''
+				ON CONFLICT DO NOTHING`,
+			`INSERT INTO files (bucket, object_key, owner_id, kind, spool_id, content_type, size, created_at)
+				SELECT 'uploads', banner_link, creator_id, 'spool_banner', id, '', 0, created_at
+				FROM spools WHERE banner_link <> ''
+				ON CONFLICT DO NOTHING`,
+		},
+	},
+	{
+		// Квоты появились позже файлов: файлам треда проставляем спул (место считается и в него)
+		// и заводим стартовые счётчики. Дальше их двигает сам репозиторий файлов — при повторе
+		// пользователь или спул, у которого счётчика ещё нет, получил бы его заново из files
+		name: "seed_storage_usage",
+		stmts: []string{
+			`UPDATE files f SET spool_id = t.spool_id
+				FROM threads t
+				WHERE f.thread_id = t.id AND f.spool_id IS NULL`,
+			`INSERT INTO storage_usages (scope, scope_id, bytes, files, updated_at)
+				SELECT 'user', owner_id, sum(size), count(*), now()
+				FROM files WHERE coalesce(quarantine, '') = '' GROUP BY owner_id
+				ON CONFLICT DO NOTHING`,
+			`INSERT INTO storage_usages (scope, scope_id, bytes, files, updated_at)
+				SELECT 'spool', spool_id, sum(size), count(*), now()
+				FROM files WHERE spool_id IS NOT NULL AND coalesce(quarantine, '') = '' GROUP BY spool_id
+				ON CONFLICT DO NOTHING`,
+		},
+	},
+	{
+		// Маркеры прочтения появились позже сообщений: всё написанное до них считаем прочитанным,
+		// иначе вся история (и все старые упоминания) разом станет непрочитанной
+		name: "backfill_last_read",
+		stmts: []string{
+			`UPDATE thread_users tu SET last_read_message_id = m.max_id
+				FROM (SELECT thread_id, max(id) AS max_id FROM messages GROUP BY thread_id) m
+				WHERE tu.thread_id = m.thread_id AND tu.last_read_message_id = 0`,
+		},
+	},
+	{
+		// Журнал треда: сообщениям, написанным до появления seq, выдаём номера по порядку id
+		// после уже выданных, подтягиваем threads.last_seq и дописываем их в thread_events
+		name: "backfill_thread_seq",
+		stmts: []string{
+			`UPDATE messages m SET seq = t.last_seq + s.rn
+				FROM (SELECT id, thread_id, row_number() OVER (PARTITION BY thread_id ORDER BY id) AS rn
+					FROM messages WHERE seq = 0) s
+				JOIN threads t ON t.id = s.thread_id
+				WHERE m.id = s.id`,
+			`UPDATE threads t SET last_seq = m.max_seq
+				FROM (SELECT thread_id, max(seq) AS max_seq FROM messages GROUP BY thread_id) m
+				WHERE t.id = m.thread_id AND t.last_seq < m.max_seq`,
+			`INSERT INTO thread_events (thread_id, seq, type, message_id, created_at)
+				SELECT thread_id, seq, 'message.created', id, created_at FROM messages
+				ON CONFLICT (thread_id, seq) DO NOTHING`,
+		},
+	},
+}
+
+// migrateOnce — каждая миграция в своей транзакции вместе с отметкой. Второй инстанс ждёт
+// на вставке отметки, пока первый не закоммитит, и дальше пропускает
+func migrateOnce(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name text PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now())`).Error; err != nil {
+		return err
+	}
+	for _, m := range oneShotMigrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Exec(`INSERT INTO schema_migrations (name) VALUES (?) ON CONFLICT DO NOTHING`, m.name)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			for _, stmt := range m.stmts {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// customDDL — то, что AutoMigrate не умеет. Все запросы идемпотентные, гоняются при каждом старте.
+var customDDL = []string{
+	// В треде одновременно пишется не больше одной записи
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_voice_recordings_active_thread ON voice_recordings (thread_id)
+		WHERE status IN ('starting', 'active', 'stopping')`,
+	// Полнотекстовый поиск по сообщениям: генерируемая колонка сама пересчитывается
+	// при вставке и правке content, удалённые (deleted_at) отсекаются в запросе.
+	// Конфиг russian стеммит кириллицу, латиница проходит как есть.
+	`ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('russian', coalesce(content, ''))) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector)`,
+	// Ключ идемпотентности теперь общий у строк одного broadcast'а, уникальна пара (channel, key):
+	// старый индекс по одному ключу убираем, парный заводим явно, не полагаясь на тег модели
+	`DROP INDEX IF EXISTS idx_outbox_events_idempotency_key`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_channel_key ON outbox_events (channel, idempotency_key)`,
+}
+
+func migrateCustomDDL(db *gorm.DB) error {
+	for _, stmt := range customDDL {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
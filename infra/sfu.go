@@ -13,3 +13,12 @@ func LiveKitConnect(cfg *config.Config) *livekit.RoomServiceClient {
 	)
 	return client
 }
+
+// LiveKitEgressConnect — клиент Egress API (запись комнат), ходит на тот же сервер LiveKit
+func LiveKitEgressConnect(cfg *config.Config) *livekit.EgressClient {
+	return livekit.NewEgressClient(
+		cfg.LiveKit.URL,
+		cfg.LiveKit.APIKey,
+		cfg.LiveKit.APISecret,
+	)
+}
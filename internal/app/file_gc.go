@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/config"
+	"github.com/onionfriend2004/threadbook_backend/infra"
+	fileExternal "github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// gcBuckets — бакеты, куда пишут пользователи. Общий бакет и карантин GC не трогает
+func gcBuckets(cfg *config.Config) []string {
+	return []string{"avatars", "uploads", cfg.Upload.Message.Bucket}
+}
+
+func initFileGC(cfg *config.Config, db *gorm.DB, files *fileStorage, dryRun bool, logger *zap.Logger) fileUsecase.GCUsecaseInterface {
+	var repos []fileExternal.FileRepoInterface
+	for _, bucket := range gcBuckets(cfg) {
+		repos = append(repos, files.Repo(bucket))
+	}
+	return fileUsecase.NewGCUsecase(
+		repos,
+		fileExternal.NewFileRefRepo(db),
+		fileExternal.NewFileMetaRepo(db),
+		fileUsecase.GCOptions{
+			GracePeriod: time.Duration(cfg.FileGC.GracePeriod) * time.Hour,
+			DryRun:      dryRun,
+			BatchSize:   cfg.FileGC.BatchSize,
+		},
+		logger.With(zap.String("component", "file_gc")),
+	)
+}
+
+// startFileGC раз в interval убирает файлы-сироты. Инстансов может быть несколько —
+// удаление идемпотентное, так что параллельные проходы друг другу не мешают
+func startFileGC(ctx context.Context, uc fileUsecase.GCUsecaseInterface, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := uc.Collect(ctx)
+			if err != nil {
+				logger.Warn("file gc interrupted", zap.Error(err))
+				continue
+			}
+			logGCReport(report, logger)
+		}
+	}
+}
+
+func logGCReport(report *fileUsecase.GCReport, logger *zap.Logger) {
+	for _, b := range report.Buckets {
+		logger.Info("file gc finished",
+			zap.String("bucket", b.Bucket),
+			zap.Bool("dry_run", report.DryRun),
+			zap.Int("scanned", b.Scanned),
+			zap.Int("orphaned", b.Orphaned),
+			zap.Int64("orphaned_bytes", b.OrphanedBytes),
+			zap.Int("deleted", b.Deleted),
+			zap.Int("failed", b.Failed),
+			zap.Strings("sample", b.Sample),
+		)
+	}
+}
+
+// RunFileGC — один проход GC без сервера (cmd/filegc): отчёт возвращается вызывающему
+func RunFileGC(ctx context.Context, cfg *config.Config, dryRun bool, logger *zap.Logger) (*fileUsecase.GCReport, error) {
+	db, err := infra.PostgresConnect(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	files, err := initFileStorage(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init file storage: %w", err)
+	}
+	return initFileGC(cfg, db, files, dryRun, logger).Collect(ctx)
+}
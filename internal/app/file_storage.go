@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/onionfriend2004/threadbook_backend/config"
+	"github.com/onionfriend2004/threadbook_backend/infra"
+	fileExternal "github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	"go.uber.org/zap"
+)
+
+// fileStorage — хранилище из конфига (storage.backend). Репозитории всех бакетов
+// смотрят в одно хранилище, поэтому читать можно из любого бакета через любой из них
+type fileStorage struct {
+	backend string
+	minio   *minio.Client
+	memory  *fileExternal.MemoryStore
+	root    string
+}
+
+func initFileStorage(cfg *config.Config) (*fileStorage, error) {
+	s := &fileStorage{backend: cfg.Storage.Backend}
+	switch s.backend {
+	case fileExternal.StorageMinio:
+		client, err := infra.MinioConnect(cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.minio = client
+	case fileExternal.StorageLocal:
+		if err := os.MkdirAll(cfg.Storage.LocalDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create storage dir %q: %w", cfg.Storage.LocalDir, err)
+		}
+		s.root = cfg.Storage.LocalDir
+	case fileExternal.StorageMemory:
+		s.memory = fileExternal.NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", s.backend)
+	}
+	return s, nil
+}
+
+func (s *fileStorage) Repo(bucket string) fileExternal.FileRepoInterface {
+	switch s.backend {
+	case fileExternal.StorageLocal:
+		return fileExternal.NewLocalFileRepo(s.root, bucket)
+	case fileExternal.StorageMemory:
+		return fileExternal.NewMemoryFileRepo(s.memory, bucket)
+	default:
+		return fileExternal.NewFileRepo(s.minio, bucket)
+	}
+}
+
+// IsMinio — egress записывает звонки только в S3, на диск и в память записи не попадут
+func (s *fileStorage) IsMinio() bool {
+	return s.backend == fileExternal.StorageMinio
+}
+
+// EnsureBuckets заводит бакеты в MinIO; диску и памяти это не нужно — каталоги создаются при записи
+func (s *fileStorage) EnsureBuckets(ctx context.Context, buckets []string, logger *zap.Logger) error {
+	if !s.IsMinio() {
+		return nil
+	}
+	for _, bucket := range buckets {
+		created, err := fileExternal.EnsureMinioBucket(ctx, s.minio, bucket)
+		if err != nil {
+			return err
+		}
+		if created {
+			logger.Info("bucket created", zap.String("bucket", bucket))
+		}
+	}
+	return nil
+}
+
+// fileBuckets — все бакеты приложения: их создаём при старте и только из них отдаём файлы
+func fileBuckets(cfg *config.Config) []string {
+	return []string{cfg.Minio.Bucket, cfg.Upload.Message.Bucket, "avatars", "uploads"}
+}
+
+// fileScanner — антивирус из конфига; nil — загрузки не проверяются
+func fileScanner(cfg *config.Config) fileExternal.ScannerInterface {
+	switch cfg.Scanner.Backend {
+	case "clamav":
+		return fileExternal.NewClamAVScanner(cfg.Scanner.Address, time.Duration(cfg.Scanner.Timeout)*time.Second)
+	default:
+		return nil
+	}
+}
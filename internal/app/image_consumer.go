@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/onionfriend2004/threadbook_backend/config"
+	fileDeliveryNATS "github.com/onionfriend2004/threadbook_backend/internal/file/delivery/nats"
+	fileExternal "github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func initImageConsumer(cfg *config.Config, db *gorm.DB, files *fileStorage, nc *nats.Conn, logger *zap.Logger) fileDeliveryNATS.ImageConsumerInterface {
+	imageUC := fileUsecase.NewImageUsecase(
+		// бакет тут только для SaveFile/DeleteFile, картинки читаются и пишутся туда, откуда пришла джоба
+		files.Repo(cfg.Minio.Bucket),
+		fileExternal.NewFileMetaRepo(db),
+		fileUsecase.ImageVariants{
+			AvatarSizes: cfg.Images.AvatarSizes,
+			BannerSizes: cfg.Images.BannerSizes,
+			MaxPixels:   cfg.Images.MaxPixels,
+			MaxBytes:    int64(cfg.Images.MaxSizeMB) << 20,
+			Quality:     cfg.Images.Quality,
+		},
+		logger.With(zap.String("service", "images")),
+	)
+	return fileDeliveryNATS.NewImageConsumer(
+		nc,
+		cfg.Images.Subject,
+		imageUC,
+		logger.With(zap.String("component", "image_consumer")),
+	)
+}
+
+func startImageConsumer(ctx context.Context, consumer fileDeliveryNATS.ImageConsumerInterface, logger *zap.Logger) {
+	if err := consumer.Start(ctx); err != nil {
+		logger.Error("image consumer failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/centrifugal/gocent/v3"
+	"github.com/nats-io/nats.go"
+	"github.com/onionfriend2004/threadbook_backend/config"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	outboxExternal "github.com/onionfriend2004/threadbook_backend/internal/outbox/external"
+	outboxUsecase "github.com/onionfriend2004/threadbook_backend/internal/outbox/usecase"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// outboxCleanupInterval — как часто чистим уже отправленные события
+const outboxCleanupInterval = time.Hour
+
+func initOutboxRelay(cfg *config.Config, db *gorm.DB, centrifugo *gocent.Client, nc *nats.Conn, logger *zap.Logger) outboxUsecase.RelayUsecaseInterface {
+	return outboxUsecase.NewRelayUsecase(
+		outboxExternal.NewOutboxRepo(db),
+		// релей и так шлёт пачками, копить вызовы ему незачем; доставленное зеркалим в NATS для SSE
+		threadExternal.NewNatsMirrorPublisher(
+			threadExternal.NewCentrifugoPublisher(centrifugo, cfg.Centrifugo.PublishBatchSize),
+			nc,
+			cfg.SSE.Subject,
+			logger,
+		),
+		dbtx.NewTransactor(db),
+		outboxUsecase.RelayConfig{
+			BatchSize:   cfg.Outbox.BatchSize,
+			MaxAttempts: cfg.Outbox.MaxAttempts,
+			BaseBackoff: time.Duration(cfg.Outbox.BaseBackoffMs) * time.Millisecond,
+			MaxBackoff:  time.Duration(cfg.Outbox.MaxBackoff) * time.Second,
+			Retention:   time.Duration(cfg.Outbox.Retention) * time.Hour,
+		},
+		logger.With(zap.String("component", "outbox_relay")),
+	)
+}
+
+// startOutboxRelay отправляет события из outbox по тику или по пинку после коммита.
+// Пока пачки приходят полными — гоним следующую сразу, не дожидаясь тика.
+func startOutboxRelay(ctx context.Context, uc outboxUsecase.RelayUsecaseInterface, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(outboxCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+			deleted, err := uc.Cleanup(ctx)
+			if err != nil {
+				logger.Warn("outbox cleanup failed", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				logger.Info("outbox cleaned up", zap.Int64("deleted", deleted))
+			}
+			continue
+		case <-ticker.C:
+		case <-uc.Wakeup():
+		}
+
+		for ctx.Err() == nil {
+			sent, err := uc.RelayBatch(ctx)
+			if err != nil {
+				logger.Warn("outbox relay failed", zap.Error(err))
+				break
+			}
+			if sent < uc.BatchSize() {
+				break
+			}
+		}
+	}
+}
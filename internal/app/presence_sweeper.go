@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/config"
+	presenceExternal "github.com/onionfriend2004/threadbook_backend/internal/presence/external"
+	presenceUsecase "github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	spoolExternal "github.com/onionfriend2004/threadbook_backend/internal/spool/external"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func initPresenceSweeper(cfg *config.Config, db *gorm.DB, rdb *redis.Client, publisher threadExternal.PublisherInterface, logger *zap.Logger) presenceUsecase.PresenceUsecaseInterface {
+	presenceRepo := presenceExternal.NewRedisPresenceRepo(
+		rdb,
+		time.Duration(cfg.Presence.TTL)*time.Second,
+		time.Duration(cfg.Presence.TypingTTL)*time.Second,
+	)
+	websocketRepo := threadExternal.NewWebsocketRepo(publisher, cfg.Centrifugo.TokenHMAC, "threadbook")
+	return presenceUsecase.NewPresenceUsecase(
+		presenceRepo,
+		spoolExternal.NewSpoolRepo(db),
+		websocketRepo,
+		logger.With(zap.String("component", "presence_sweeper")),
+	)
+}
+
+// startPresenceSweeper раз в interval переводит в offline пользователей без живых heartbeat
+func startPresenceSweeper(ctx context.Context, uc presenceUsecase.PresenceUsecaseInterface, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := uc.SweepExpired(ctx); err != nil {
+				logger.Warn("presence sweep failed", zap.Error(err))
+			}
+		}
+	}
+}
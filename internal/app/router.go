@@ -8,12 +8,10 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/centrifugal/gocent/v3"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	livekit "github.com/livekit/server-sdk-go/v2"
-	"github.com/minio/minio-go/v7"
 	"github.com/nats-io/nats.go"
 	"github.com/redis/go-redis/v9"
 
@@ -28,10 +26,20 @@ import (
 	fileDeliveryHTTP "github.com/onionfriend2004/threadbook_backend/internal/file/delivery/http"
 	fileExternal "github.com/onionfriend2004/threadbook_backend/internal/file/external"
 	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	outboxExternal "github.com/onionfriend2004/threadbook_backend/internal/outbox/external"
+	outboxUsecase "github.com/onionfriend2004/threadbook_backend/internal/outbox/usecase"
+	presenceDeliveryHTTP "github.com/onionfriend2004/threadbook_backend/internal/presence/delivery/http"
+	presenceExternal "github.com/onionfriend2004/threadbook_backend/internal/presence/external"
+	presenceUsecase "github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
 	profileDeliveryHTTP "github.com/onionfriend2004/threadbook_backend/internal/profile/delivery/http"
 	profileExternal "github.com/onionfriend2004/threadbook_backend/internal/profile/external"
 	profileUsecase "github.com/onionfriend2004/threadbook_backend/internal/profile/usecase"
+	realtimeDeliveryHTTP "github.com/onionfriend2004/threadbook_backend/internal/realtime/delivery/http"
+	realtimeExternal "github.com/onionfriend2004/threadbook_backend/internal/realtime/external"
+	realtimeUsecase "github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
 	spoolDeliveryHTTP "github.com/onionfriend2004/threadbook_backend/internal/spool/delivery/http"
 	spoolExternal "github.com/onionfriend2004/threadbook_backend/internal/spool/external"
 	spoolUsecase "github.com/onionfriend2004/threadbook_backend/internal/spool/usecase"
@@ -73,11 +81,18 @@ func Run(config *config.Config, logger *zap.Logger) error {
 
 	// ===================== LiveKitConn =====================
 	liveKitConn := infra.LiveKitConnect(config)
+	egressConn := infra.LiveKitEgressConnect(config)
 
-	// ===================== MinioConn =====================
-	minioConn, err := infra.MinioConnect(config)
+	// ===================== File Storage =====================
+	// MinIO, диск или память — из конфига; к MinIO подключаемся, только если он и выбран
+	files, err := initFileStorage(config)
 	if err != nil {
-		logger.Error("failed to connect to MinIO", zap.Error(err))
+		logger.Error("failed to init file storage", zap.Error(err))
+		return err
+	}
+	// карантин не входит в fileBuckets: из него ничего не отдаём, но создать его надо
+	if err := files.EnsureBuckets(context.Background(), append(fileBuckets(config), config.Scanner.QuarantineBucket), logger); err != nil {
+		logger.Error("failed to ensure buckets", zap.Error(err))
 		return err
 	}
 
@@ -90,6 +105,43 @@ func Run(config *config.Config, logger *zap.Logger) error {
 
 	go startEmailConsumer(ctx, emailConsumer, logger)
 
+	// ===================== Image Consumer =====================
+	// копии аватарок и баннеров режутся в фоне, загрузка их не ждёт
+	imageConsumer := initImageConsumer(config, postgreConn, files, natsConn, logger)
+	go startImageConsumer(ctx, imageConsumer, logger)
+
+	// ===================== File GC =====================
+	// файлы, на которые в БД никто не ссылается (старые аватарки, брошенные загрузки).
+	// Без file_gc.dry_run=false только пишет в лог, что удалил бы
+	if config.FileGC.Enabled {
+		fileGC := initFileGC(config, postgreConn, files, config.FileGC.DryRun, logger)
+		go startFileGC(ctx, fileGC, time.Duration(config.FileGC.Interval)*time.Minute, logger)
+	}
+
+	// ===================== Upload Sweeper =====================
+	// части докачиваемых загрузок, которые так и не завершили
+	uploadSweeper := initUploadSweeper(config, redisConn, files, logger)
+	go startUploadSweeper(ctx, uploadSweeper, time.Duration(config.Upload.Resumable.SweepInterval)*time.Minute, logger)
+
+	// ===================== Centrifugo Publisher =====================
+	// прямые (не через outbox) публикации из параллельных запросов копим и шлём одним pipe
+	batchingPublisher := threadExternal.NewBatchingPublisher(
+		threadExternal.NewCentrifugoPublisher(centrifugoClient, config.Centrifugo.PublishBatchSize),
+		config.Centrifugo.PublishBatchSize,
+		time.Duration(config.Centrifugo.PublishBatchDelayMs)*time.Millisecond,
+	)
+	go batchingPublisher.Run(ctx)
+	// всё, что ушло в Centrifugo, дублируем в NATS — оттуда события раздают SSE-клиентам все инстансы
+	centrifugoPublisher := threadExternal.NewNatsMirrorPublisher(batchingPublisher, natsConn, config.SSE.Subject, logger)
+
+	// ===================== Presence Sweeper =====================
+	presenceSweeper := initPresenceSweeper(config, postgreConn, redisConn, centrifugoPublisher, logger)
+	go startPresenceSweeper(ctx, presenceSweeper, time.Duration(config.Presence.SweepInterval)*time.Second, logger)
+
+	// ===================== Outbox Relay =====================
+	outboxRelay := initOutboxRelay(config, postgreConn, centrifugoClient, natsConn, logger)
+	go startOutboxRelay(ctx, outboxRelay, time.Duration(config.Outbox.PollIntervalMs)*time.Millisecond, logger)
+
 	// ===================== HTTP Server =====================
 	r := chi.NewRouter()
 
@@ -107,7 +159,9 @@ func Run(config *config.Config, logger *zap.Logger) error {
 	r.Use(middleware.RealIP)      // - RealIP: извлекает реальный IP клиента из заголовков (X-Forwarded-For и др.).
 	r.Use(middleware.Recoverer)   // - Recoverer: перехватывает паники в обработчиках и предотвращает падение сервера.
 
-	apiRouter, err := apiRouter(config, postgreConn, redisConn, natsConn, liveKitConn, minioConn, centrifugoClient, logger)
+	// SSE-потоки бесконечные, Shutdown их сам не дождётся — закрываем по сигналу
+	sseShutdown := make(chan struct{})
+	apiRouter, err := apiRouter(config, postgreConn, redisConn, natsConn, liveKitConn, egressConn, files, centrifugoPublisher, outboxRelay, sseShutdown, logger)
 	if err != nil {
 		return err
 	}
@@ -117,6 +171,7 @@ func Run(config *config.Config, logger *zap.Logger) error {
 		Addr:    config.App.Port,
 		Handler: r,
 	}
+	httpServer.RegisterOnShutdown(func() { close(sseShutdown) })
 
 	// Запускаем HTTP сервер
 	go func() {
@@ -132,19 +187,20 @@ func Run(config *config.Config, logger *zap.Logger) error {
 	<-quit
 	logger.Info("shutting down gracefully...")
 
-	cancel()
-
+	// сначала дожидаемся запросов: они ещё публикуют через фоновые воркеры, которые гасит cancel
 	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelShutdown()
 	if err := httpServer.Shutdown(ctxShutdown); err != nil {
 		logger.Error("HTTP server shutdown failed", zap.Error(err))
 	}
 
+	cancel()
+
 	logger.Info("server exited")
 	return nil
 }
 
-func apiRouter(cfg *config.Config, db *gorm.DB, redis *redis.Client, nts *nats.Conn, livekit *livekit.RoomServiceClient, minio *minio.Client, centrifugo *gocent.Client, logger *zap.Logger) (chi.Router, error) {
+func apiRouter(cfg *config.Config, db *gorm.DB, redis *redis.Client, nts *nats.Conn, livekit *livekit.RoomServiceClient, egress *livekit.EgressClient, files *fileStorage, centrifugo threadExternal.PublisherInterface, outboxRelay outboxUsecase.RelayUsecaseInterface, shutdown <-chan struct{}, logger *zap.Logger) (chi.Router, error) {
 	r := chi.NewRouter()
 	// ===================== Auth =====================
 
@@ -171,38 +227,114 @@ func apiRouter(cfg *config.Config, db *gorm.DB, redis *redis.Client, nts *nats.C
 	authHandler := authDeliveryHTTP.NewAuthHandler(authauthUsecase, logger.With(zap.String("component", "auth")), cookieConfig)
 	authHandler.Routes(r)
 	// ===================== File =====================
-	fileRepo := fileExternal.NewFileRepo(minio, cfg.Minio.Bucket)
-	fileUC := fileUsecase.NewFileUsecase(fileRepo, logger)
+	// права на скачивание считаются по тредам и спулам — нужен репозиторий тредов
+	threadRepo := threadExternal.NewThreadRepo(db, logger)
+	// одни метаданные и одни правила доступа на все бакеты, отличается только бакет, куда пишем
+	fileAccess := &fileUsecase.FileAccess{
+		Meta:       fileExternal.NewFileMetaRepo(db),
+		Membership: threadRepo,
+		Buckets:    fileBuckets(cfg),
+		Images:     fileExternal.NewImageJobRepo(nts, cfg.Images.Subject),
+		// те же лимиты, что у ресайзера: что он не переварит, то и при загрузке не чистим
+		ImageMaxPixels: cfg.Images.MaxPixels,
+		ImageMaxBytes:  int64(cfg.Images.MaxSizeMB) << 20,
+		Quota: fileExternal.StorageLimits{
+			UserBytes:  cfg.Quota.UserMB << 20,
+			SpoolBytes: cfg.Quota.SpoolMB << 20,
+		},
+
+		Uploads:        fileExternal.NewRedisUploadSessionRepo(redis),
+		UploadTTL:      time.Duration(cfg.Upload.Resumable.TTL) * time.Hour,
+		UploadPartSize: int64(cfg.Upload.Resumable.PartSizeMB) << 20,
+		Detect:         fileConfig.DetectContentType,
+
+		Scanner:          fileScanner(cfg),
+		ScanFailOpen:     cfg.Scanner.FailOpen,
+		QuarantineBucket: cfg.Scanner.QuarantineBucket,
+		LinkSecret:       []byte(cfg.Files.LinkSecret),
+		LinkTTL:          time.Duration(cfg.Files.LinkTTL) * time.Second,
+		LinkBaseURL:      cfg.Files.LinkBaseURL,
+	}
+	fileRepo := files.Repo(cfg.Minio.Bucket)
+	fileUC := fileUsecase.NewFileUsecase(fileRepo, fileAccess, logger)
+	// скачивание из любого бакета — один роут, бакет в пути
 	fileHandler := fileDeliveryHTTP.NewFileHandler(fileUC, logger)
-	fileHandler.Routes(r)
+	fileHandler.Routes(r, authenticator)
 
 	// ===================== Thread =====================
 	// external repos
-	threadRepo := threadExternal.NewThreadRepo(db, logger)
 	liveKitRepo := threadExternal.NewLiveKitRepo(livekit, cfg.Room.EmptyTTL, cfg.Room.MaxParticipants)
-	websocketRepo := threadExternal.NewWebsocketRepo(
-		centrifugo,               // *gocent.Client
+	directWebsocketRepo := threadExternal.NewWebsocketRepo(
+		centrifugo,               // транспорт до Centrifugo
 		cfg.Centrifugo.TokenHMAC, // JWT secret
 		"threadbook",             // token issuer
 	)
+	// события пишутся в outbox вместе с доменными изменениями, до Centrifugo их довозит релей
+	outboxRepo := outboxExternal.NewOutboxRepo(db)
+	websocketRepo := outboxExternal.NewOutboxWebsocketRepo(outboxRepo, directWebsocketRepo, outboxRelay.Notify)
+	transactor := dbtx.NewTransactor(db)
 	// messages repo
 	messageRepo := threadExternal.NewMessageRepo(db)
+	// присутствие и "печатает" (Redis)
+	presenceRepo := presenceExternal.NewRedisPresenceRepo(
+		redis,
+		time.Duration(cfg.Presence.TTL)*time.Second,
+		time.Duration(cfg.Presence.TypingTTL)*time.Second,
+	)
+	// вложения сообщений живут в отдельном бакете
+	attachmentFileRepo := files.Repo(cfg.Upload.Message.Bucket)
+	attachmentFileUC := fileUsecase.NewFileUsecase(attachmentFileRepo, fileAccess, logger)
 
 	// usecases
-	threadUC := threadUsecase.NewThreadUsecase(threadRepo, websocketRepo, userRepo, time.Duration(cfg.Centrifugo.TTL)*time.Second, logger)
-	messageUC := threadUsecase.NewMessageUsecase(messageRepo, websocketRepo, threadRepo, time.Duration(cfg.Centrifugo.TTL)*time.Second, logger)
-	roomUC := threadUsecase.NewRoomUsecase(threadRepo, liveKitRepo, cfg.LiveKit.URL, cfg.LiveKit.APIKey, cfg.LiveKit.APISecret, logger)
+	threadUC := threadUsecase.NewThreadUsecase(threadRepo, websocketRepo, userRepo, liveKitRepo, transactor, time.Duration(cfg.Centrifugo.TTL)*time.Second, logger)
+	messageUC := threadUsecase.NewMessageUsecase(messageRepo, websocketRepo, threadRepo, transactor, attachmentFileUC, presenceRepo, time.Duration(cfg.Centrifugo.TTL)*time.Second, time.Duration(cfg.Presence.TypingTTL)*time.Second, logger)
+	recordingRepo := threadExternal.NewRecordingRepo(db)
+	// egress заливает записи прямо в бакет вложений, готовый файл прикладывается к сообщению как обычное вложение
+	// без MinIO записи выключены: egress больше никуда писать не умеет
+	var egressRepo threadExternal.EgressInterface
+	if files.IsMinio() {
+		egressRepo = threadExternal.NewLiveKitEgressRepo(egress, threadExternal.EgressS3Target{
+			Endpoint:  recordingS3Endpoint(cfg),
+			Region:    cfg.Recording.Region,
+			AccessKey: cfg.Minio.AccessKey,
+			SecretKey: cfg.Minio.SecretKey,
+			Bucket:    cfg.Upload.Message.Bucket,
+		}, cfg.Recording.AudioOnly)
+	}
+	recordingUC := threadUsecase.NewRecordingUsecase(recordingRepo, threadRepo, liveKitRepo, egressRepo, websocketRepo, messageUC, attachmentFileUC, transactor, logger)
+	roomUC := threadUsecase.NewRoomUsecase(threadRepo, liveKitRepo, cfg.LiveKit.URL, cfg.LiveKit.APIKey, cfg.LiveKit.APISecret, voiceGrants(cfg), logger)
 
 	// handler
-	threadHandler := threadDeliveryHTTP.NewThreadHandler(threadUC, messageUC, roomUC, logger)
+	threadHandler := threadDeliveryHTTP.NewThreadHandler(threadUC, messageUC, roomUC, recordingUC, logger, fileConfig)
 	threadHandler.Routes(r, authenticator)
 
+	// ===================== Realtime (Centrifugo proxy) =====================
+	// connect/subscribe и их refresh проверяются на лету, срок подтверждения — тот же Centrifugo.TTL
+	realtimeUC := realtimeUsecase.NewRealtimeUsecase(sessionRepo, threadRepo, time.Duration(cfg.Centrifugo.TTL)*time.Second, logger)
+	// без секрета connect/subscribe мог бы дёрнуть кто угодно — лучше не стартовать
+	if cfg.Centrifugo.ProxySecret == "" {
+		return nil, fmt.Errorf("centrifugo.proxy_secret is required for centrifugo proxy endpoints")
+	}
+	proxyHandler := realtimeDeliveryHTTP.NewProxyHandler(realtimeUC, cfg.Centrifugo.ProxySecret, logger.With(zap.String("component", "centrifugo_proxy")))
+	proxyHandler.Routes(r)
+
+	// ===================== Realtime (SSE) =====================
+	// для тех, у кого WebSocket до Centrifugo режет прокси; догонялка по Last-Event-ID — из outbox
+	streamUC := realtimeUsecase.NewStreamUsecase(
+		realtimeExternal.NewNatsSubscriber(nts, cfg.SSE.Subject, logger),
+		outboxRepo,
+		threadRepo,
+		cfg.SSE.BufferSize,
+		cfg.SSE.ReplayLimit,
+		logger,
+	)
+	sseHandler := realtimeDeliveryHTTP.NewSSEHandler(streamUC, time.Duration(cfg.SSE.HeartbeatSec)*time.Second, shutdown, logger.With(zap.String("component", "sse")))
+	sseHandler.Routes(r, authenticator)
+
 	// ===================== Profile =====================
 	profileRepo := profileExternal.NewProfileRepo(db)
-	profileFileRepo := fileExternal.NewFileRepo(minio, "avatars")
-	profileFileUC := fileUsecase.NewFileUsecase(profileFileRepo, logger)
-	profileFileHandler := fileDeliveryHTTP.NewFileHandler(profileFileUC, logger)
-	profileFileHandler.Routes(r)
+	profileFileRepo := files.Repo("avatars")
+	profileFileUC := fileUsecase.NewFileUsecase(profileFileRepo, fileAccess, logger)
 
 	profileUC := profileUsecase.NewProfileUsecase(profileRepo, profileFileUC, logger)
 	profileHandler := profileDeliveryHTTP.NewProfileHandler(profileUC, logger, fileConfig)
@@ -210,16 +342,64 @@ func apiRouter(cfg *config.Config, db *gorm.DB, redis *redis.Client, nts *nats.C
 
 	// ===================== Spool =====================
 
-	spoolFileRepo := fileExternal.NewFileRepo(minio, "uploads")
-	spoolFileUC := fileUsecase.NewFileUsecase(spoolFileRepo, logger)
-	spoolFileHandler := fileDeliveryHTTP.NewFileHandler(spoolFileUC, logger)
-	spoolFileHandler.Routes(r)
+	spoolFileRepo := files.Repo("uploads")
+	spoolFileUC := fileUsecase.NewFileUsecase(spoolFileRepo, fileAccess, logger)
 
 	spoolRepo := spoolExternal.NewSpoolRepo(db)
-	spoolUC := spoolUsecase.NewSpoolUsecase(spoolRepo, websocketRepo, spoolFileUC, logger)
+	spoolUC := spoolUsecase.NewSpoolUsecase(spoolRepo, websocketRepo, presenceRepo, spoolFileUC, logger)
 	spoolHandler := spoolDeliveryHTTP.NewSpoolHandler(spoolUC, logger, fileConfig)
 	spoolHandler.Routes(r, authenticator)
+
+	// ===================== Presence =====================
+	// онлайн/офлайн устаревает быстрее, чем релей успел бы повторить, — шлём напрямую
+	presenceUC := presenceUsecase.NewPresenceUsecase(presenceRepo, spoolRepo, directWebsocketRepo, logger)
+	presenceHandler := presenceDeliveryHTTP.NewPresenceHandler(presenceUC, logger)
+	presenceHandler.Routes(r, authenticator)
+
+	// ===================== Voice presence (LiveKit webhooks) =====================
+	voiceRepo := presenceExternal.NewRedisVoiceRepo(redis)
+	voiceUC := presenceUsecase.NewVoiceUsecase(voiceRepo, threadRepo, websocketRepo, logger)
+	voiceHandler := presenceDeliveryHTTP.NewVoiceHandler(voiceUC, recordingUC, cfg.LiveKit.APIKey, cfg.LiveKit.APISecret, logger.With(zap.String("component", "voice")))
+	voiceHandler.Routes(r, authenticator)
 	// ===================== Other =====================
 
 	return r, nil
 }
+
+// recordingS3Endpoint — адрес MinIO для egress: явный из конфига или тот же, что у нас
+func recordingS3Endpoint(cfg *config.Config) string {
+	if cfg.Recording.S3Endpoint != "" {
+		return cfg.Recording.S3Endpoint
+	}
+	scheme := "http"
+	if cfg.Minio.UseSSL {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, cfg.Minio.Host, cfg.Minio.Port)
+}
+
+// defaultVoiceTokenTTL — если в конфиге TTL токена обнулили или ушёл в минус
+const defaultVoiceTokenTTL = time.Hour
+
+// voiceGrants — права в голосовых комнатах по ролям спула из конфига
+func voiceGrants(cfg *config.Config) threadUsecase.VoiceGrants {
+	toGrant := func(rc config.VoiceRoleConfig) threadUsecase.VoiceRoleGrant {
+		ttl := time.Duration(rc.TokenTTL) * time.Second
+		if ttl <= 0 {
+			// с нулевым TTL токен протухает сразу, с отрицательным LiveKit его вообще не примет
+			ttl = defaultVoiceTokenTTL
+		}
+		return threadUsecase.VoiceRoleGrant{
+			TokenTTL: ttl,
+			Sources:  rc.Sources,
+		}
+	}
+	return threadUsecase.VoiceGrants{
+		Roles: map[gdomain.SpoolRole]threadUsecase.VoiceRoleGrant{
+			gdomain.SpoolRoleOwner:     toGrant(cfg.Room.Roles.Owner),
+			gdomain.SpoolRoleModerator: toGrant(cfg.Room.Roles.Moderator),
+			gdomain.SpoolRoleMember:    toGrant(cfg.Room.Roles.Member),
+		},
+		StageMinMembers: cfg.Room.StageMinMembers,
+	}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/config"
+	fileExternal "github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// initUploadSweeper — докачиваемые загрузки бывают только у вложений, их бакет и чистим
+func initUploadSweeper(cfg *config.Config, rdb *redis.Client, files *fileStorage, logger *zap.Logger) fileUsecase.FileUsecaseInterface {
+	access := &fileUsecase.FileAccess{
+		Uploads:   fileExternal.NewRedisUploadSessionRepo(rdb),
+		UploadTTL: time.Duration(cfg.Upload.Resumable.TTL) * time.Hour,
+	}
+	return fileUsecase.NewFileUsecase(files.Repo(cfg.Upload.Message.Bucket), access, logger.With(zap.String("component", "upload_sweeper")))
+}
+
+// startUploadSweeper раз в interval бросает в хранилище загрузки, чьи сессии уже истекли.
+// Несколько инстансов друг другу не мешают: брошенную загрузку второй раз просто не найдут
+func startUploadSweeper(ctx context.Context, uc fileUsecase.FileUsecaseInterface, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			aborted, err := uc.CleanupUploads(ctx)
+			if err != nil {
+				logger.Warn("upload sweep failed", zap.Error(err))
+				continue
+			}
+			if aborted > 0 {
+				logger.Info("abandoned uploads aborted", zap.Int("count", aborted))
+			}
+		}
+	}
+}
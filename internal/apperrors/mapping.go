@@ -5,7 +5,10 @@ import (
 	"net/http"
 
 	authUsecase "github.com/onionfriend2004/threadbook_backend/internal/auth/usecase"
+	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	presenceUsecase "github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	realtimeUsecase "github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
 	spoolUsecase "github.com/onionfriend2004/threadbook_backend/internal/spool/usecase"
 	threadUsecase "github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
 )
@@ -24,7 +27,53 @@ var errToCode = map[error]int{
 	threadUsecase.ErrInvalidInput:       http.StatusBadRequest,          // 400 — некорректные входные данные
 	threadUsecase.ErrFaildToEnsureRoom:  http.StatusInternalServerError, // 500 — ошибка при создании/проверке комнаты
 	threadUsecase.ErrNoRightsOnJoinRoom: http.StatusForbidden,           // 403 — нет прав для входа в комнату потока
-	threadUsecase.ErrWrognTypeThread:    http.StatusBadRequest,          // 400 — неверный тип потока
+	threadUsecase.ErrNotThreadModerator: http.StatusForbidden,           // 403 — модерировать комнату может только создатель треда/спула
+	threadUsecase.ErrNotInVoiceRoom:     http.StatusNotFound,            // 404 — такого участника нет в голосовой комнате
+
+	threadUsecase.ErrRecordingInProgress:  http.StatusConflict,       // 409 — запись в треде уже идёт
+	threadUsecase.ErrNoActiveRecording:    http.StatusConflict,       // 409 — останавливать нечего
+	threadUsecase.ErrVoiceRoomEmpty:       http.StatusConflict,       // 409 — в комнате никого, писать нечего
+	threadUsecase.ErrRecordingUnavailable: http.StatusNotImplemented, // 501 — файлы не в MinIO, egress писать некуда
+	threadUsecase.ErrWrognTypeThread:      http.StatusBadRequest,     // 400 — неверный тип потока
+
+	threadUsecase.ErrReplyTargetNotFound:  http.StatusNotFound,   // 404 — сообщение для ответа не найдено
+	threadUsecase.ErrReplyToAnotherThread: http.StatusBadRequest, // 400 — ответ на сообщение из другого треда
+
+	threadUsecase.ErrNoAccessToThread:   http.StatusForbidden,  // 403 — пользователь не участник треда
+	threadUsecase.ErrThreadClosed:       http.StatusConflict,   // 409 — тред закрыт
+	threadUsecase.ErrEmptyMessage:       http.StatusBadRequest, // 400 — пустое сообщение без вложений
+	threadUsecase.ErrAttachmentNotFound: http.StatusNotFound,   // 404 — вложение не найдено или уже прикреплено
+	threadUsecase.ErrTooManyAttachments: http.StatusBadRequest, // 400 — слишком много вложений
+
+	threadUsecase.ErrMessageNotFound: http.StatusNotFound,   // 404 — сообщение не найдено в треде
+	threadUsecase.ErrInvalidCursor:   http.StatusBadRequest, // 400 — битый курсор пагинации
+
+	// --- Ошибки file ---
+	fileUsecase.ErrInvalidInput:  http.StatusBadRequest,            // 400 — нет имени файла или кривой размер
+	fileUsecase.ErrFileNotFound:  http.StatusNotFound,              // 404 — файла нет или бакет не из белого списка
+	fileUsecase.ErrAccessDenied:  http.StatusForbidden,             // 403 — файл чужого треда или спула
+	fileUsecase.ErrLinksDisabled: http.StatusNotImplemented,        // 501 — подписанные ссылки не настроены
+	fileUsecase.ErrFileInfected:  http.StatusUnprocessableEntity,   // 422 — антивирус нашёл заражение, файл в карантине
+	fileUsecase.ErrScanFailed:    http.StatusServiceUnavailable,    // 503 — антивирус недоступен, загрузка не принята
+	fileUsecase.ErrQuotaExceeded: http.StatusRequestEntityTooLarge, // 413 — у пользователя или спула кончилось место
+	fileUsecase.ErrImageDecode:   http.StatusBadRequest,            // 400 — аватарка или баннер не читается как картинка
+	fileUsecase.ErrImageTooLarge: http.StatusRequestEntityTooLarge, // 413 — картинку не вычистить от EXIF, слишком большая
+
+	fileUsecase.ErrUploadsDisabled:      http.StatusNotImplemented, // 501 — докачиваемые загрузки не настроены
+	fileUsecase.ErrUploadNotFound:       http.StatusNotFound,       // 404 — загрузка истекла, завершена или чужая
+	fileUsecase.ErrUploadBusy:           http.StatusConflict,       // 409 — в загрузку уже пишет другой запрос
+	fileUsecase.ErrUploadOffsetMismatch: http.StatusConflict,       // 409 — клиент отстал или забежал вперёд, надо спросить смещение
+	fileUsecase.ErrUploadIncomplete:     http.StatusConflict,       // 409 — завершать рано, приняты не все байты
+	fileUsecase.ErrInvalidPart:          http.StatusBadRequest,     // 400 — часть не того размера
+	fileUsecase.ErrContentTypeMismatch:  http.StatusBadRequest,     // 400 — содержимое не совпало с расширением
+
+	// --- Ошибки presence ---
+	presenceUsecase.ErrInvalidInput:     http.StatusBadRequest, // 400 — нет пользователя или сессии
+	presenceUsecase.ErrNoAccessToThread: http.StatusForbidden,  // 403 — голос чужого треда
+	presenceUsecase.ErrNoAccessToSpool:  http.StatusForbidden,  // 403 — голос чужого спула
+
+	// --- Ошибки realtime (SSE) ---
+	realtimeUsecase.ErrInvalidInput: http.StatusBadRequest, // 400 — нет пользователя
 
 	// --- Ошибки auth ---
 	authUsecase.ErrUserNotFound:       http.StatusNotFound,     // 404 — пользователь не найден
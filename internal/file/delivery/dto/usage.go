@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// UsageResponse — занятое место в байтах; limit_bytes 0 — без лимита
+type UsageResponse struct {
+	Bytes      int64 `json:"bytes"`
+	Files      int64 `json:"files"`
+	LimitBytes int64 `json:"limit_bytes"`
+}
+
+type UsageByKind struct {
+	Kind  string `json:"kind"`
+	Bytes int64  `json:"bytes"`
+	Files int64  `json:"files"`
+}
+
+type UsageByUploader struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Bytes    int64  `json:"bytes"`
+	Files    int64  `json:"files"`
+}
+
+type UsageFile struct {
+	Bucket      string    `json:"bucket"`
+	Key         string    `json:"key"`
+	Kind        string    `json:"kind"`
+	OwnerID     uint      `json:"owner_id"`
+	ThreadID    *uint     `json:"thread_id,omitempty"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SpoolUsageResponse struct {
+	UsageResponse
+	ByKind     []UsageByKind     `json:"by_kind"`
+	ByUploader []UsageByUploader `json:"by_uploader"`
+	Largest    []UsageFile       `json:"largest"`
+}
@@ -3,13 +3,13 @@ package deliveryHTTP
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
 	"go.uber.org/zap"
 )
 
 type FileHandler struct {
 	usecase usecase.FileUsecaseInterface
 	logger  *zap.Logger
-	bucket  string
 }
 
 func NewFileHandler(u usecase.FileUsecaseInterface, logger *zap.Logger) *FileHandler {
@@ -19,6 +19,10 @@ func NewFileHandler(u usecase.FileUsecaseInterface, logger *zap.Logger) *FileHan
 	}
 }
 
-func (h *FileHandler) Routes(r chi.Router) {
-	r.Get("/uploads/{bucket}/{filename}", h.GetFile)
+func (h *FileHandler) Routes(r chi.Router, authenticator auth.AuthenticatorInterface) {
+	// ключ может быть с папками (recordings/thread_1/...), поэтому хвост целиком
+	r.With(h.signedOrAuth(authenticator)).Get("/uploads/{bucket}/*", h.GetFile)
+	r.With(auth.AuthMiddleware(authenticator)).Get("/files/link", h.GetFileLink)
+	r.With(auth.AuthMiddleware(authenticator)).Get("/files/usage", h.GetUsage)
+	r.With(auth.AuthMiddleware(authenticator)).Get("/files/usage/spools/{spoolID}", h.GetSpoolUsage)
 }
@@ -1,53 +1,98 @@
 package deliveryHTTP
 
 import (
+	"mime"
 	"net/http"
-	"net/url"
+	"path"
+	"strconv"
+	"strings"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
 	"github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
 	"go.uber.org/zap"
 )
 
-func (h *FileHandler) GetFile(w http.ResponseWriter, r *http.Request) {
-	filename := chi.URLParam(r, "filename")
-	filename, err := url.PathUnescape(filename)
-	if err != nil {
-		lib.WriteError(w, "invalid file path", http.StatusBadRequest)
-		return
-	}
+// fileCacheControl — имена объектов уникальные (uuid) и не перезаписываются, так что кэшировать можно навсегда.
+// private — чтобы вложения не оседали в общих прокси
+const fileCacheControl = "private, max-age=31536000, immutable"
 
-	bucket := chi.URLParam(r, "bucket")
-	bucket, err = url.PathUnescape(bucket)
+// GetFile отдаёт объект потоком из MinIO. Range/206, If-None-Match, If-Modified-Since
+// и Content-Length делает http.ServeContent, нам остаётся выставить заголовки
+func (h *FileHandler) GetFile(w http.ResponseWriter, r *http.Request) {
+	bucket, filename, err := objectFromRequest(r)
 	if err != nil {
 		lib.WriteError(w, "invalid file path", http.StatusBadRequest)
 		return
 	}
 
-	input := usecase.GetFileInput{Filename: filename, Bucket: bucket}
+	input := usecase.GetFileInput{Filename: filename, Bucket: bucket, Signed: isSignedRequest(r)}
 	if input.Filename == "" || input.Bucket == "" {
 		lib.WriteError(w, "filename required", http.StatusBadRequest)
 		return
 	}
+	// ?size=128 — уменьшенная копия картинки, если она есть
+	if s := r.URL.Query().Get("size"); s != "" {
+		input.Size, err = strconv.Atoi(s)
+		if err != nil || input.Size <= 0 {
+			lib.WriteError(w, "invalid size", http.StatusBadRequest)
+			return
+		}
+	}
+	if !input.Signed {
+		input.UserID, err = auth.GetUserIDFromContext(r.Context())
+		if err != nil {
+			lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
 
-	data, contentType, err := h.usecase.GetFile(r.Context(), input)
+	obj, err := h.usecase.GetFile(r.Context(), input)
 	if err != nil {
-		h.logger.Error("failed to get file", zap.Error(err))
-		switch err {
-		case usecase.ErrInvalidInput:
-			lib.WriteError(w, err.Error(), http.StatusBadRequest)
-		case usecase.ErrFileNotFound:
-			lib.WriteError(w, err.Error(), http.StatusNotFound)
-		default:
-			lib.WriteError(w, "internal server error", http.StatusInternalServerError)
-		}
+		h.logger.Warn("failed to get file", zap.Error(err))
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		lib.WriteError(w, clientErr.Error(), code)
 		return
 	}
+	defer obj.Close()
+
+	contentType := obj.Info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
 
-	w.Header().Set("Content-Type", contentType)
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(data); err != nil {
-		h.logger.Warn("failed to write response", zap.Error(err))
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", contentDisposition(contentType, path.Base(obj.Key)))
+	if obj.Exact {
+		header.Set("Cache-Control", fileCacheControl)
+	} else {
+		// копия ещё режется — пусть браузер переспросит, а не держит оригинал вместо неё год
+		header.Set("Cache-Control", "private, no-cache")
+	}
+	header.Set("X-Content-Type-Options", "nosniff")
+	if obj.Info.ETag != "" {
+		header.Set("ETag", `"`+strings.Trim(obj.Info.ETag, `"`)+`"`)
+	}
+
+	http.ServeContent(w, r, obj.Key, obj.Info.LastModified, obj)
+}
+
+// contentDisposition — картинки, видео, звук и pdf показываем в браузере, остальное только скачиваем,
+// чтобы загруженный html не исполнился на нашем домене
+func contentDisposition(contentType, filename string) string {
+	disposition := "attachment"
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml",
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		mediaType == "application/pdf":
+		disposition = "inline"
+	}
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); v != "" {
+		return v
 	}
+	return disposition
 }
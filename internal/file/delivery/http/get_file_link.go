@@ -0,0 +1,48 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/file/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"go.uber.org/zap"
+)
+
+// GetFileLink — короткоживущая ссылка на файл: ?bucket=&key=, direct=true — presigned URL прямо в MinIO
+func (h *FileHandler) GetFileLink(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	input := usecase.GetFileLinkInput{
+		UserID:   userID,
+		Bucket:   q.Get("bucket"),
+		Filename: q.Get("key"),
+		Direct:   q.Get("direct") == "true",
+	}
+	if input.Bucket == "" || input.Filename == "" {
+		lib.WriteError(w, "bucket and key required", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.usecase.GetFileLink(r.Context(), input)
+	if err != nil {
+		h.logger.Warn("failed to get file link", zap.Error(err))
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(dto.FileLinkResponse{URL: link.URL, ExpiresAt: link.ExpiresAt}); err != nil {
+		h.logger.Warn("failed to encode file link response", zap.Error(err))
+	}
+}
@@ -0,0 +1,69 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/file/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"go.uber.org/zap"
+)
+
+// GetSpoolUsage — на что ушло место спула: по видам файлов, по загрузившим и самые большие файлы.
+// Только для владельца спула
+func (h *FileHandler) GetSpoolUsage(w http.ResponseWriter, r *http.Request) {
+	spoolID, err := strconv.ParseUint(chi.URLParam(r, "spoolID"), 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid spool id", http.StatusBadRequest)
+		return
+	}
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := h.usecase.GetSpoolUsage(r.Context(), usecase.GetSpoolUsageInput{UserID: userID, SpoolID: uint(spoolID)})
+	if err != nil {
+		h.logger.Warn("failed to get spool storage usage", zap.Error(err))
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.SpoolUsageResponse{
+		UsageResponse: dto.UsageResponse{Bytes: usage.Bytes, Files: usage.Files, LimitBytes: usage.LimitBytes},
+		ByKind:        make([]dto.UsageByKind, 0, len(usage.ByKind)),
+		ByUploader:    make([]dto.UsageByUploader, 0, len(usage.ByOwner)),
+		Largest:       make([]dto.UsageFile, 0, len(usage.Largest)),
+	}
+	for _, k := range usage.ByKind {
+		resp.ByKind = append(resp.ByKind, dto.UsageByKind{Kind: k.Kind, Bytes: k.Bytes, Files: k.Files})
+	}
+	for _, o := range usage.ByOwner {
+		resp.ByUploader = append(resp.ByUploader, dto.UsageByUploader{UserID: o.OwnerID, Username: o.Username, Bytes: o.Bytes, Files: o.Files})
+	}
+	for _, f := range usage.Largest {
+		resp.Largest = append(resp.Largest, dto.UsageFile{
+			Bucket:      f.Bucket,
+			Key:         f.ObjectKey,
+			Kind:        f.Kind,
+			OwnerID:     f.OwnerID,
+			ThreadID:    f.ThreadID,
+			ContentType: f.ContentType,
+			Size:        f.Size,
+			CreatedAt:   f.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode spool usage response", zap.Error(err))
+	}
+}
@@ -0,0 +1,36 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/file/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"go.uber.org/zap"
+)
+
+// GetUsage — сколько места занимают мои файлы и сколько можно
+func (h *FileHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	usage, err := h.usecase.GetUsage(r.Context(), userID)
+	if err != nil {
+		h.logger.Warn("failed to get storage usage", zap.Error(err))
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	resp := dto.UsageResponse{Bytes: usage.Bytes, Files: usage.Files, LimitBytes: usage.LimitBytes}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode usage response", zap.Error(err))
+	}
+}
@@ -0,0 +1,59 @@
+package deliveryHTTP
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+)
+
+type signedLinkKey struct{}
+
+// signedOrAuth — по ссылке с подписью пускаем без куки (плеер, <img> на другом домене),
+// иначе обычная авторизация по сессии
+func (h *FileHandler) signedOrAuth(authenticator auth.AuthenticatorInterface) func(http.Handler) http.Handler {
+	withAuth := auth.AuthMiddleware(authenticator)
+	return func(next http.Handler) http.Handler {
+		authed := withAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			sig := q.Get("sig")
+			if sig == "" {
+				authed.ServeHTTP(w, r)
+				return
+			}
+
+			bucket, filename, err := objectFromRequest(r)
+			if err != nil {
+				lib.WriteError(w, "invalid file path", http.StatusBadRequest)
+				return
+			}
+			if !h.usecase.VerifyLink(bucket, filename, q.Get("exp"), sig) {
+				lib.WriteError(w, "invalid or expired link", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), signedLinkKey{}, true)))
+		})
+	}
+}
+
+func isSignedRequest(r *http.Request) bool {
+	signed, _ := r.Context().Value(signedLinkKey{}).(bool)
+	return signed
+}
+
+// objectFromRequest — бакет и ключ объекта из /uploads/{bucket}/*
+func objectFromRequest(r *http.Request) (string, string, error) {
+	bucket, err := url.PathUnescape(chi.URLParam(r, "bucket"))
+	if err != nil {
+		return "", "", err
+	}
+	filename, err := url.PathUnescape(chi.URLParam(r, "*"))
+	if err != nil {
+		return "", "", err
+	}
+	return bucket, filename, nil
+}
@@ -0,0 +1,94 @@
+package deliveryNATS
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/nats-io/nats.go"
+	"github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"go.uber.org/zap"
+)
+
+// imageQueue — очередь NATS: каждую картинку режет один инстанс, а не все сразу
+const imageQueue = "file-images"
+
+// imageJobTimeout — сколько даём на одну картинку вместе со скачиванием и заливкой копий
+const imageJobTimeout = 2 * time.Minute
+
+type ImageConsumerInterface interface {
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type imageConsumer struct {
+	nc       *nats.Conn
+	subject  string
+	usecase  usecase.ImageUsecaseInterface
+	logger   *zap.Logger
+	quitChan chan struct{}
+}
+
+func NewImageConsumer(
+	nc *nats.Conn,
+	subject string,
+	usecase usecase.ImageUsecaseInterface,
+	logger *zap.Logger,
+) ImageConsumerInterface {
+	return &imageConsumer{
+		nc:       nc,
+		subject:  subject,
+		usecase:  usecase,
+		logger:   logger,
+		quitChan: make(chan struct{}),
+	}
+}
+
+// Блокирующий метод — запускать в отдельной горутине.
+func (c *imageConsumer) Start(ctx context.Context) error {
+	sub, err := c.nc.QueueSubscribe(c.subject, imageQueue, func(msg *nats.Msg) {
+		c.handleMessage(ctx, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to NATS subject %s: %w", c.subject, err)
+	}
+	defer sub.Unsubscribe()
+
+	c.logger.Info("image consumer started", zap.String("subject", c.subject))
+
+	select {
+	case <-ctx.Done():
+		c.logger.Info("image consumer stopped by context")
+		return nil
+	case <-c.quitChan:
+		c.logger.Info("image consumer stopped manually")
+		return nil
+	}
+}
+
+func (c *imageConsumer) Stop() {
+	close(c.quitChan)
+}
+
+func (c *imageConsumer) handleMessage(ctx context.Context, msg *nats.Msg) {
+	var job gdomain.ImageJob
+	if err := json.Unmarshal(msg.Data, &job); err != nil {
+		c.logger.Error("failed to unmarshal image job", zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, imageJobTimeout)
+	defer cancel()
+
+	// не вышло — остаётся оригинал, его и будут отдавать вместо копий
+	if err := c.usecase.ProcessImage(ctx, job); err != nil {
+		c.logger.Error("failed to process image",
+			zap.String("bucket", job.Bucket),
+			zap.String("object_key", job.ObjectKey),
+			zap.Error(err))
+	}
+}
+
+var _ ImageConsumerInterface = (*imageConsumer)(nil)
@@ -0,0 +1,127 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamavChunkSize — кусками такого размера шлём поток в clamd (INSTREAM)
+const clamavChunkSize = 64 << 10
+
+// clamavScanner — клиент clamd по его сокетному протоколу: zINSTREAM, куски с длиной, нулевой кусок в конце
+type clamavScanner struct {
+	network   string
+	address   string
+	timeout   time.Duration
+	chunkSize int
+}
+
+// NewClamAVScanner — address вида "127.0.0.1:3310" (tcp) или "/run/clamav/clamd.ctl" (unix-сокет)
+func NewClamAVScanner(address string, timeout time.Duration) ScannerInterface {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+	return &clamavScanner{
+		network:   network,
+		address:   address,
+		timeout:   timeout,
+		chunkSize: clamavChunkSize,
+	}
+}
+
+func (s *clamavScanner) Scan(ctx context.Context, r io.Reader) (*ScanResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrScan, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if writeErr := s.stream(conn, r); writeErr != nil {
+		// clamd рвёт поток, когда файл больше его StreamMaxLength, — причину он успевает написать в ответ
+		if reply, err := readClamdReply(conn); err == nil {
+			if _, parseErr := parseClamdReply(reply); parseErr != nil {
+				return nil, parseErr
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", ErrScan, writeErr)
+	}
+
+	reply, err := readClamdReply(conn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrScan, err)
+	}
+	return parseClamdReply(reply)
+}
+
+func (s *clamavScanner) stream(conn net.Conn, r io.Reader) error {
+	w := bufio.NewWriterSize(conn, s.chunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return err
+	}
+
+	buf := make([]byte, s.chunkSize)
+	var size [4]byte
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, werr := w.Write(size[:]); werr != nil {
+				return werr
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// кусок нулевой длины — конец потока
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// readClamdReply — в z-режиме ответ заканчивается нулевым байтом
+func readClamdReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(err == io.EOF && reply != "") {
+		return "", err
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseClamdReply — "stream: OK", "stream: <сигнатура> FOUND" или "<причина> ERROR"
+func parseClamdReply(reply string) (*ScanResult, error) {
+	body := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case body == "OK":
+		return &ScanResult{}, nil
+	case strings.HasSuffix(body, " FOUND"):
+		return &ScanResult{Infected: true, Signature: strings.TrimSuffix(body, " FOUND")}, nil
+	case strings.HasSuffix(body, " ERROR"):
+		return nil, fmt.Errorf("%s: clamd: %s", ErrScan, strings.TrimSuffix(body, " ERROR"))
+	default:
+		return nil, fmt.Errorf("%s: unexpected clamd reply %q", ErrScan, reply)
+	}
+}
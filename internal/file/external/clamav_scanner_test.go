@@ -0,0 +1,212 @@
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eicar — стандартная тестовая "сигнатура", на неё срабатывает любой антивирус
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd — clamd в миниатюре: принимает INSTREAM и ищет в потоке EICAR
+type fakeClamd struct {
+	listener  net.Listener
+	maxStream int // больше — отвечаем как clamd при превышении StreamMaxLength; 0 — без лимита
+	chunks    chan int
+}
+
+func newFakeClamd(t *testing.T, network, address string) *fakeClamd {
+	t.Helper()
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeClamd{listener: l, chunks: make(chan int, 1024)}
+	t.Cleanup(func() { l.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	if cmd != "zINSTREAM\x00" {
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var data bytes.Buffer
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		n := int(binary.BigEndian.Uint32(size[:]))
+		if n == 0 {
+			break
+		}
+		f.chunks <- n
+		if f.maxStream > 0 && data.Len()+n > f.maxStream {
+			io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+		if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+			return
+		}
+	}
+
+	if bytes.Contains(data.Bytes(), []byte(eicar)) {
+		io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+		return
+	}
+	io.WriteString(conn, "stream: OK\x00")
+}
+
+func (f *fakeClamd) chunkCount() int {
+	n := 0
+	for {
+		select {
+		case <-f.chunks:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+func TestClamAVScannerClean(t *testing.T) {
+	daemon := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	scanner := NewClamAVScanner(daemon.listener.Addr().String(), 5*time.Second)
+
+	res, err := scanner.Scan(context.Background(), strings.NewReader("just a picture"))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if res.Infected {
+		t.Errorf("clean file reported as infected: %+v", res)
+	}
+}
+
+func TestClamAVScannerInfected(t *testing.T) {
+	daemon := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	scanner := NewClamAVScanner(daemon.listener.Addr().String(), 5*time.Second)
+
+	res, err := scanner.Scan(context.Background(), strings.NewReader("prefix "+eicar+" suffix"))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !res.Infected || res.Signature != "Eicar-Test-Signature" {
+		t.Errorf("result = %+v, want Eicar-Test-Signature", res)
+	}
+}
+
+func TestClamAVScannerStreamsInChunks(t *testing.T) {
+	daemon := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	scanner := NewClamAVScanner(daemon.listener.Addr().String(), 5*time.Second).(*clamavScanner)
+	scanner.chunkSize = 1024
+
+	// сигнатура на стыке кусков — clamd склеивает поток, и мы не должны его резать иначе
+	body := strings.Repeat("a", 1000) + eicar + strings.Repeat("b", 3000)
+	res, err := scanner.Scan(context.Background(), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !res.Infected {
+		t.Error("signature split across chunks was not found")
+	}
+	if got, want := daemon.chunkCount(), (len(body)+1023)/1024; got != want {
+		t.Errorf("chunks = %d, want %d", got, want)
+	}
+}
+
+func TestClamAVScannerSizeLimit(t *testing.T) {
+	daemon := newFakeClamd(t, "tcp", "127.0.0.1:0")
+	daemon.maxStream = 100
+	scanner := NewClamAVScanner(daemon.listener.Addr().String(), 5*time.Second)
+
+	_, err := scanner.Scan(context.Background(), strings.NewReader(strings.Repeat("x", 500)))
+	if err == nil || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Errorf("err = %v, want size limit error", err)
+	}
+}
+
+func TestClamAVScannerUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "clamd.sock")
+	newFakeClamd(t, "unix", sock)
+	scanner := NewClamAVScanner(sock, 5*time.Second)
+
+	res, err := scanner.Scan(context.Background(), strings.NewReader(eicar))
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if !res.Infected {
+		t.Error("infected file over unix socket reported as clean")
+	}
+}
+
+func TestClamAVScannerDaemonDown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	scanner := NewClamAVScanner(addr, time.Second)
+	if _, err := scanner.Scan(context.Background(), strings.NewReader("x")); err == nil {
+		t.Error("scan against a dead daemon must fail, not pass the file")
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		infected  bool
+		signature string
+		wantErr   bool
+	}{
+		{reply: "stream: OK"},
+		{reply: "stream: Win.Test.EICAR_HDB-1 FOUND", infected: true, signature: "Win.Test.EICAR_HDB-1"},
+		{reply: "INSTREAM size limit exceeded. ERROR", wantErr: true},
+		{reply: "garbage", wantErr: true},
+	}
+	for _, tt := range tests {
+		res, err := parseClamdReply(tt.reply)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error", tt.reply)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.reply, err)
+			continue
+		}
+		if res.Infected != tt.infected || res.Signature != tt.signature {
+			t.Errorf("%q: got %+v", tt.reply, res)
+		}
+	}
+}
@@ -1,9 +1,42 @@
 package external
 
+import "errors"
+
+var (
+	ErrObjectNotFound   = errors.New("object not found")
+	ErrFileMetaNotFound = errors.New("file metadata not found")
+	// ErrPresignNotSupported — у хранилища нет своих ссылок (диск, память)
+	ErrPresignNotSupported = errors.New("presigned urls are not supported by this storage")
+	ErrInvalidObjectKey    = errors.New("invalid object key")
+	// ErrQuotaExceeded — с этим файлом владелец или спул вылезают за лимит места
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	// ErrUploadNotFound — multipart-загрузку уже завершили, бросили или её не было
+	ErrUploadNotFound = errors.New("multipart upload not found")
+	// ErrInvalidPart — части при завершении не совпали с принятыми
+	ErrInvalidPart = errors.New("invalid multipart part")
+	// ErrUploadSessionNotFound — в Redis нет такой загрузки (истекла или завершена)
+	ErrUploadSessionNotFound = errors.New("upload session not found")
+)
+
 var (
-	ErrGetObject    = "failed to get object"
-	ErrReadObject   = "failed to read object"
-	ErrStatObject   = "failed to stat object"
-	ErrPutObject    = "failed to put object"
-	ErrRemoveObject = "failed to remove object"
+	ErrGetObject     = "failed to get object"
+	ErrReadObject    = "failed to read object"
+	ErrStatObject    = "failed to stat object"
+	ErrPutObject     = "failed to put object"
+	ErrRemoveObject  = "failed to remove object"
+	ErrPresignObject = "failed to presign object"
+	ErrListObjects   = "failed to list objects"
+
+	ErrNewMultipart      = "failed to start multipart upload"
+	ErrPutPart           = "failed to put part"
+	ErrCompleteMultipart = "failed to complete multipart upload"
+	ErrAbortMultipart    = "failed to abort multipart upload"
+	ErrListMultipart     = "failed to list multipart uploads"
+
+	ErrEnqueueImageJob = "failed to enqueue image job"
+
+	ErrCheckBucket = "failed to check bucket"
+	ErrMakeBucket  = "failed to create bucket"
+
+	ErrScan = "failed to scan file"
 )
@@ -0,0 +1,53 @@
+package external
+
+import (
+	"context"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+// FileMetaRepoInterface — таблица files: кто и куда загрузил объект.
+// Вместе со строками двигаются счётчики места (storage_usages)
+type FileMetaRepoInterface interface {
+	// Create — повторная запись того же bucket/key ничего не меняет. Лимиты не проверяет:
+	// так заводим то, что уже лежит в хранилище (записи звонков, копии картинок)
+	Create(ctx context.Context, f *gdomain.File) error
+	// CreateWithinQuota — как Create, но ErrQuotaExceeded, если файл не влезает в лимиты
+	CreateWithinQuota(ctx context.Context, f *gdomain.File, limits StorageLimits) error
+	// Get — ErrFileMetaNotFound, если про объект ничего не знаем
+	Get(ctx context.Context, bucket, objectKey string) (*gdomain.File, error)
+	AttachToMessage(ctx context.Context, bucket string, objectKeys []string, messageID uint) error
+	Delete(ctx context.Context, bucket, objectKey string) error
+	// ListVariants — уменьшенные копии картинки, от меньшей к большей
+	ListVariants(ctx context.Context, parentID uint) ([]gdomain.File, error)
+
+	// GetUsage — счётчик пользователя или спула; нулевой, если файлов ещё не было
+	GetUsage(ctx context.Context, scope string, scopeID uint) (*gdomain.StorageUsage, error)
+	// SpoolUsageBreakdown — на что в спуле ушло место: по видам файлов, по загрузившим и самые большие файлы
+	SpoolUsageBreakdown(ctx context.Context, spoolID uint, limit int) (*SpoolUsageBreakdown, error)
+}
+
+// StorageLimits — сколько байт можно занять; 0 — без лимита
+type StorageLimits struct {
+	UserBytes  int64
+	SpoolBytes int64
+}
+
+type UsageByKind struct {
+	Kind  string
+	Bytes int64
+	Files int64
+}
+
+type UsageByOwner struct {
+	OwnerID  uint
+	Username string
+	Bytes    int64
+	Files    int64
+}
+
+type SpoolUsageBreakdown struct {
+	ByKind  []UsageByKind
+	ByOwner []UsageByOwner
+	Largest []gdomain.File
+}
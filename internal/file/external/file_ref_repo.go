@@ -0,0 +1,9 @@
+package external
+
+import "context"
+
+// FileRefRepoInterface — кто в Postgres ссылается на объекты: профили, спулы, вложения, записи звонков
+type FileRefRepoInterface interface {
+	// Referenced — какие из ключей бакета ещё нужны. Копия картинки нужна, пока нужен её оригинал
+	Referenced(ctx context.Context, bucket string, keys []string) (map[string]bool, error)
+}
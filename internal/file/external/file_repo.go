@@ -2,12 +2,89 @@ package external
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Хранилища файлов — выбираются в конфиге (storage.backend)
+const (
+	StorageMinio  = "minio"
+	StorageLocal  = "local"  // каталог на диске, для dev и установок на одну машину
+	StorageMemory = "memory" // всё в памяти процесса, для тестов
 )
 
 type FileRepoInterface interface {
-	GetFile(ctx context.Context, bucket, filename string) ([]byte, string, error)
+	// GetFile открывает объект на чтение, ничего не скачивая заранее. Закрыть — на вызывающем
+	GetFile(ctx context.Context, bucket, filename string) (*FileObject, error)
 	SaveFile(ctx context.Context, filename string, reader io.Reader, size int64, contentType string) (string, error)
 	DeleteFile(ctx context.Context, filename string) error
+	// PutObject кладёт объект ровно под этим ключом (SaveFile придумывает ключ сам)
+	PutObject(ctx context.Context, bucket, filename string, reader io.Reader, size int64, contentType string) error
 	GetBucketName() string
+	// PresignGet — прямая ссылка на объект в хранилище, живёт ttl. ErrPresignNotSupported, если таких ссылок нет
+	PresignGet(ctx context.Context, bucket, filename string, ttl time.Duration) (string, error)
+	// ListObjects обходит все объекты своего бакета, включая ключи с папками. Бакета нет — объектов нет.
+	// ContentType и ETag в листинге не заполняются
+	ListObjects(ctx context.Context, fn func(obj StoredObject) error) error
+
+	MultipartRepoInterface
+}
+
+// MultipartRepoInterface — объект своего бакета по частям. Пока загрузку не завершили, объекта нет.
+// Части нумеруются с 1, все кроме последней — не меньше MinPartSize (требование S3)
+type MultipartRepoInterface interface {
+	// NewMultipartUpload придумывает ключ, как SaveFile, но под resumablePrefix
+	NewMultipartUpload(ctx context.Context, originalName, contentType string) (key, uploadID string, err error)
+	// PutPart — повторная отправка той же части заменяет прежнюю
+	PutPart(ctx context.Context, key, uploadID string, number int, reader io.Reader, size int64) (etag string, err error)
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	// AbortMultipartUpload — ErrUploadNotFound, если такой загрузки нет
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+	// AbortStaleUploads бросает незавершённые загрузки, начатые до olderThan. Трогает только ключи
+	// под resumablePrefix: multipart в бакете бывает и чужой (egress пишет так длинные записи)
+	AbortStaleUploads(ctx context.Context, olderThan time.Time) (int, error)
+}
+
+// MinPartSize — меньше S3 не принимает ни одну часть, кроме последней
+const MinPartSize = 5 << 20
+
+// resumablePrefix — папка для объектов, собранных по частям
+const resumablePrefix = "resumable/"
+
+type CompletedPart struct {
+	Number int
+	ETag   string
+}
+
+// newObjectKey — уникальное имя объекта с расширением исходного файла
+func newObjectKey(originalName string) string {
+	ext := filepath.Ext(originalName)
+	if ext == "" {
+		ext = ".jpg" // дефолтное расширение
+	}
+	return fmt.Sprintf("%s%s", uuid.NewString(), ext)
+}
+
+// FileObject — открытый объект хранилища. Seek не качает лишнего: чтение после него — это Range-запрос,
+// поэтому его можно отдавать прямо в http.ServeContent
+type FileObject struct {
+	io.ReadSeekCloser
+	Info ObjectInfo
+}
+
+type ObjectInfo struct {
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// StoredObject — объект из листинга бакета
+type StoredObject struct {
+	Key string
+	ObjectInfo
 }
@@ -0,0 +1,419 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const conformanceBucket = "conformance"
+
+// newRepoFunc — свежее пустое хранилище и репозиторий бакета conformanceBucket в нём.
+// Второй бакет того же хранилища достаётся через otherBucket
+type newRepoFunc func(t *testing.T) (repo FileRepoInterface, otherBucket func(bucket string) FileRepoInterface)
+
+// runFileRepoConformance — то, на что полагается usecase; любое хранилище обязано это пройти
+func runFileRepoConformance(t *testing.T, newRepo newRepoFunc) {
+	ctx := context.Background()
+
+	t.Run("SaveAndGet", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		body := []byte("hello, threadbook")
+
+		key, err := repo.SaveFile(ctx, "photo.png", bytes.NewReader(body), int64(len(body)), "image/png")
+		if err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		if path.Ext(key) != ".png" {
+			t.Errorf("key %q lost the extension", key)
+		}
+
+		obj := mustGet(t, repo, conformanceBucket, key)
+		if got := readAll(t, obj); !bytes.Equal(got, body) {
+			t.Errorf("content = %q, want %q", got, body)
+		}
+		if obj.Info.Size != int64(len(body)) {
+			t.Errorf("size = %d, want %d", obj.Info.Size, len(body))
+		}
+		if obj.Info.ContentType != "image/png" {
+			t.Errorf("content type = %q", obj.Info.ContentType)
+		}
+		if obj.Info.ETag == "" {
+			t.Error("empty etag")
+		}
+		if obj.Info.LastModified.IsZero() || time.Since(obj.Info.LastModified) > time.Hour {
+			t.Errorf("last modified = %v", obj.Info.LastModified)
+		}
+	})
+
+	t.Run("UniqueKeysAndDefaultExtension", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		a, err := repo.SaveFile(ctx, "noext", strings.NewReader("a"), 1, "application/octet-stream")
+		if err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		b, err := repo.SaveFile(ctx, "noext", strings.NewReader("b"), 1, "application/octet-stream")
+		if err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		if a == b {
+			t.Errorf("two uploads got the same key %q", a)
+		}
+		if path.Ext(a) != ".jpg" {
+			t.Errorf("key %q: want default .jpg extension", a)
+		}
+	})
+
+	t.Run("MissingObject", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		if _, err := repo.GetFile(ctx, conformanceBucket, "missing.png"); !errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("missing object: err = %v, want ErrObjectNotFound", err)
+		}
+		if _, err := repo.GetFile(ctx, "no-such-bucket", "missing.png"); !errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("missing bucket: err = %v, want ErrObjectNotFound", err)
+		}
+	})
+
+	t.Run("Seek", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		body := []byte("0123456789")
+		if err := repo.PutObject(ctx, conformanceBucket, "digits.txt", bytes.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+
+		// так http.ServeContent отвечает на Range
+		obj := mustGet(t, repo, conformanceBucket, "digits.txt")
+		end, err := obj.Seek(0, io.SeekEnd)
+		if err != nil || end != int64(len(body)) {
+			t.Fatalf("Seek(end) = %d, %v", end, err)
+		}
+		if _, err := obj.Seek(3, io.SeekStart); err != nil {
+			t.Fatalf("Seek(3): %v", err)
+		}
+		part := make([]byte, 4)
+		if _, err := io.ReadFull(obj, part); err != nil {
+			t.Fatalf("read after seek: %v", err)
+		}
+		if string(part) != "3456" {
+			t.Errorf("read after seek = %q, want 3456", part)
+		}
+	})
+
+	t.Run("PutNestedKeyAndOverwrite", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		key := "recordings/thread_1/20250101T000000Z.mp4"
+		if err := repo.PutObject(ctx, conformanceBucket, key, strings.NewReader("first"), 5, "video/mp4"); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+		first := mustGet(t, repo, conformanceBucket, key)
+		firstETag := first.Info.ETag
+		readAll(t, first)
+
+		if err := repo.PutObject(ctx, conformanceBucket, key, strings.NewReader("second!"), 7, "audio/ogg"); err != nil {
+			t.Fatalf("PutObject overwrite: %v", err)
+		}
+		second := mustGet(t, repo, conformanceBucket, key)
+		if got := readAll(t, second); string(got) != "second!" {
+			t.Errorf("content after overwrite = %q", got)
+		}
+		if second.Info.ContentType != "audio/ogg" {
+			t.Errorf("content type after overwrite = %q", second.Info.ContentType)
+		}
+		if second.Info.ETag == firstETag {
+			t.Error("etag did not change after overwrite")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		key, err := repo.SaveFile(ctx, "a.txt", strings.NewReader("bye"), 3, "text/plain")
+		if err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		if err := repo.DeleteFile(ctx, key); err != nil {
+			t.Fatalf("DeleteFile: %v", err)
+		}
+		if _, err := repo.GetFile(ctx, conformanceBucket, key); !errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("after delete: err = %v, want ErrObjectNotFound", err)
+		}
+		// повторное удаление — не ошибка, как в S3
+		if err := repo.DeleteFile(ctx, key); err != nil {
+			t.Errorf("second DeleteFile: %v", err)
+		}
+	})
+
+	t.Run("BucketsShareStorage", func(t *testing.T) {
+		repo, otherBucket := newRepo(t)
+		other := otherBucket("conformance-other")
+		if other.GetBucketName() != "conformance-other" || repo.GetBucketName() != conformanceBucket {
+			t.Fatalf("bucket names = %q, %q", repo.GetBucketName(), other.GetBucketName())
+		}
+
+		key, err := other.SaveFile(ctx, "x.txt", strings.NewReader("x"), 1, "text/plain")
+		if err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		// один хендлер отдаёт файлы всех бакетов через любой репозиторий
+		readAll(t, mustGet(t, repo, "conformance-other", key))
+		if _, err := repo.GetFile(ctx, conformanceBucket, key); !errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("object leaked into another bucket: err = %v", err)
+		}
+		// DeleteFile трогает только свой бакет
+		if err := repo.DeleteFile(ctx, key); err != nil {
+			t.Fatalf("DeleteFile: %v", err)
+		}
+		readAll(t, mustGet(t, repo, "conformance-other", key))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo, otherBucket := newRepo(t)
+		plain, err := repo.SaveFile(ctx, "a.txt", strings.NewReader("abc"), 3, "text/plain")
+		if err != nil {
+			t.Fatalf("SaveFile: %v", err)
+		}
+		nested := "recordings/thread_1/" + path.Base(plain)
+		if err := repo.PutObject(ctx, conformanceBucket, nested, strings.NewReader("nested"), 6, "video/mp4"); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+		foreign, err := otherBucket("conformance-other").SaveFile(ctx, "b.txt", strings.NewReader("b"), 1, "text/plain")
+		if err != nil {
+			t.Fatalf("SaveFile other bucket: %v", err)
+		}
+
+		// в MinIO бакет общий на все подтесты, поэтому проверяем только свои ключи
+		seen := map[string]StoredObject{}
+		err = repo.ListObjects(ctx, func(obj StoredObject) error {
+			seen[obj.Key] = obj
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ListObjects: %v", err)
+		}
+		for key, size := range map[string]int64{plain: 3, nested: 6} {
+			obj, ok := seen[key]
+			if !ok {
+				t.Errorf("key %q not listed", key)
+				continue
+			}
+			if obj.Size != size {
+				t.Errorf("%q: size = %d, want %d", key, obj.Size, size)
+			}
+			if obj.LastModified.IsZero() || time.Since(obj.LastModified) > time.Hour {
+				t.Errorf("%q: last modified = %v", key, obj.LastModified)
+			}
+		}
+		if _, ok := seen[foreign]; ok {
+			t.Errorf("object from another bucket listed: %q", foreign)
+		}
+
+		stop := errors.New("stop")
+		if err := repo.ListObjects(ctx, func(StoredObject) error { return stop }); !errors.Is(err, stop) {
+			t.Errorf("error from fn: got %v, want it returned as is", err)
+		}
+
+		empty := otherBucket("conformance-missing")
+		if err := empty.ListObjects(ctx, func(obj StoredObject) error {
+			t.Errorf("missing bucket listed %q", obj.Key)
+			return nil
+		}); err != nil {
+			t.Errorf("ListObjects on missing bucket: %v", err)
+		}
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		key, uploadID, err := repo.NewMultipartUpload(ctx, "video.mp4", "video/mp4")
+		if err != nil {
+			t.Fatalf("NewMultipartUpload: %v", err)
+		}
+		if path.Ext(key) != ".mp4" || !strings.HasPrefix(key, resumablePrefix) {
+			t.Errorf("key = %q, want %s<uuid>.mp4", key, resumablePrefix)
+		}
+		// до завершения объекта нет
+		if _, err := repo.GetFile(ctx, conformanceBucket, key); !errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("object visible before complete: err = %v", err)
+		}
+
+		first := bytes.Repeat([]byte("a"), MinPartSize)
+		last := []byte("tail")
+		// первую часть шлём дважды: обрыв связи и повтор не должны ничего ломать
+		if _, err := repo.PutPart(ctx, key, uploadID, 1, bytes.NewReader(first), int64(len(first))); err != nil {
+			t.Fatalf("PutPart(1): %v", err)
+		}
+		etag1, err := repo.PutPart(ctx, key, uploadID, 1, bytes.NewReader(first), int64(len(first)))
+		if err != nil {
+			t.Fatalf("PutPart(1) again: %v", err)
+		}
+		etag2, err := repo.PutPart(ctx, key, uploadID, 2, bytes.NewReader(last), int64(len(last)))
+		if err != nil {
+			t.Fatalf("PutPart(2): %v", err)
+		}
+
+		if err := repo.CompleteMultipartUpload(ctx, key, uploadID, []CompletedPart{{Number: 1, ETag: etag1}, {Number: 2, ETag: "bogus"}}); !errors.Is(err, ErrInvalidPart) {
+			t.Errorf("complete with wrong etag: err = %v, want ErrInvalidPart", err)
+		}
+		if err := repo.CompleteMultipartUpload(ctx, key, uploadID, []CompletedPart{{Number: 1, ETag: etag1}, {Number: 2, ETag: etag2}}); err != nil {
+			t.Fatalf("CompleteMultipartUpload: %v", err)
+		}
+
+		obj := mustGet(t, repo, conformanceBucket, key)
+		if got := readAll(t, obj); !bytes.Equal(got, append(first, last...)) {
+			t.Errorf("assembled object: %d bytes, want %d", len(got), len(first)+len(last))
+		}
+		if obj.Info.ContentType != "video/mp4" {
+			t.Errorf("content type = %q", obj.Info.ContentType)
+		}
+		if err := repo.AbortMultipartUpload(ctx, key, uploadID); !errors.Is(err, ErrUploadNotFound) {
+			t.Errorf("abort after complete: err = %v, want ErrUploadNotFound", err)
+		}
+	})
+
+	t.Run("MultipartAbort", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		key, uploadID, err := repo.NewMultipartUpload(ctx, "a.bin", "application/octet-stream")
+		if err != nil {
+			t.Fatalf("NewMultipartUpload: %v", err)
+		}
+		if _, err := repo.PutPart(ctx, key, uploadID, 1, strings.NewReader("x"), 1); err != nil {
+			t.Fatalf("PutPart: %v", err)
+		}
+		if err := repo.AbortMultipartUpload(ctx, key, uploadID); err != nil {
+			t.Fatalf("AbortMultipartUpload: %v", err)
+		}
+		if _, err := repo.PutPart(ctx, key, uploadID, 2, strings.NewReader("y"), 1); !errors.Is(err, ErrUploadNotFound) {
+			t.Errorf("PutPart after abort: err = %v, want ErrUploadNotFound", err)
+		}
+	})
+
+	t.Run("AbortStaleUploads", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		key, uploadID, err := repo.NewMultipartUpload(ctx, "stale.bin", "application/octet-stream")
+		if err != nil {
+			t.Fatalf("NewMultipartUpload: %v", err)
+		}
+
+		// только что начатую не трогаем
+		if _, err := repo.AbortStaleUploads(ctx, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatalf("AbortStaleUploads: %v", err)
+		}
+		etag, err := repo.PutPart(ctx, key, uploadID, 1, strings.NewReader("x"), 1)
+		if err != nil {
+			t.Fatalf("fresh upload was aborted: %v", err)
+		}
+
+		aborted, err := repo.AbortStaleUploads(ctx, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("AbortStaleUploads: %v", err)
+		}
+		if aborted < 1 {
+			t.Errorf("aborted = %d, want at least 1", aborted)
+		}
+		if err := repo.CompleteMultipartUpload(ctx, key, uploadID, []CompletedPart{{Number: 1, ETag: etag}}); !errors.Is(err, ErrUploadNotFound) {
+			t.Errorf("complete after stale abort: err = %v, want ErrUploadNotFound", err)
+		}
+	})
+
+	t.Run("Presign", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		if err := repo.PutObject(ctx, conformanceBucket, "p.txt", strings.NewReader("p"), 1, "text/plain"); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+		link, err := repo.PresignGet(ctx, conformanceBucket, "p.txt", time.Minute)
+		if errors.Is(err, ErrPresignNotSupported) {
+			return
+		}
+		if err != nil || link == "" {
+			t.Errorf("PresignGet = %q, %v", link, err)
+		}
+	})
+}
+
+func mustGet(t *testing.T, repo FileRepoInterface, bucket, key string) *FileObject {
+	t.Helper()
+	obj, err := repo.GetFile(context.Background(), bucket, key)
+	if err != nil {
+		t.Fatalf("GetFile(%s/%s): %v", bucket, key, err)
+	}
+	t.Cleanup(func() { obj.Close() })
+	return obj
+}
+
+func readAll(t *testing.T, obj *FileObject) []byte {
+	t.Helper()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		t.Fatalf("read object: %v", err)
+	}
+	return data
+}
+
+func TestMemoryFileRepo(t *testing.T) {
+	runFileRepoConformance(t, func(t *testing.T) (FileRepoInterface, func(string) FileRepoInterface) {
+		store := NewMemoryStore()
+		return NewMemoryFileRepo(store, conformanceBucket), func(bucket string) FileRepoInterface {
+			return NewMemoryFileRepo(store, bucket)
+		}
+	})
+}
+
+func TestLocalFileRepo(t *testing.T) {
+	runFileRepoConformance(t, func(t *testing.T) (FileRepoInterface, func(string) FileRepoInterface) {
+		root := t.TempDir()
+		return NewLocalFileRepo(root, conformanceBucket), func(bucket string) FileRepoInterface {
+			return NewLocalFileRepo(root, bucket)
+		}
+	})
+}
+
+// Ключ приходит из URL — на диске он не должен вылезать из каталога бакета
+func TestLocalFileRepoRejectsEscapingKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLocalFileRepo(t.TempDir(), conformanceBucket)
+	for _, key := range []string{"../x.txt", "a/../../x.txt", "/abs.txt", `a\b.txt`, ""} {
+		if err := repo.PutObject(ctx, conformanceBucket, key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidObjectKey) {
+			t.Errorf("PutObject(%q): err = %v, want ErrInvalidObjectKey", key, err)
+		}
+		if _, err := repo.GetFile(ctx, conformanceBucket, key); !errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("GetFile(%q): err = %v, want ErrObjectNotFound", key, err)
+		}
+	}
+	if _, err := repo.GetFile(ctx, ".meta", "x.json"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("metadata dir must not be readable as a bucket: err = %v", err)
+	}
+}
+
+// MinIO гоняем, только если он поднят: THREADBOOK_TEST_MINIO=host:port (+ _ACCESS_KEY/_SECRET_KEY)
+func TestMinioFileRepo(t *testing.T) {
+	endpoint := os.Getenv("THREADBOOK_TEST_MINIO")
+	if endpoint == "" {
+		t.Skip("THREADBOOK_TEST_MINIO is not set")
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewStaticV4(os.Getenv("THREADBOOK_TEST_MINIO_ACCESS_KEY"), os.Getenv("THREADBOOK_TEST_MINIO_SECRET_KEY"), ""),
+	})
+	if err != nil {
+		t.Fatalf("minio client: %v", err)
+	}
+
+	runFileRepoConformance(t, func(t *testing.T) (FileRepoInterface, func(string) FileRepoInterface) {
+		ctx := context.Background()
+		// бакеты общие на все подтесты, изоляцию дают уникальные ключи
+		for _, bucket := range []string{conformanceBucket, "conformance-other"} {
+			if _, err := EnsureMinioBucket(ctx, client, bucket); err != nil {
+				t.Fatalf("EnsureMinioBucket(%s): %v", bucket, err)
+			}
+		}
+		return NewFileRepo(client, conformanceBucket), func(bucket string) FileRepoInterface {
+			return NewFileRepo(client, bucket)
+		}
+	})
+}
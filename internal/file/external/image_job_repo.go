@@ -0,0 +1,8 @@
+package external
+
+import "github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+
+// ImageJobRepoInterface — очередь обработки картинок, чтобы загрузка не ждала ресайза
+type ImageJobRepoInterface interface {
+	Enqueue(job gdomain.ImageJob) error
+}
@@ -0,0 +1,386 @@
+package external
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+)
+
+// localMetaDir — рядом с бакетами, но имя бакета с точки начинаться не может, так что не пересекутся
+const localMetaDir = ".meta"
+
+// localMultipartDir — незавершённые загрузки: <root>/.multipart/<bucket>/<uploadID>/{upload.json,<n>,<n>.etag}
+const localMultipartDir = ".multipart"
+
+// localFileRepo — объекты лежат файлами в <root>/<bucket>/<key>,
+// тип и ETag — в <root>/.meta/<bucket>/<key>.json (на диске их больше негде хранить)
+type localFileRepo struct {
+	root       string
+	bucketName string
+}
+
+type localObjectMeta struct {
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+}
+
+func NewLocalFileRepo(root, bucket string) FileRepoInterface {
+	return &localFileRepo{
+		root:       root,
+		bucketName: bucket,
+	}
+}
+
+// objectPaths — путь к самому файлу и к его метаданным. Ключ из URL не должен выходить за бакет
+func (r *localFileRepo) objectPaths(bucket, filename string) (string, string, error) {
+	if bucket == "" || strings.HasPrefix(bucket, ".") || strings.ContainsAny(bucket, `/\`) {
+		return "", "", ErrInvalidObjectKey
+	}
+	clean := path.Clean("/" + filename)[1:]
+	if filename == "" || clean != filename || strings.Contains(filename, `\`) {
+		return "", "", ErrInvalidObjectKey
+	}
+	rel := filepath.FromSlash(clean)
+	return filepath.Join(r.root, bucket, rel), filepath.Join(r.root, localMetaDir, bucket, rel+".json"), nil
+}
+
+func (r *localFileRepo) GetFile(ctx context.Context, bucket, filename string) (*FileObject, error) {
+	dataPath, metaPath, err := r.objectPaths(bucket, filename)
+	if err != nil {
+		return nil, ErrObjectNotFound
+	}
+
+	f, err := os.Open(dataPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", ErrGetObject, err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", ErrStatObject, err)
+	}
+	if st.IsDir() {
+		f.Close()
+		return nil, ErrObjectNotFound
+	}
+
+	var meta localObjectMeta
+	if raw, err := os.ReadFile(metaPath); err == nil {
+		_ = json.Unmarshal(raw, &meta)
+	}
+
+	return &FileObject{
+		ReadSeekCloser: f,
+		Info: ObjectInfo{
+			Size:         st.Size(),
+			ContentType:  meta.ContentType,
+			ETag:         meta.ETag,
+			LastModified: st.ModTime().UTC().Truncate(time.Second),
+		},
+	}, nil
+}
+
+func (r *localFileRepo) SaveFile(ctx context.Context, originalName string, reader io.Reader, size int64, contentType string) (string, error) {
+	newFilename := newObjectKey(originalName)
+	if err := r.PutObject(ctx, r.bucketName, newFilename, reader, size, contentType); err != nil {
+		return "", err
+	}
+	return newFilename, nil
+}
+
+// PutObject пишет во временный файл и переименовывает: читатель видит либо старый объект, либо новый целиком
+func (r *localFileRepo) PutObject(ctx context.Context, bucket, filename string, reader io.Reader, size int64, contentType string) error {
+	dataPath, metaPath, err := r.objectPaths(bucket, filename)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0o755); err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dataPath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	defer os.Remove(tmp.Name()) // после удачного Rename удалять уже нечего
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("%s: read %d bytes, expected %d", ErrPutObject, written, size)
+	}
+
+	meta, err := json.Marshal(localObjectMeta{ContentType: contentType, ETag: hex.EncodeToString(hash.Sum(nil))})
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0o755); err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	if err := os.WriteFile(metaPath, meta, 0o644); err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	if err := os.Rename(tmp.Name(), dataPath); err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	return nil
+}
+
+// DeleteFile, как и в MinIO, не считает ошибкой отсутствие объекта
+func (r *localFileRepo) DeleteFile(ctx context.Context, filename string) error {
+	dataPath, metaPath, err := r.objectPaths(r.bucketName, filename)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dataPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: %w", ErrRemoveObject, err)
+	}
+	if err := os.Remove(metaPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: %w", ErrRemoveObject, err)
+	}
+	return nil
+}
+
+func (r *localFileRepo) PresignGet(ctx context.Context, bucket, filename string, ttl time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// ListObjects пропускает недописанные временные файлы PutObject
+func (r *localFileRepo) ListObjects(ctx context.Context, fn func(obj StoredObject) error) error {
+	bucketDir := filepath.Join(r.root, r.bucketName)
+	err := filepath.WalkDir(bucketDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == bucketDir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // удалили, пока обходили
+			}
+			return err
+		}
+		rel, err := filepath.Rel(bucketDir, p)
+		if err != nil {
+			return err
+		}
+		return fn(StoredObject{
+			Key:        filepath.ToSlash(rel),
+			ObjectInfo: ObjectInfo{Size: info.Size(), LastModified: info.ModTime().UTC().Truncate(time.Second)},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrListObjects, err)
+	}
+	return nil
+}
+
+type localUpload struct {
+	Key         string    `json:"key"`
+	ContentType string    `json:"content_type"`
+	Initiated   time.Time `json:"initiated"`
+}
+
+// uploadDir — каталог загрузки; uploadID придумываем сами (uuid), чужое в путь не пускаем
+func (r *localFileRepo) uploadDir(uploadID string) (string, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", ErrUploadNotFound
+	}
+	return filepath.Join(r.root, localMultipartDir, r.bucketName, uploadID), nil
+}
+
+// openUpload — каталог загрузки и её описание; ключ должен совпасть с тем, что выдали при старте
+func (r *localFileRepo) openUpload(key, uploadID string) (string, *localUpload, error) {
+	dir, err := r.uploadDir(uploadID)
+	if err != nil {
+		return "", nil, err
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "upload.json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, ErrUploadNotFound
+		}
+		return "", nil, err
+	}
+	var u localUpload
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return "", nil, err
+	}
+	if u.Key != key {
+		return "", nil, ErrUploadNotFound
+	}
+	return dir, &u, nil
+}
+
+func (r *localFileRepo) NewMultipartUpload(ctx context.Context, originalName, contentType string) (string, string, error) {
+	key := resumablePrefix + newObjectKey(originalName)
+	uploadID := uuid.NewString()
+	dir, _ := r.uploadDir(uploadID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("%s: %w", ErrNewMultipart, err)
+	}
+	raw, err := json.Marshal(localUpload{Key: key, ContentType: contentType, Initiated: time.Now().UTC()})
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", ErrNewMultipart, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "upload.json"), raw, 0o644); err != nil {
+		return "", "", fmt.Errorf("%s: %w", ErrNewMultipart, err)
+	}
+	return key, uploadID, nil
+}
+
+// PutPart пишет часть так же, как PutObject — через временный файл, ETag кладёт рядом
+func (r *localFileRepo) PutPart(ctx context.Context, key, uploadID string, number int, reader io.Reader, size int64) (string, error) {
+	if number < 1 {
+		return "", ErrInvalidPart
+	}
+	dir, _, err := r.openUpload(key, uploadID)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrPutPart, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrPutPart, err)
+	}
+	if written != size {
+		return "", fmt.Errorf("%s: read %d bytes, expected %d", ErrPutPart, written, size)
+	}
+
+	etag := hex.EncodeToString(hash.Sum(nil))
+	partPath := filepath.Join(dir, strconv.Itoa(number))
+	if err := os.Rename(tmp.Name(), partPath); err != nil {
+		return "", fmt.Errorf("%s: %w", ErrPutPart, err)
+	}
+	if err := os.WriteFile(partPath+".etag", []byte(etag), 0o644); err != nil {
+		return "", fmt.Errorf("%s: %w", ErrPutPart, err)
+	}
+	return etag, nil
+}
+
+func (r *localFileRepo) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	dir, u, err := r.openUpload(key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	readers := make([]io.Reader, 0, len(parts))
+	var size int64
+	for i, p := range parts {
+		partPath := filepath.Join(dir, strconv.Itoa(p.Number))
+		etag, err := os.ReadFile(partPath + ".etag")
+		if err != nil || p.Number != i+1 || string(etag) != p.ETag {
+			return fmt.Errorf("%w: part %d", ErrInvalidPart, p.Number)
+		}
+		f, err := os.Open(partPath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrCompleteMultipart, err)
+		}
+		defer f.Close()
+		st, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrCompleteMultipart, err)
+		}
+		size += st.Size()
+		readers = append(readers, f)
+	}
+
+	if err := r.PutObject(ctx, r.bucketName, key, io.MultiReader(readers...), size, u.ContentType); err != nil {
+		return fmt.Errorf("%s: %w", ErrCompleteMultipart, err)
+	}
+	return os.RemoveAll(dir)
+}
+
+func (r *localFileRepo) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	dir, _, err := r.openUpload(key, uploadID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("%s: %w", ErrAbortMultipart, err)
+	}
+	return nil
+}
+
+func (r *localFileRepo) AbortStaleUploads(ctx context.Context, olderThan time.Time) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(r.root, localMultipartDir, r.bucketName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("%s: %w", ErrListMultipart, err)
+	}
+
+	aborted := 0
+	for _, e := range entries {
+		dir, err := r.uploadDir(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		var u localUpload
+		raw, err := os.ReadFile(filepath.Join(dir, "upload.json"))
+		if err == nil {
+			err = json.Unmarshal(raw, &u)
+		}
+		// описание не записалось — загрузку всё равно никто не продолжит, смотрим на время каталога
+		if err != nil {
+			info, statErr := e.Info()
+			if statErr != nil {
+				continue
+			}
+			u.Initiated = info.ModTime()
+		}
+		if !u.Initiated.Before(olderThan) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return aborted, fmt.Errorf("%s: %w", ErrAbortMultipart, err)
+		}
+		aborted++
+	}
+	return aborted, nil
+}
+
+func (r *localFileRepo) GetBucketName() string {
+	return r.bucketName
+}
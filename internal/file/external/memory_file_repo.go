@@ -0,0 +1,240 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore — объекты всех бакетов в памяти процесса. Один на приложение,
+// репозитории разных бакетов делят его так же, как делят один MinIO
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject  // ключ — bucket + "/" + key
+	uploads map[string]*memoryUpload // незавершённые multipart, ключ — uploadID
+}
+
+type memoryUpload struct {
+	bucket      string
+	key         string
+	contentType string
+	initiated   time.Time
+	parts       map[int][]byte
+}
+
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		objects: make(map[string]memoryObject),
+		uploads: make(map[string]*memoryUpload),
+	}
+}
+
+type memoryFileRepo struct {
+	store      *MemoryStore
+	bucketName string
+}
+
+func NewMemoryFileRepo(store *MemoryStore, bucket string) FileRepoInterface {
+	return &memoryFileRepo{
+		store:      store,
+		bucketName: bucket,
+	}
+}
+
+func (r *memoryFileRepo) GetFile(ctx context.Context, bucket, filename string) (*FileObject, error) {
+	r.store.mu.RLock()
+	obj, ok := r.store.objects[bucket+"/"+filename]
+	r.store.mu.RUnlock()
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	// data после записи не меняется (перезапись кладёт новый срез), так что читать можно без блокировки
+	return &FileObject{
+		ReadSeekCloser: nopSeekCloser{bytes.NewReader(obj.data)},
+		Info:           obj.info,
+	}, nil
+}
+
+func (r *memoryFileRepo) SaveFile(ctx context.Context, originalName string, reader io.Reader, size int64, contentType string) (string, error) {
+	newFilename := newObjectKey(originalName)
+	if err := r.PutObject(ctx, r.bucketName, newFilename, reader, size, contentType); err != nil {
+		return "", err
+	}
+	return newFilename, nil
+}
+
+func (r *memoryFileRepo) PutObject(ctx context.Context, bucket, filename string, reader io.Reader, size int64, contentType string) error {
+	if filename == "" {
+		return ErrInvalidObjectKey
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return fmt.Errorf("%s: read %d bytes, expected %d", ErrPutObject, len(data), size)
+	}
+	sum := md5.Sum(data)
+
+	r.store.mu.Lock()
+	r.store.objects[bucket+"/"+filename] = memoryObject{
+		data: data,
+		info: ObjectInfo{
+			Size:         int64(len(data)),
+			ContentType:  contentType,
+			ETag:         hex.EncodeToString(sum[:]),
+			LastModified: time.Now().UTC().Truncate(time.Second),
+		},
+	}
+	r.store.mu.Unlock()
+	return nil
+}
+
+func (r *memoryFileRepo) DeleteFile(ctx context.Context, filename string) error {
+	r.store.mu.Lock()
+	delete(r.store.objects, r.bucketName+"/"+filename)
+	r.store.mu.Unlock()
+	return nil
+}
+
+func (r *memoryFileRepo) PresignGet(ctx context.Context, bucket, filename string, ttl time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+func (r *memoryFileRepo) ListObjects(ctx context.Context, fn func(obj StoredObject) error) error {
+	prefix := r.bucketName + "/"
+	r.store.mu.RLock()
+	var objects []StoredObject
+	for key, obj := range r.store.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, StoredObject{Key: strings.TrimPrefix(key, prefix), ObjectInfo: ObjectInfo{Size: obj.info.Size, LastModified: obj.info.LastModified}})
+		}
+	}
+	r.store.mu.RUnlock()
+
+	// fn может сам писать в хранилище, поэтому зовём его уже без блокировки; порядок — как у S3
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	for _, obj := range objects {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryFileRepo) NewMultipartUpload(ctx context.Context, originalName, contentType string) (string, string, error) {
+	key := resumablePrefix + newObjectKey(originalName)
+	uploadID := uuid.NewString()
+	r.store.mu.Lock()
+	r.store.uploads[uploadID] = &memoryUpload{
+		bucket:      r.bucketName,
+		key:         key,
+		contentType: contentType,
+		initiated:   time.Now(),
+		parts:       make(map[int][]byte),
+	}
+	r.store.mu.Unlock()
+	return key, uploadID, nil
+}
+
+// upload — загрузка этого бакета и ключа; вызывать под блокировкой
+func (r *memoryFileRepo) upload(key, uploadID string) (*memoryUpload, error) {
+	u, ok := r.store.uploads[uploadID]
+	if !ok || u.bucket != r.bucketName || u.key != key {
+		return nil, ErrUploadNotFound
+	}
+	return u, nil
+}
+
+func (r *memoryFileRepo) PutPart(ctx context.Context, key, uploadID string, number int, reader io.Reader, size int64) (string, error) {
+	if number < 1 {
+		return "", ErrInvalidPart
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrPutPart, err)
+	}
+	if int64(len(data)) != size {
+		return "", fmt.Errorf("%s: read %d bytes, expected %d", ErrPutPart, len(data), size)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	u, err := r.upload(key, uploadID)
+	if err != nil {
+		return "", err
+	}
+	u.parts[number] = data
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (r *memoryFileRepo) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	r.store.mu.Lock()
+	u, err := r.upload(key, uploadID)
+	if err != nil {
+		r.store.mu.Unlock()
+		return err
+	}
+	var buf bytes.Buffer
+	for i, p := range parts {
+		data, ok := u.parts[p.Number]
+		sum := md5.Sum(data)
+		if !ok || p.Number != i+1 || p.ETag != hex.EncodeToString(sum[:]) {
+			r.store.mu.Unlock()
+			return fmt.Errorf("%w: part %d", ErrInvalidPart, p.Number)
+		}
+		buf.Write(data)
+	}
+	delete(r.store.uploads, uploadID)
+	r.store.mu.Unlock()
+
+	return r.PutObject(ctx, r.bucketName, key, &buf, int64(buf.Len()), u.contentType)
+}
+
+func (r *memoryFileRepo) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, err := r.upload(key, uploadID); err != nil {
+		return err
+	}
+	delete(r.store.uploads, uploadID)
+	return nil
+}
+
+func (r *memoryFileRepo) AbortStaleUploads(ctx context.Context, olderThan time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	aborted := 0
+	for id, u := range r.store.uploads {
+		if u.bucket == r.bucketName && strings.HasPrefix(u.key, resumablePrefix) && u.initiated.Before(olderThan) {
+			delete(r.store.uploads, id)
+			aborted++
+		}
+	}
+	return aborted, nil
+}
+
+func (r *memoryFileRepo) GetBucketName() string {
+	return r.bucketName
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
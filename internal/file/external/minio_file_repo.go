@@ -1,14 +1,12 @@
 package external
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"log"
-	"path/filepath"
+	"net/url"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 )
 
@@ -17,60 +15,63 @@ type FileRepo struct {
 	BucketName string
 }
 
+// NewFileRepo ничего не создаёт и никуда не ходит; бакет заводит EnsureMinioBucket при старте
 func NewFileRepo(client *minio.Client, bucket string) FileRepoInterface {
+	return &FileRepo{
+		client:     client,
+		BucketName: bucket,
+	}
+}
 
-	ctx := context.Background()
-
+// EnsureMinioBucket создаёт бакет, если его ещё нет. Возвращает true, если создал
+func EnsureMinioBucket(ctx context.Context, client *minio.Client, bucket string) (bool, error) {
 	exists, err := client.BucketExists(ctx, bucket)
 	if err != nil {
-		log.Printf("failed to check bucket existence: %v", err)
+		return false, fmt.Errorf("%s %q: %w", ErrCheckBucket, bucket, err)
 	}
-
-	if !exists {
-		err = client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: "us-east-1"})
-		if err != nil {
-			log.Printf("failed to create bucket %q: %v", bucket, err)
-		} else {
-			log.Printf("bucket %q successfully created", bucket)
-		}
-	} else {
-		log.Printf("bucket %q already exists", bucket)
+	if exists {
+		return false, nil
 	}
-
-	return &FileRepo{
-		client:     client,
-		BucketName: bucket,
+	if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: "us-east-1"}); err != nil {
+		return false, fmt.Errorf("%s %q: %w", ErrMakeBucket, bucket, err)
 	}
+	return true, nil
 }
 
-func (r *FileRepo) GetFile(ctx context.Context, bucket, filename string) ([]byte, string, error) {
+func (r *FileRepo) GetFile(ctx context.Context, bucket, filename string) (*FileObject, error) {
+	// GetObject ленивый: запрос в MinIO уйдёт только на Stat/Read
 	obj, err := r.client.GetObject(ctx, bucket, filename, minio.GetObjectOptions{})
 	if err != nil {
-		return nil, "", fmt.Errorf("%s: %w", ErrGetObject, err)
-	}
-	defer obj.Close()
-
-	buf := new(bytes.Buffer)
-	if _, err := io.Copy(buf, obj); err != nil {
-		return nil, "", fmt.Errorf("%s: %w", ErrReadObject, err)
+		return nil, fmt.Errorf("%s: %w", ErrGetObject, err)
 	}
 
-	info, err := r.client.StatObject(ctx, r.BucketName, filename, minio.StatObjectOptions{})
+	info, err := obj.Stat()
 	if err != nil {
-		return nil, "", fmt.Errorf("%s: %w", ErrStatObject, err)
+		obj.Close()
+		if isNoSuchObject(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", ErrStatObject, err)
 	}
 
-	return buf.Bytes(), info.ContentType, nil
+	return &FileObject{
+		ReadSeekCloser: obj,
+		Info: ObjectInfo{
+			Size:         info.Size,
+			ContentType:  info.ContentType,
+			ETag:         info.ETag,
+			LastModified: info.LastModified,
+		},
+	}, nil
 }
 
-func (r *FileRepo) SaveFile(ctx context.Context, originalName string, reader io.Reader, size int64, contentType string) (string, error) {
-	// Извлекаем расширение файла
-	ext := filepath.Ext(originalName)
-	if ext == "" {
-		ext = ".jpg" // дефолтное расширение
-	}
+func isNoSuchObject(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NoSuchBucket"
+}
 
-	newFilename := fmt.Sprintf("%s%s", uuid.NewString(), ext)
+func (r *FileRepo) SaveFile(ctx context.Context, originalName string, reader io.Reader, size int64, contentType string) (string, error) {
+	newFilename := newObjectKey(originalName)
 
 	_, err := r.client.PutObject(ctx, r.BucketName, newFilename, reader, size, minio.PutObjectOptions{
 		ContentType: contentType,
@@ -82,6 +83,16 @@ func (r *FileRepo) SaveFile(ctx context.Context, originalName string, reader io.
 	return newFilename, nil
 }
 
+func (r *FileRepo) PutObject(ctx context.Context, bucket, filename string, reader io.Reader, size int64, contentType string) error {
+	_, err := r.client.PutObject(ctx, bucket, filename, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrPutObject, err)
+	}
+	return nil
+}
+
 func (r *FileRepo) DeleteFile(ctx context.Context, filename string) error {
 	err := r.client.RemoveObject(ctx, r.BucketName, filename, minio.RemoveObjectOptions{})
 	if err != nil {
@@ -90,6 +101,110 @@ func (r *FileRepo) DeleteFile(ctx context.Context, filename string) error {
 	return nil
 }
 
+func (r *FileRepo) PresignGet(ctx context.Context, bucket, filename string, ttl time.Duration) (string, error) {
+	u, err := r.client.PresignedGetObject(ctx, bucket, filename, ttl, url.Values{})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrPresignObject, err)
+	}
+	return u.String(), nil
+}
+
+func (r *FileRepo) ListObjects(ctx context.Context, fn func(obj StoredObject) error) error {
+	// отменяем листинг, если fn вернул ошибку, — иначе горутина minio-go так и будет ждать чтения
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for obj := range r.client.ListObjects(ctx, r.BucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			if isNoSuchObject(obj.Err) {
+				return nil
+			}
+			return fmt.Errorf("%s: %w", ErrListObjects, obj.Err)
+		}
+		if err := fn(StoredObject{Key: obj.Key, ObjectInfo: ObjectInfo{Size: obj.Size, LastModified: obj.LastModified}}); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// core — низкоуровневый multipart: обычный PutObject сам режет на части, но не даёт их докачивать
+func (r *FileRepo) core() minio.Core {
+	return minio.Core{Client: r.client}
+}
+
+// multipartError — коды S3 в наши ошибки, остальное заворачиваем как есть
+func multipartError(op string, err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchUpload":
+		return ErrUploadNotFound
+	case "InvalidPart", "InvalidPartOrder", "EntityTooSmall":
+		return fmt.Errorf("%w: %v", ErrInvalidPart, err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func (r *FileRepo) NewMultipartUpload(ctx context.Context, originalName, contentType string) (string, string, error) {
+	key := resumablePrefix + newObjectKey(originalName)
+	uploadID, err := r.core().NewMultipartUpload(ctx, r.BucketName, key, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", ErrNewMultipart, err)
+	}
+	return key, uploadID, nil
+}
+
+func (r *FileRepo) PutPart(ctx context.Context, key, uploadID string, number int, reader io.Reader, size int64) (string, error) {
+	part, err := r.core().PutObjectPart(ctx, r.BucketName, key, uploadID, number, reader, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", multipartError(ErrPutPart, err)
+	}
+	return part.ETag, nil
+}
+
+func (r *FileRepo) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	complete := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		complete[i] = minio.CompletePart{PartNumber: p.Number, ETag: p.ETag}
+	}
+	if _, err := r.core().CompleteMultipartUpload(ctx, r.BucketName, key, uploadID, complete, minio.PutObjectOptions{}); err != nil {
+		return multipartError(ErrCompleteMultipart, err)
+	}
+	return nil
+}
+
+func (r *FileRepo) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	if err := r.core().AbortMultipartUpload(ctx, r.BucketName, key, uploadID); err != nil {
+		return multipartError(ErrAbortMultipart, err)
+	}
+	return nil
+}
+
+func (r *FileRepo) AbortStaleUploads(ctx context.Context, olderThan time.Time) (int, error) {
+	// сначала собираем, потом бросаем: менять список, пока по нему идём, S3 не обещает
+	var stale []minio.ObjectMultipartInfo
+	for upload := range r.client.ListIncompleteUploads(ctx, r.BucketName, resumablePrefix, true) {
+		if upload.Err != nil {
+			if isNoSuchObject(upload.Err) {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("%s: %w", ErrListMultipart, upload.Err)
+		}
+		if upload.Initiated.Before(olderThan) {
+			stale = append(stale, upload)
+		}
+	}
+
+	aborted := 0
+	for _, upload := range stale {
+		err := r.core().AbortMultipartUpload(ctx, r.BucketName, upload.Key, upload.UploadID)
+		if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+			return aborted, fmt.Errorf("%s: %w", ErrAbortMultipart, err)
+		}
+		aborted++
+	}
+	return aborted, nil
+}
+
 func (r *FileRepo) GetBucketName() string {
 	return r.BucketName
 }
@@ -0,0 +1,32 @@
+package external
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+	"github.com/nats-io/nats.go"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+type imageJobRepo struct {
+	nc      *nats.Conn
+	subject string
+}
+
+func NewImageJobRepo(nc *nats.Conn, subject string) ImageJobRepoInterface {
+	return &imageJobRepo{
+		nc:      nc,
+		subject: subject,
+	}
+}
+
+func (r *imageJobRepo) Enqueue(job gdomain.ImageJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrEnqueueImageJob, err)
+	}
+	if err := r.nc.Publish(r.subject, data); err != nil {
+		return fmt.Errorf("%s: %w", ErrEnqueueImageJob, err)
+	}
+	return nil
+}
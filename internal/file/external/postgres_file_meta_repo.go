@@ -0,0 +1,208 @@
+package external
+
+import (
+	"context"
+	"errors"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type fileMetaRepo struct {
+	db *gorm.DB
+}
+
+func NewFileMetaRepo(db *gorm.DB) FileMetaRepoInterface {
+	return &fileMetaRepo{db: db}
+}
+
+// conn — текущая транзакция из ctx (см. dbtx), иначе обычное подключение
+func (r *fileMetaRepo) conn(ctx context.Context) *gorm.DB {
+	return dbtx.DB(ctx, r.db)
+}
+
+// notQuarantined — у строк из бэкфилла quarantine NULL, а не пустая строка
+const notQuarantined = "COALESCE(quarantine, '') = ''"
+
+func (r *fileMetaRepo) Create(ctx context.Context, f *gdomain.File) error {
+	return r.create(ctx, f, StorageLimits{})
+}
+
+func (r *fileMetaRepo) CreateWithinQuota(ctx context.Context, f *gdomain.File, limits StorageLimits) error {
+	return r.create(ctx, f, limits)
+}
+
+func (r *fileMetaRepo) create(ctx context.Context, f *gdomain.File, limits StorageLimits) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// файлы треда занимают место спула, в котором тред лежит
+		if f.SpoolID == nil && f.ThreadID != nil {
+			var spoolID uint
+			if err := tx.Table("threads").Select("spool_id").Where("id = ?", *f.ThreadID).Scan(&spoolID).Error; err != nil {
+				return err
+			}
+			if spoolID != 0 {
+				f.SpoolID = &spoolID
+			}
+		}
+
+		res := tx.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "bucket"}, {Name: "object_key"}},
+				DoNothing: true,
+			}).
+			Create(f)
+		if res.Error != nil {
+			return res.Error
+		}
+		// строка уже была — её место уже посчитано
+		if res.RowsAffected == 0 || f.Quarantine != "" {
+			return nil
+		}
+		return addUsage(tx, f, f.Size, 1, limits)
+	})
+}
+
+func (r *fileMetaRepo) Get(ctx context.Context, bucket, objectKey string) (*gdomain.File, error) {
+	var f gdomain.File
+	err := r.conn(ctx).
+		Where("bucket = ? AND object_key = ?", bucket, objectKey).
+		Take(&f).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFileMetaNotFound
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *fileMetaRepo) AttachToMessage(ctx context.Context, bucket string, objectKeys []string, messageID uint) error {
+	if len(objectKeys) == 0 {
+		return nil
+	}
+	return r.conn(ctx).
+		Model(&gdomain.File{}).
+		Where("bucket = ? AND object_key IN ?", bucket, objectKeys).
+		Update("message_id", messageID).Error
+}
+
+func (r *fileMetaRepo) ListVariants(ctx context.Context, parentID uint) ([]gdomain.File, error) {
+	var variants []gdomain.File
+	err := r.conn(ctx).
+		Where("parent_id = ?", parentID).
+		Order("variant ASC").
+		Find(&variants).Error
+	return variants, err
+}
+
+func (r *fileMetaRepo) Delete(ctx context.Context, bucket, objectKey string) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var deleted []gdomain.File
+		err := tx.
+			Clauses(clause.Returning{}).
+			Where("bucket = ? AND object_key = ?", bucket, objectKey).
+			Delete(&deleted).Error
+		if err != nil {
+			return err
+		}
+		for i := range deleted {
+			if deleted[i].Quarantine != "" {
+				continue
+			}
+			if err := addUsage(tx, &deleted[i], -deleted[i].Size, -1, StorageLimits{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// addUsage двигает счётчики владельца файла и его спула
+func addUsage(tx *gorm.DB, f *gdomain.File, bytes, files int64, limits StorageLimits) error {
+	if err := bumpUsage(tx, gdomain.StorageScopeUser, f.OwnerID, bytes, files, limits.UserBytes); err != nil {
+		return err
+	}
+	if f.SpoolID == nil {
+		return nil
+	}
+	return bumpUsage(tx, gdomain.StorageScopeSpool, *f.SpoolID, bytes, files, limits.SpoolBytes)
+}
+
+// bumpUsage — прибавить к счётчику. С лимитом проверка и прибавка — один upsert,
+// так что параллельные загрузки не проскочат лимит вдвоём
+func bumpUsage(tx *gorm.DB, scope string, scopeID uint, bytes, files, limit int64) error {
+	if limit > 0 && bytes > limit {
+		return ErrQuotaExceeded
+	}
+	query := `INSERT INTO storage_usages (scope, scope_id, bytes, files, updated_at) VALUES (?, ?, ?, ?, now())
+		ON CONFLICT (scope, scope_id) DO UPDATE SET
+			bytes = storage_usages.bytes + EXCLUDED.bytes,
+			files = storage_usages.files + EXCLUDED.files,
+			updated_at = EXCLUDED.updated_at`
+	args := []any{scope, scopeID, bytes, files}
+	if limit > 0 && bytes > 0 {
+		query += ` WHERE storage_usages.bytes + EXCLUDED.bytes <= ?`
+		args = append(args, limit)
+	}
+
+	res := tx.Exec(query, args...)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (r *fileMetaRepo) GetUsage(ctx context.Context, scope string, scopeID uint) (*gdomain.StorageUsage, error) {
+	usage := gdomain.StorageUsage{Scope: scope, ScopeID: scopeID}
+	err := r.conn(ctx).
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		Take(&usage).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *fileMetaRepo) SpoolUsageBreakdown(ctx context.Context, spoolID uint, limit int) (*SpoolUsageBreakdown, error) {
+	var out SpoolUsageBreakdown
+	db := r.conn(ctx)
+
+	err := db.
+		Model(&gdomain.File{}).
+		Select("kind, SUM(size) AS bytes, COUNT(*) AS files").
+		Where("spool_id = ? AND "+notQuarantined, spoolID).
+		Group("kind").
+		Order("bytes DESC").
+		Scan(&out.ByKind).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.
+		Table("files AS f").
+		Select("f.owner_id, u.username, SUM(f.size) AS bytes, COUNT(*) AS files").
+		Joins("LEFT JOIN users u ON u.id = f.owner_id").
+		Where("f.spool_id = ? AND COALESCE(f.quarantine, '') = ''", spoolID).
+		Group("f.owner_id, u.username").
+		Order("bytes DESC").
+		Limit(limit).
+		Scan(&out.ByOwner).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.
+		Where("spool_id = ? AND "+notQuarantined, spoolID).
+		Order("size DESC").
+		Limit(limit).
+		Find(&out.Largest).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
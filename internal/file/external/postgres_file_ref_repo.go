@@ -0,0 +1,78 @@
+package external
+
+import (
+	"context"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	"gorm.io/gorm"
+)
+
+type fileRefRepo struct {
+	db *gorm.DB
+}
+
+func NewFileRefRepo(db *gorm.DB) FileRefRepoInterface {
+	return &fileRefRepo{db: db}
+}
+
+func (r *fileRefRepo) conn(ctx context.Context) *gorm.DB {
+	return dbtx.DB(ctx, r.db)
+}
+
+func (r *fileRefRepo) Referenced(ctx context.Context, bucket string, keys []string) (map[string]bool, error) {
+	referenced := make(map[string]bool, len(keys))
+	if len(keys) == 0 {
+		return referenced, nil
+	}
+
+	// копии картинок сами ни в каких таблицах не упоминаются — смотрим на оригинал
+	var variants []struct {
+		ObjectKey string
+		ParentKey string
+	}
+	err := r.conn(ctx).
+		Table("files AS v").
+		Select("v.object_key, p.object_key AS parent_key").
+		Joins("JOIN files p ON p.id = v.parent_id").
+		Where("v.bucket = ? AND v.object_key IN ?", bucket, keys).
+		Scan(&variants).Error
+	if err != nil {
+		return nil, err
+	}
+	candidates := append([]string(nil), keys...)
+	for _, v := range variants {
+		candidates = append(candidates, v.ParentKey)
+	}
+
+	// ключи — uuid, поэтому профили, спулы и записи сверяем без бакета: лишний раз не удалить надёжнее
+	sources := []struct {
+		column string
+		query  *gorm.DB
+	}{
+		{"avatar_link", r.conn(ctx).Model(&gdomain.Profile{}).Where("avatar_link IN ?", candidates)},
+		{"banner_link", r.conn(ctx).Model(&gdomain.Spool{}).Where("banner_link IN ?", candidates)},
+		{"file_link", r.conn(ctx).Model(&gdomain.MessagePayload{}).Where("bucket = ? AND file_link IN ?", bucket, candidates)},
+		{"file_link", r.conn(ctx).Model(&gdomain.VoiceRecording{}).Where("file_link IN ?", candidates)},
+	}
+	found := make(map[string]bool)
+	for _, s := range sources {
+		var links []string
+		if err := s.query.Pluck(s.column, &links).Error; err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			found[link] = true
+		}
+	}
+
+	for _, key := range keys {
+		referenced[key] = found[key]
+	}
+	for _, v := range variants {
+		if found[v.ParentKey] {
+			referenced[v.ObjectKey] = true
+		}
+	}
+	return referenced, nil
+}
@@ -0,0 +1,64 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	uploadSessionKeyPrefix = "upload:session:" // upload:session:<id> — UploadSession (json)
+	uploadLockKeyPrefix    = "upload:lock:"    // upload:lock:<id> — идёт запись части
+)
+
+type redisUploadSessionRepo struct {
+	client redis.UniversalClient
+}
+
+func NewRedisUploadSessionRepo(client redis.UniversalClient) UploadSessionRepoInterface {
+	return &redisUploadSessionRepo{client: client}
+}
+
+func (r *redisUploadSessionRepo) Save(ctx context.Context, s *gdomain.UploadSession) error {
+	ttl := time.Until(s.ExpiresAt)
+	if ttl <= 0 {
+		return ErrUploadSessionNotFound
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshal upload session: %w", err)
+	}
+	return r.client.Set(ctx, uploadSessionKeyPrefix+s.ID, data, ttl).Err()
+}
+
+func (r *redisUploadSessionRepo) Get(ctx context.Context, id string) (*gdomain.UploadSession, error) {
+	data, err := r.client.Get(ctx, uploadSessionKeyPrefix+id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrUploadSessionNotFound
+		}
+		return nil, err
+	}
+	var s gdomain.UploadSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("unmarshal upload session: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *redisUploadSessionRepo) Delete(ctx context.Context, id string) error {
+	return r.client.Del(ctx, uploadSessionKeyPrefix+id).Err()
+}
+
+func (r *redisUploadSessionRepo) Lock(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, uploadLockKeyPrefix+id, 1, ttl).Result()
+}
+
+func (r *redisUploadSessionRepo) Unlock(ctx context.Context, id string) error {
+	return r.client.Del(ctx, uploadLockKeyPrefix+id).Err()
+}
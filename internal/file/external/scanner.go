@@ -0,0 +1,16 @@
+package external
+
+import (
+	"context"
+	"io"
+)
+
+// ScannerInterface — антивирус для загрузок. Подключается в конфиге (scanner.backend), по умолчанию выключен
+type ScannerInterface interface {
+	Scan(ctx context.Context, r io.Reader) (*ScanResult, error)
+}
+
+type ScanResult struct {
+	Infected  bool
+	Signature string // что нашёл сканер, например "Eicar-Test-Signature"
+}
@@ -0,0 +1,20 @@
+package external
+
+import (
+	"context"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+// UploadSessionRepoInterface — состояние докачиваемых загрузок
+type UploadSessionRepoInterface interface {
+	// Save — сессия живёт до своего ExpiresAt, дальше забывается сама
+	Save(ctx context.Context, s *gdomain.UploadSession) error
+	// Get — ErrUploadSessionNotFound, если сессии нет или она истекла
+	Get(ctx context.Context, id string) (*gdomain.UploadSession, error)
+	Delete(ctx context.Context, id string) error
+	// Lock — в загрузку пишет один запрос за раз; false — кто-то уже пишет
+	Lock(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, id string) error
+}
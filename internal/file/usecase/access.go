@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+// MembershipRepoInterface — права на треды и спулы живут в модуле thread, его ThreadRepo сюда и передаём
+type MembershipRepoInterface interface {
+	CheckRightsUserOnThreadRoom(ctx context.Context, threadID, userID uint) (bool, error)
+	IsUserInSpool(ctx context.Context, userID, spoolID uint) (bool, error)
+	IsSpoolOwner(ctx context.Context, spoolID, userID uint) (bool, error)
+}
+
+// FileAccess — общее для всех бакетов: метаданные, проверка прав и подписанные ссылки
+type FileAccess struct {
+	Meta       external.FileMetaRepoInterface
+	Membership MembershipRepoInterface
+	Buckets    []string                       // только эти бакеты вообще можно скачивать
+	Images     external.ImageJobRepoInterface // очередь ресайза картинок; nil — копии не режем
+	// ImageMaxPixels, ImageMaxBytes — лимиты, с которыми чистим метаданные аватарок и баннеров при загрузке
+	ImageMaxPixels int
+	ImageMaxBytes  int64
+	Quota          external.StorageLimits // сколько места можно занять пользователю и спулу
+
+	Uploads        external.UploadSessionRepoInterface // сессии докачиваемых загрузок; nil — такие загрузки выключены
+	UploadTTL      time.Duration                       // за сколько надо дописать загрузку
+	UploadPartSize int64                               // размер части; меньше external.MinPartSize не бывает
+	// Detect — тип по первым байтам и имени файла (config.FileConfig.DetectContentType); nil — не проверяем
+	Detect func(file io.ReadSeeker, filename string) (string, error)
+
+	Scanner          external.ScannerInterface // антивирус; nil — загрузки не проверяем
+	ScanFailOpen     bool                      // сканер недоступен — всё равно принимать файл
+	QuarantineBucket string                    // сюда уезжают заражённые файлы, скачать оттуда нельзя
+
+	LinkSecret  []byte        // HMAC для ссылок без сессии; пусто — такие ссылки выключены
+	LinkTTL     time.Duration // сколько живёт подписанная или presigned ссылка
+	LinkBaseURL string        // префикс API, к нему приклеивается /uploads/...
+}
+
+func (a *FileAccess) uploadPartSize() int64 {
+	return max(a.UploadPartSize, external.MinPartSize)
+}
+
+func (a *FileAccess) bucketAllowed(bucket string) bool {
+	for _, b := range a.Buckets {
+		if b == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// canRead — владелец видит всегда; файлы треда — участники треда, файлы спула — участники спула,
+// остальное (аватарки, баннеры) — любой залогиненный
+func (a *FileAccess) canRead(ctx context.Context, f *gdomain.File, userID uint) (bool, error) {
+	if f.OwnerID == userID {
+		return true, nil
+	}
+	switch {
+	case f.ThreadID != nil:
+		return a.Membership.CheckRightsUserOnThreadRoom(ctx, *f.ThreadID, userID)
+	case f.SpoolID != nil && f.Kind != gdomain.FileKindSpoolBanner:
+		return a.Membership.IsUserInSpool(ctx, userID, *f.SpoolID)
+	default:
+		return true, nil
+	}
+}
+
+// signLink — подпись bucket/key/exp. Ключ и срок в подписи, так что ссылку нельзя ни продлить, ни перенаправить на другой файл
+func (a *FileAccess) signLink(bucket, key string, exp int64) string {
+	mac := hmac.New(sha256.New, a.LinkSecret)
+	fmt.Fprintf(mac, "%s\n%s\n%d", bucket, key, exp)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (a *FileAccess) signedURL(bucket, key string, expiresAt time.Time) string {
+	exp := expiresAt.Unix()
+	q := url.Values{}
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", a.signLink(bucket, key, exp))
+	return fmt.Sprintf("%s/uploads/%s/%s?%s", a.LinkBaseURL, url.PathEscape(bucket), escapeKey(key), q.Encode())
+}
+
+// escapeKey экранирует части ключа, оставляя "/" между ними — роут принимает ключ с папками
+func escapeKey(key string) string {
+	u := url.URL{Path: key}
+	return u.EscapedPath()
+}
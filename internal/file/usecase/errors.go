@@ -7,4 +7,24 @@ var (
 	ErrFileNotFound = errors.New("file not found")
 	ErrSaveFailed   = errors.New("failed to save file")
 	ErrDeleteFailed = errors.New("failed to delete file")
+
+	ErrAccessDenied  = errors.New("access to file denied")
+	ErrLinksDisabled = errors.New("signed links are disabled")
+	ErrLinkFailed    = errors.New("failed to create file link")
+
+	ErrImageDecode   = errors.New("failed to decode image")
+	ErrImageTooLarge = errors.New("image is too large to process")
+
+	ErrFileInfected = errors.New("file is infected and was quarantined")
+	ErrScanFailed   = errors.New("failed to scan file, try again later")
+
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+	ErrUploadsDisabled      = errors.New("resumable uploads are disabled")
+	ErrUploadNotFound       = errors.New("upload not found or expired")
+	ErrUploadBusy           = errors.New("another part of this upload is being written")
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match received bytes")
+	ErrUploadIncomplete     = errors.New("upload is not complete")
+	ErrInvalidPart          = errors.New("part size does not match expected")
+	ErrContentTypeMismatch  = errors.New("file content does not match its extension")
 )
@@ -2,27 +2,58 @@ package usecase
 
 import (
 	"context"
+	"crypto/hmac"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
 	"go.uber.org/zap"
 )
 
 type FileUsecaseInterface interface {
-	GetFile(ctx context.Context, input GetFileInput) ([]byte, string, error)
+	GetFile(ctx context.Context, input GetFileInput) (*FileContent, error)
 	SaveFile(ctx context.Context, input SaveFile) (string, error)
 	DeleteFile(ctx context.Context, input DeleteFileInput) error
 	GetBucketName() string
+
+	// RegisterFile — объект в бакет положили в обход SaveFile (например, egress записал звонок).
+	// Не влез в квоту — объект удаляется, наружу ErrQuotaExceeded
+	RegisterFile(ctx context.Context, input RegisterFileInput) error
+	AttachToMessage(ctx context.Context, input AttachToMessageInput) error
+	GetFileLink(ctx context.Context, input GetFileLinkInput) (*FileLink, error)
+	// VerifyLink проверяет подписанную ссылку из GetFileLink
+	VerifyLink(bucket, filename, exp, sig string) bool
+
+	// GetUsage — сколько места занимают файлы пользователя
+	GetUsage(ctx context.Context, userID uint) (*Usage, error)
+	// GetSpoolUsage — место спула с разбивкой, только для владельца спула
+	GetSpoolUsage(ctx context.Context, input GetSpoolUsageInput) (*SpoolUsage, error)
+
+	// InitUpload начинает докачиваемую загрузку: место под объявленный размер проверяется сразу
+	InitUpload(ctx context.Context, input InitUploadInput) (*gdomain.UploadSession, error)
+	// UploadPart принимает следующую часть ровно с того места, где остановились
+	UploadPart(ctx context.Context, input UploadPartInput) (*gdomain.UploadSession, error)
+	GetUpload(ctx context.Context, input UploadRefInput) (*gdomain.UploadSession, error)
+	// CompleteUpload собирает объект и проводит его как обычную загрузку (антивирус, квота, метаданные)
+	CompleteUpload(ctx context.Context, input UploadRefInput) (*gdomain.UploadSession, error)
+	AbortUpload(ctx context.Context, input UploadRefInput) error
+	// CleanupUploads бросает в хранилище загрузки, чьи сессии уже истекли
+	CleanupUploads(ctx context.Context) (int, error)
 }
 
 type fileUsecase struct {
 	repo   external.FileRepoInterface
+	access *FileAccess
 	logger *zap.Logger
 	Bucket string
 }
 
-func NewFileUsecase(repo external.FileRepoInterface, logger *zap.Logger) FileUsecaseInterface {
+func NewFileUsecase(repo external.FileRepoInterface, access *FileAccess, logger *zap.Logger) FileUsecaseInterface {
 	return &fileUsecase{
 		repo:   repo,
+		access: access,
 		logger: logger,
 		Bucket: repo.GetBucketName(),
 	}
@@ -32,27 +63,201 @@ func (u *fileUsecase) GetBucketName() string {
 	return u.Bucket
 }
 
-func (u *fileUsecase) GetFile(ctx context.Context, input GetFileInput) ([]byte, string, error) {
-	if input.Filename == "" {
-		return nil, "", ErrInvalidInput
+func (u *fileUsecase) GetFile(ctx context.Context, input GetFileInput) (*FileContent, error) {
+	if input.Filename == "" || input.Size < 0 {
+		return nil, ErrInvalidInput
+	}
+	meta, err := u.checkRead(ctx, input.Bucket, input.Filename, input.UserID, input.Signed)
+	if err != nil {
+		return nil, err
+	}
+
+	key, exact := input.Filename, true
+	if input.Size > 0 {
+		variants, err := u.access.Meta.ListVariants(ctx, meta.ID)
+		if err != nil {
+			u.logger.Warn("failed to list image variants", zap.Error(err))
+		}
+		if v := pickVariant(variants, input.Size); v != nil {
+			key = v.ObjectKey
+		}
+		// копий ещё нет — джоба не успела; отдаём оригинал, но кэшировать его под этим размером нельзя
+		exact = len(variants) > 0
+	}
+
+	obj, err := u.repo.GetFile(ctx, input.Bucket, key)
+	if err != nil {
+		if !errors.Is(err, external.ErrObjectNotFound) {
+			u.logger.Error("failed to get file", zap.Error(err))
+		}
+		return nil, ErrFileNotFound
 	}
 
-	data, contentType, err := u.repo.GetFile(ctx, input.Bucket, input.Filename)
+	return &FileContent{FileObject: obj, Key: key, Exact: exact}, nil
+}
+
+// checkRead — бакет из белого списка, про объект есть метаданные и пользователю его можно видеть.
+// По подписанной ссылке права уже проверили, когда её выдавали
+func (u *fileUsecase) checkRead(ctx context.Context, bucket, filename string, userID uint, signed bool) (*gdomain.File, error) {
+	if !u.access.bucketAllowed(bucket) {
+		return nil, ErrFileNotFound
+	}
+	meta, err := u.access.Meta.Get(ctx, bucket, filename)
 	if err != nil {
-		u.logger.Error("failed to get file", zap.Error(err))
-		return nil, "", ErrFileNotFound
+		if !errors.Is(err, external.ErrFileMetaNotFound) {
+			u.logger.Error("failed to get file metadata", zap.Error(err))
+		}
+		return nil, ErrFileNotFound
+	}
+	if signed {
+		return meta, nil
 	}
 
-	return data, contentType, nil
+	ok, err := u.access.canRead(ctx, meta, userID)
+	if err != nil {
+		u.logger.Error("failed to check file access", zap.Error(err))
+		return nil, ErrAccessDenied
+	}
+	if !ok {
+		return nil, ErrAccessDenied
+	}
+	return meta, nil
 }
 
 func (u *fileUsecase) SaveFile(ctx context.Context, input SaveFile) (string, error) {
+	ownerID, _ := strconv.ParseUint(input.UserID, 10, 64)
+	// заранее, чтобы не качать в хранилище то, что всё равно не влезет. Точная проверка — при записи метаданных
+	if err := u.checkQuota(ctx, uint(ownerID), input.SpoolID, input.Size); err != nil {
+		return "", err
+	}
+
 	fileLink, err := u.repo.SaveFile(ctx, input.Filename, input.File, input.Size, input.ContentType)
 	if err != nil {
 		return "", err
 	}
+
+	meta := &gdomain.File{
+		Bucket:      u.Bucket,
+		ObjectKey:   fileLink,
+		OwnerID:     uint(ownerID),
+		Kind:        input.FileType,
+		SpoolID:     input.SpoolID,
+		ThreadID:    input.ThreadID,
+		ContentType: input.ContentType,
+		Size:        input.Size,
+	}
+	if err := u.commit(ctx, meta); err != nil {
+		return "", err
+	}
 	return fileLink, nil
 }
+
+// commit — объект уже лежит в хранилище: проверяем его, записываем метаданные в пределах квоты
+// и ставим нарезку копий. Не вышло — объект удаляется
+func (u *fileUsecase) commit(ctx context.Context, meta *gdomain.File) error {
+	// Проверяем до записи метаданных: пока строки нет, файл не скачать и не прикрепить
+	if err := u.scanUpload(ctx, meta); err != nil {
+		return err
+	}
+	// аватарки и баннеры видят все и кэшируют надолго — координаты из EXIF не должны успеть утечь
+	if err := u.sanitizeImage(ctx, meta); err != nil {
+		return err
+	}
+	if err := u.access.Meta.CreateWithinQuota(ctx, meta, u.access.Quota); err != nil {
+		// без метаданных файл никто не скачает — не оставляем мусор
+		if delErr := u.repo.DeleteFile(ctx, meta.ObjectKey); delErr != nil {
+			u.logger.Error("failed to cleanup file without metadata", zap.Error(delErr), zap.String("file_link", meta.ObjectKey))
+		}
+		if errors.Is(err, external.ErrQuotaExceeded) {
+			return ErrQuotaExceeded
+		}
+		u.logger.Error("failed to save file metadata", zap.Error(err), zap.String("file_link", meta.ObjectKey))
+		return ErrSaveFailed
+	}
+
+	// копии картинок режем в фоне, загрузка их не ждёт
+	if u.access.Images != nil && hasVariants(meta.Kind) && imageContentTypes[meta.ContentType] {
+		if err := u.access.Images.Enqueue(gdomain.ImageJob{Bucket: u.Bucket, ObjectKey: meta.ObjectKey}); err != nil {
+			// без копий отдаётся оригинал, так что это не повод ронять загрузку
+			u.logger.Warn("failed to enqueue image job", zap.Error(err), zap.String("file_link", meta.ObjectKey))
+		}
+	}
+	return nil
+}
+
+// scanUpload проверяет уже сохранённый объект — именно его потом и будут отдавать.
+// Заражённый уезжает в карантин, наружу — ErrFileInfected
+func (u *fileUsecase) scanUpload(ctx context.Context, meta *gdomain.File) error {
+	if u.access.Scanner == nil {
+		return nil
+	}
+
+	res, err := u.scanObject(ctx, meta.ObjectKey)
+	if err != nil {
+		if u.access.ScanFailOpen {
+			u.logger.Warn("file scan failed, accepting upload unscanned", zap.Error(err), zap.String("file_link", meta.ObjectKey))
+			return nil
+		}
+		u.logger.Error("file scan failed", zap.Error(err), zap.String("file_link", meta.ObjectKey))
+		if delErr := u.repo.DeleteFile(ctx, meta.ObjectKey); delErr != nil {
+			u.logger.Error("failed to cleanup unscanned file", zap.Error(delErr), zap.String("file_link", meta.ObjectKey))
+		}
+		return ErrScanFailed
+	}
+	if !res.Infected {
+		return nil
+	}
+
+	u.logger.Warn("infected upload quarantined",
+		zap.String("file_link", meta.ObjectKey),
+		zap.String("signature", res.Signature),
+		zap.Uint("owner_id", meta.OwnerID))
+	u.quarantine(ctx, meta, res.Signature)
+	return ErrFileInfected
+}
+
+func (u *fileUsecase) scanObject(ctx context.Context, key string) (*external.ScanResult, error) {
+	obj, err := u.repo.GetFile(ctx, u.Bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return u.access.Scanner.Scan(ctx, obj)
+}
+
+// quarantine переносит объект в карантинный бакет (для разбора) и удаляет из рабочего.
+// Не получилось скопировать — всё равно удаляем: лучше потерять улику, чем раздавать вирус
+func (u *fileUsecase) quarantine(ctx context.Context, meta *gdomain.File, signature string) {
+	if err := u.copyToQuarantine(ctx, meta, signature); err != nil {
+		u.logger.Error("failed to quarantine file", zap.Error(err), zap.String("file_link", meta.ObjectKey))
+	}
+	if err := u.repo.DeleteFile(ctx, meta.ObjectKey); err != nil {
+		u.logger.Error("failed to delete infected file", zap.Error(err), zap.String("file_link", meta.ObjectKey))
+	}
+}
+
+func (u *fileUsecase) copyToQuarantine(ctx context.Context, meta *gdomain.File, signature string) error {
+	if u.access.QuarantineBucket == "" {
+		return nil
+	}
+	obj, err := u.repo.GetFile(ctx, u.Bucket, meta.ObjectKey)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	// ключ с исходным бакетом — чтобы при разборе было понятно, откуда файл
+	key := u.Bucket + "/" + meta.ObjectKey
+	if err := u.repo.PutObject(ctx, u.access.QuarantineBucket, key, obj, obj.Info.Size, meta.ContentType); err != nil {
+		return err
+	}
+	quarantined := *meta
+	quarantined.Bucket = u.access.QuarantineBucket
+	quarantined.ObjectKey = key
+	quarantined.Quarantine = signature
+	return u.access.Meta.Create(ctx, &quarantined)
+}
+
 func (u *fileUsecase) DeleteFile(ctx context.Context, input DeleteFileInput) error {
 	if input.Filename == "" {
 		return ErrInvalidInput
@@ -62,6 +267,102 @@ func (u *fileUsecase) DeleteFile(ctx context.Context, input DeleteFileInput) err
 		u.logger.Error("failed to delete file", zap.Error(err))
 		return ErrDeleteFailed
 	}
+	u.deleteVariants(ctx, input.Filename)
+	// объекта уже нет, строка без объекта ни на что не влияет — только логируем
+	if err := u.access.Meta.Delete(ctx, u.Bucket, input.Filename); err != nil {
+		u.logger.Warn("failed to delete file metadata", zap.Error(err), zap.String("file_link", input.Filename))
+	}
 
 	return nil
 }
+
+// deleteVariants — вместе с картинкой уходят её копии. Ошибки только логируем: оригинала уже нет
+func (u *fileUsecase) deleteVariants(ctx context.Context, filename string) {
+	meta, err := u.access.Meta.Get(ctx, u.Bucket, filename)
+	if err != nil {
+		return
+	}
+	variants, err := u.access.Meta.ListVariants(ctx, meta.ID)
+	if err != nil {
+		u.logger.Warn("failed to list image variants", zap.Error(err), zap.String("file_link", filename))
+		return
+	}
+	for _, v := range variants {
+		if err := u.repo.DeleteFile(ctx, v.ObjectKey); err != nil {
+			u.logger.Warn("failed to delete image variant", zap.Error(err), zap.String("file_link", v.ObjectKey))
+			continue
+		}
+		if err := u.access.Meta.Delete(ctx, u.Bucket, v.ObjectKey); err != nil {
+			u.logger.Warn("failed to delete image variant metadata", zap.Error(err), zap.String("file_link", v.ObjectKey))
+		}
+	}
+}
+
+func (u *fileUsecase) RegisterFile(ctx context.Context, input RegisterFileInput) error {
+	if input.Filename == "" {
+		return ErrInvalidInput
+	}
+	err := u.access.Meta.CreateWithinQuota(ctx, &gdomain.File{
+		Bucket:      u.Bucket,
+		ObjectKey:   input.Filename,
+		OwnerID:     input.OwnerID,
+		Kind:        input.Kind,
+		SpoolID:     input.SpoolID,
+		ThreadID:    input.ThreadID,
+		ContentType: input.ContentType,
+		Size:        input.Size,
+	}, u.access.Quota)
+	if errors.Is(err, external.ErrQuotaExceeded) {
+		// объект уже лежит и место занимает, хоть его никто и не посчитал — держать его незачем
+		if delErr := u.repo.DeleteFile(ctx, input.Filename); delErr != nil {
+			u.logger.Error("failed to delete file over quota", zap.Error(delErr), zap.String("file_link", input.Filename))
+		}
+		return ErrQuotaExceeded
+	}
+	return err
+}
+
+func (u *fileUsecase) AttachToMessage(ctx context.Context, input AttachToMessageInput) error {
+	return u.access.Meta.AttachToMessage(ctx, u.Bucket, input.Filenames, input.MessageID)
+}
+
+// GetFileLink — ссылка на файл для тех, кто не может прислать куку (плееры, нативные клиенты).
+// Direct — presigned URL самого MinIO, иначе наш /uploads с HMAC-подписью
+func (u *fileUsecase) GetFileLink(ctx context.Context, input GetFileLinkInput) (*FileLink, error) {
+	if input.Filename == "" || input.Bucket == "" {
+		return nil, ErrInvalidInput
+	}
+	if _, err := u.checkRead(ctx, input.Bucket, input.Filename, input.UserID, false); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(u.access.LinkTTL)
+
+	if input.Direct {
+		link, err := u.repo.PresignGet(ctx, input.Bucket, input.Filename, u.access.LinkTTL)
+		if errors.Is(err, external.ErrPresignNotSupported) {
+			return nil, ErrLinksDisabled
+		}
+		if err != nil {
+			u.logger.Error("failed to presign file", zap.Error(err))
+			return nil, ErrLinkFailed
+		}
+		return &FileLink{URL: link, ExpiresAt: expiresAt}, nil
+	}
+
+	if len(u.access.LinkSecret) == 0 {
+		return nil, ErrLinksDisabled
+	}
+	return &FileLink{URL: u.access.signedURL(input.Bucket, input.Filename, expiresAt), ExpiresAt: expiresAt}, nil
+}
+
+func (u *fileUsecase) VerifyLink(bucket, filename, exp, sig string) bool {
+	if len(u.access.LinkSecret) == 0 || sig == "" {
+		return false
+	}
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expUnix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(u.access.signLink(bucket, filename, expUnix)))
+}
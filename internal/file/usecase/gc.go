@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	"go.uber.org/zap"
+)
+
+// gcReportSample — столько ключей-сирот на бакет попадает в отчёт, остальные только считаются
+const gcReportSample = 50
+
+type GCUsecaseInterface interface {
+	// Collect — один проход по бакетам: объекты, на которые в Postgres никто не ссылается, удаляются.
+	// В dry-run только считаем, что удалили бы
+	Collect(ctx context.Context) (*GCReport, error)
+}
+
+type GCOptions struct {
+	GracePeriod time.Duration // объекты моложе не трогаем: загрузку могли ещё не записать в БД
+	DryRun      bool
+	BatchSize   int // столько ключей сверяем с БД за раз
+}
+
+type GCReport struct {
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	DryRun     bool             `json:"dry_run"`
+	Buckets    []GCBucketReport `json:"buckets"`
+}
+
+type GCBucketReport struct {
+	Bucket        string   `json:"bucket"`
+	Scanned       int      `json:"scanned"`
+	Young         int      `json:"young"` // моложе GracePeriod, пропущены
+	Orphaned      int      `json:"orphaned"`
+	OrphanedBytes int64    `json:"orphaned_bytes"`
+	Deleted       int      `json:"deleted"`
+	Failed        int      `json:"failed"`
+	Sample        []string `json:"sample,omitempty"` // первые из сирот
+	Error         string   `json:"error,omitempty"`  // бакет обойти не удалось
+}
+
+type gcUsecase struct {
+	repos  []external.FileRepoInterface // по репозиторию на бакет
+	refs   external.FileRefRepoInterface
+	meta   external.FileMetaRepoInterface
+	opts   GCOptions
+	logger *zap.Logger
+}
+
+func NewGCUsecase(repos []external.FileRepoInterface, refs external.FileRefRepoInterface, meta external.FileMetaRepoInterface, opts GCOptions, logger *zap.Logger) GCUsecaseInterface {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	return &gcUsecase{
+		repos:  repos,
+		refs:   refs,
+		meta:   meta,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+func (u *gcUsecase) Collect(ctx context.Context) (*GCReport, error) {
+	report := &GCReport{StartedAt: time.Now(), DryRun: u.opts.DryRun}
+	cutoff := report.StartedAt.Add(-u.opts.GracePeriod)
+
+	for _, repo := range u.repos {
+		bucket := u.collectBucket(ctx, repo, cutoff)
+		report.Buckets = append(report.Buckets, bucket)
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// collectBucket — ошибка одного бакета не останавливает остальные, она уходит в отчёт
+func (u *gcUsecase) collectBucket(ctx context.Context, repo external.FileRepoInterface, cutoff time.Time) GCBucketReport {
+	report := GCBucketReport{Bucket: repo.GetBucketName()}
+	batch := make([]external.StoredObject, 0, u.opts.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := u.collectBatch(ctx, repo, batch, &report)
+		batch = batch[:0]
+		return err
+	}
+	err := repo.ListObjects(ctx, func(obj external.StoredObject) error {
+		report.Scanned++
+		if obj.LastModified.After(cutoff) {
+			report.Young++
+			return nil
+		}
+		batch = append(batch, obj)
+		if len(batch) < u.opts.BatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		u.logger.Error("file gc failed", zap.Error(err), zap.String("bucket", report.Bucket))
+		report.Error = err.Error()
+	}
+	return report
+}
+
+func (u *gcUsecase) collectBatch(ctx context.Context, repo external.FileRepoInterface, batch []external.StoredObject, report *GCBucketReport) error {
+	keys := make([]string, len(batch))
+	for i, obj := range batch {
+		keys[i] = obj.Key
+	}
+	referenced, err := u.refs.Referenced(ctx, report.Bucket, keys)
+	if err != nil {
+		return fmt.Errorf("failed to check references: %w", err)
+	}
+
+	for _, obj := range batch {
+		if referenced[obj.Key] {
+			continue
+		}
+		report.Orphaned++
+		report.OrphanedBytes += obj.Size
+		if len(report.Sample) < gcReportSample {
+			report.Sample = append(report.Sample, obj.Key)
+		}
+		if u.opts.DryRun {
+			continue
+		}
+
+		if err := repo.DeleteFile(ctx, obj.Key); err != nil {
+			report.Failed++
+			u.logger.Warn("failed to delete orphaned file", zap.Error(err), zap.String("bucket", report.Bucket), zap.String("file_link", obj.Key))
+			continue
+		}
+		// строка в files тоже уходит — вместе с ней освобождается место в квоте
+		if err := u.meta.Delete(ctx, report.Bucket, obj.Key); err != nil {
+			u.logger.Warn("failed to delete orphaned file metadata", zap.Error(err), zap.String("bucket", report.Bucket), zap.String("file_link", obj.Key))
+		}
+		report.Deleted++
+	}
+	return nil
+}
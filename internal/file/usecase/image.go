@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"go.uber.org/zap"
+)
+
+// originalJPEGQuality — с таким качеством пересжимаем оригинал, если из него пришлось вырезать EXIF
+const originalJPEGQuality = 92
+
+type ImageUsecaseInterface interface {
+	// ProcessImage — нарезать копии. Метаданные из оригинала вычищены ещё при загрузке. Повторный запуск безопасен
+	ProcessImage(ctx context.Context, job gdomain.ImageJob) error
+}
+
+// ImageVariants — какие копии режем. Аватарки — квадраты, баннеры — по ширине
+type ImageVariants struct {
+	AvatarSizes []int
+	BannerSizes []int
+	MaxPixels   int   // больше — не декодируем вовсе, чтобы одна картинка не съела память
+	MaxBytes    int64 // столько максимум читаем из хранилища
+	Quality     int   // качество jpeg у копий
+}
+
+// hasVariants — копии режем только аватаркам и баннерам, вложения отдаём как загрузили
+func hasVariants(kind string) bool {
+	return kind == gdomain.FileKindAvatar || kind == gdomain.FileKindSpoolBanner
+}
+
+// sizesFor — размеры копий и квадратные ли они
+func (v ImageVariants) sizesFor(kind string) ([]int, bool) {
+	switch kind {
+	case gdomain.FileKindAvatar:
+		return v.AvatarSizes, true
+	case gdomain.FileKindSpoolBanner:
+		return v.BannerSizes, false
+	default:
+		return nil, false
+	}
+}
+
+type imageUsecase struct {
+	repo     external.FileRepoInterface
+	meta     external.FileMetaRepoInterface
+	variants ImageVariants
+	logger   *zap.Logger
+}
+
+func NewImageUsecase(repo external.FileRepoInterface, meta external.FileMetaRepoInterface, variants ImageVariants, logger *zap.Logger) ImageUsecaseInterface {
+	return &imageUsecase{
+		repo:     repo,
+		meta:     meta,
+		variants: variants,
+		logger:   logger,
+	}
+}
+
+func (u *imageUsecase) ProcessImage(ctx context.Context, job gdomain.ImageJob) error {
+	original, err := u.meta.Get(ctx, job.Bucket, job.ObjectKey)
+	if err != nil {
+		if errors.Is(err, external.ErrFileMetaNotFound) {
+			return nil // файл успели удалить
+		}
+		return fmt.Errorf("failed to get file metadata: %w", err)
+	}
+	sizes, square := u.variants.sizesFor(original.Kind)
+	if len(sizes) == 0 || original.ParentID != nil {
+		return nil
+	}
+
+	data, contentType, err := u.readObject(ctx, job.Bucket, job.ObjectKey)
+	if err != nil {
+		return err
+	}
+	if !imageContentTypes[contentType] {
+		return nil
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImageDecode, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > u.variants.MaxPixels {
+		return fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImageDecode, err)
+	}
+	// оригиналы, загруженные до очистки при загрузке, ещё могут быть с поворотом в EXIF
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	b := img.Bounds()
+	for _, size := range sizes {
+		// не растягиваем: копия больше оригинала ничего не даст
+		if size <= 0 || size > b.Dx() || (square && size > b.Dy()) {
+			continue
+		}
+		var variant image.Image
+		if square {
+			variant = squareThumb(img, size)
+		} else {
+			variant = fitWidth(img, size)
+		}
+		if err := u.storeVariant(ctx, original, variant, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *imageUsecase) readObject(ctx context.Context, bucket, key string) ([]byte, string, error) {
+	obj, err := u.repo.GetFile(ctx, bucket, key)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open image: %w", err)
+	}
+	defer obj.Close()
+	if obj.Info.Size > u.variants.MaxBytes {
+		return nil, "", fmt.Errorf("%w: %d bytes", ErrImageTooLarge, obj.Info.Size)
+	}
+	data, err := io.ReadAll(io.LimitReader(obj, u.variants.MaxBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+	return data, obj.Info.ContentType, nil
+}
+
+// sanitizeImage перезаписывает оригинал аватарки или баннера без EXIF — до того, как появится строка метаданных.
+// Не читается или слишком большая — загрузку не принимаем: проверить, что в ней нет координат, нечем
+func (u *fileUsecase) sanitizeImage(ctx context.Context, meta *gdomain.File) error {
+	if !hasVariants(meta.Kind) || !imageContentTypes[meta.ContentType] {
+		return nil
+	}
+
+	clean, changed, err := u.stripObject(ctx, meta.ObjectKey)
+	if err == nil && changed {
+		err = u.repo.PutObject(ctx, u.Bucket, meta.ObjectKey, bytes.NewReader(clean), int64(len(clean)), meta.ContentType)
+		if err != nil {
+			u.logger.Error("failed to rewrite original", zap.Error(err), zap.String("file_link", meta.ObjectKey))
+			err = ErrSaveFailed
+		}
+	}
+	if err != nil {
+		if delErr := u.repo.DeleteFile(ctx, meta.ObjectKey); delErr != nil {
+			u.logger.Error("failed to cleanup unsanitized image", zap.Error(delErr), zap.String("file_link", meta.ObjectKey))
+		}
+		return err
+	}
+	if changed {
+		meta.Size = int64(len(clean))
+	}
+	return nil
+}
+
+func (u *fileUsecase) stripObject(ctx context.Context, key string) ([]byte, bool, error) {
+	obj, err := u.repo.GetFile(ctx, u.Bucket, key)
+	if err != nil {
+		u.logger.Error("failed to open image", zap.Error(err), zap.String("file_link", key))
+		return nil, false, ErrSaveFailed
+	}
+	defer obj.Close()
+	if obj.Info.Size > u.access.ImageMaxBytes {
+		return nil, false, ErrImageTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(obj, u.access.ImageMaxBytes))
+	if err != nil {
+		u.logger.Error("failed to read image", zap.Error(err), zap.String("file_link", key))
+		return nil, false, ErrSaveFailed
+	}
+	return stripMetadata(data, u.access.ImageMaxPixels)
+}
+
+// stripMetadata — картинка без EXIF/XMP/IPTC; false — вычищать нечего. png и webp чистим без пересжатия,
+// jpeg пересжимаем — иначе вместе с EXIF пропал бы поворот
+func stripMetadata(data []byte, maxPixels int) ([]byte, bool, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, ErrImageDecode
+	}
+	switch format {
+	case "jpeg":
+		if !jpegHasMetadata(data) {
+			return nil, false, nil
+		}
+		if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+			return nil, false, ErrImageTooLarge
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, false, ErrImageDecode
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, orient(img, jpegOrientation(data)), &jpeg.Options{Quality: originalJPEGQuality}); err != nil {
+			return nil, false, fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.Bytes(), true, nil
+	case "png":
+		clean, changed := pngStripMetadata(data)
+		return clean, changed, nil
+	case "webp":
+		clean, changed := webpStripMetadata(data)
+		return clean, changed, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func (u *imageUsecase) storeVariant(ctx context.Context, original *gdomain.File, img image.Image, size int) error {
+	data, ext, contentType, err := encodeVariant(img, u.variants.Quality)
+	if err != nil {
+		return fmt.Errorf("failed to encode variant: %w", err)
+	}
+	key := variantKey(original.ObjectKey, size, ext)
+	if err := u.repo.PutObject(ctx, original.Bucket, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return fmt.Errorf("failed to store variant: %w", err)
+	}
+
+	// права у копии те же, что у оригинала
+	return u.meta.Create(ctx, &gdomain.File{
+		Bucket:      original.Bucket,
+		ObjectKey:   key,
+		OwnerID:     original.OwnerID,
+		Kind:        original.Kind,
+		SpoolID:     original.SpoolID,
+		ThreadID:    original.ThreadID,
+		ParentID:    &original.ID,
+		Variant:     size,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	})
+}
+
+// variantKey — <uuid>.png → <uuid>_128.jpg, рядом с оригиналом
+func variantKey(key string, size int, ext string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + strconv.Itoa(size) + ext
+}
+
+// pickVariant — самая маленькая копия не меньше запрошенного размера; nil — отдаём оригинал
+func pickVariant(variants []gdomain.File, size int) *gdomain.File {
+	for i := range variants {
+		if variants[i].Variant >= size {
+			return &variants[i]
+		}
+	}
+	return nil
+}
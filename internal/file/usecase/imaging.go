@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // регистрирует декодер webp для image.Decode
+)
+
+// imageContentTypes — что умеем декодировать; остальные картинки (gif, svg) отдаём как есть
+var imageContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/webp": true,
+}
+
+// jpegOrientation — тег Orientation из EXIF (1..8), 1 если его нет.
+// Декодер его не применяет, а EXIF мы выкидываем — поворачивать приходится самим
+func jpegOrientation(data []byte) int {
+	for _, seg := range jpegSegments(data) {
+		if seg.marker != 0xE1 || !bytes.HasPrefix(seg.body, []byte("Exif\x00\x00")) {
+			continue
+		}
+		tiff := seg.body[6:]
+		if len(tiff) < 8 {
+			return 1
+		}
+		var order binary.ByteOrder
+		switch string(tiff[:2]) {
+		case "II":
+			order = binary.LittleEndian
+		case "MM":
+			order = binary.BigEndian
+		default:
+			return 1
+		}
+		ifd := int(order.Uint32(tiff[4:8]))
+		if ifd+2 > len(tiff) {
+			return 1
+		}
+		count := int(order.Uint16(tiff[ifd:]))
+		for i := 0; i < count; i++ {
+			entry := ifd + 2 + i*12
+			if entry+12 > len(tiff) {
+				return 1
+			}
+			if order.Uint16(tiff[entry:]) == 0x0112 {
+				if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+					return o
+				}
+				return 1
+			}
+		}
+	}
+	return 1
+}
+
+type jpegSegment struct {
+	marker byte
+	body   []byte
+}
+
+// jpegSegments — сегменты заголовка до начала сжатых данных (SOS)
+func jpegSegments(data []byte) []jpegSegment {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	var segs []jpegSegment
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return segs
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return segs
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return segs
+		}
+		segs = append(segs, jpegSegment{marker: marker, body: data[i+4 : i+2+length]})
+		i += 2 + length
+	}
+	return segs
+}
+
+// jpegHasMetadata — EXIF/XMP (APP1) или IPTC (APP13): там бывают координаты и модель телефона
+func jpegHasMetadata(data []byte) bool {
+	for _, seg := range jpegSegments(data) {
+		if seg.marker == 0xE1 || seg.marker == 0xED {
+			return true
+		}
+	}
+	return false
+}
+
+// orient разворачивает картинку так, как её показал бы просмотрщик с учётом EXIF
+func orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // зеркально по горизонтали
+				dx, dy = w-1-x, y
+			case 3: // 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // зеркально по вертикали
+				dx, dy = x, h-1-y
+			case 5: // транспонирование
+				dx, dy = y, x
+			case 6: // 90° по часовой
+				dx, dy = h-1-y, x
+			case 7: // транспонирование по побочной диагонали
+				dx, dy = h-1-y, w-1-x
+			case 8: // 90° против часовой
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// pngStripMetadata выкидывает eXIf и текстовые чанки, пиксели не трогает
+func pngStripMetadata(data []byte) ([]byte, bool) {
+	const sig = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(sig)) {
+		return data, false
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, sig...)
+	changed := false
+	for i := len(sig); i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return data, false
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt":
+			changed = true
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, changed
+}
+
+// webpStripMetadata выкидывает чанки EXIF и XMP и снимает их флаги в VP8X
+func webpStripMetadata(data []byte) ([]byte, bool) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return data, false
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	changed := false
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2 // чанки выровнены по двум байтам
+		if end > len(data) {
+			end = len(data)
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+			changed = true
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				out[start+8] &^= 0x08 | 0x04 // флаги EXIF и XMP
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	if !changed {
+		return data, false
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, true
+}
+
+// squareThumb — квадрат size×size из середины картинки (как аватарки и показывают)
+func squareThumb(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2))
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
+
+// fitWidth — уменьшить до ширины width с сохранением пропорций
+func fitWidth(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	height := max(1, b.Dy()*width/b.Dx())
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// encodeVariant — без прозрачности jpeg (он сильно меньше), с прозрачностью png
+func encodeVariant(img image.Image, quality int) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	if isOpaque(img) {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", "", err
+		}
+		return buf.Bytes(), ".jpg", "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), ".png", "image/png", nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
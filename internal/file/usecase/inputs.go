@@ -1,10 +1,26 @@
 package usecase
 
-import "io"
+import (
+	"io"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
 
 type GetFileInput struct {
 	Filename string
 	Bucket   string
+	UserID   uint
+	Signed   bool // пришли по подписанной ссылке, права не проверяем
+	Size     int  // нужна уменьшенная копия картинки не меньше такого размера, 0 — оригинал
+}
+
+// FileContent — что реально отдаём: копию картинки или сам файл
+type FileContent struct {
+	*external.FileObject
+	Key   string
+	Exact bool // false — просили копию, а её ещё не нарезали, отдаём оригинал
 }
 
 type SaveFile struct {
@@ -14,8 +30,78 @@ type SaveFile struct {
 	ContentType string
 	UserID      string
 	FileType    string
+	SpoolID     *uint // к чему относится файл, от этого зависит, кому его отдавать
+	ThreadID    *uint
 }
 
 type DeleteFileInput struct {
 	Filename string
 }
+
+type RegisterFileInput struct {
+	Filename    string
+	OwnerID     uint
+	Kind        string
+	SpoolID     *uint
+	ThreadID    *uint
+	ContentType string
+	Size        int64
+}
+
+type AttachToMessageInput struct {
+	Filenames []string
+	MessageID uint
+}
+
+type GetFileLinkInput struct {
+	UserID   uint
+	Bucket   string
+	Filename string
+	Direct   bool
+}
+
+type FileLink struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// Usage — занятое место; LimitBytes 0 — без лимита
+type Usage struct {
+	Bytes      int64
+	Files      int64
+	LimitBytes int64
+}
+
+type GetSpoolUsageInput struct {
+	UserID  uint
+	SpoolID uint
+}
+
+type SpoolUsage struct {
+	Usage
+	ByKind  []external.UsageByKind
+	ByOwner []external.UsageByOwner
+	Largest []gdomain.File
+}
+
+type InitUploadInput struct {
+	UserID   uint
+	Filename string
+	Size     int64
+	Kind     string
+	SpoolID  *uint
+	ThreadID *uint
+}
+
+type UploadPartInput struct {
+	UserID   uint
+	UploadID string
+	Offset   int64 // с какого байта эта часть, должен совпасть с уже принятым
+	Body     io.Reader
+	Size     int64
+}
+
+type UploadRefInput struct {
+	UserID   uint
+	UploadID string
+}
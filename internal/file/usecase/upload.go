@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onionfriend2004/threadbook_backend/internal/file/external"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"go.uber.org/zap"
+)
+
+const (
+	// uploadLockTTL — запрос с частью умер, не сняв блокировку; с запасом на медленную сеть
+	uploadLockTTL = 10 * time.Minute
+	// uploadSniffLen — столько первых байт смотрим, чтобы понять тип файла
+	uploadSniffLen = 512
+	// uploadSweepSlack — сессия могла истечь чуть позже, чем стартовала multipart-загрузка
+	uploadSweepSlack = time.Minute
+)
+
+func (u *fileUsecase) InitUpload(ctx context.Context, input InitUploadInput) (*gdomain.UploadSession, error) {
+	if u.access.Uploads == nil {
+		return nil, ErrUploadsDisabled
+	}
+	if input.UserID == 0 || input.Filename == "" || input.Size <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if err := u.checkQuota(ctx, input.UserID, input.SpoolID, input.Size); err != nil {
+		return nil, err
+	}
+
+	// объект в хранилище заводим с первой частью: тип файла узнаем только по его первым байтам
+	now := time.Now()
+	s := &gdomain.UploadSession{
+		ID:        uuid.NewString(),
+		UserID:    input.UserID,
+		Kind:      input.Kind,
+		SpoolID:   input.SpoolID,
+		ThreadID:  input.ThreadID,
+		Bucket:    u.Bucket,
+		Filename:  filepath.Base(input.Filename),
+		Size:      input.Size,
+		PartSize:  u.access.uploadPartSize(),
+		Parts:     []gdomain.UploadPart{},
+		CreatedAt: now,
+		ExpiresAt: now.Add(u.access.UploadTTL),
+	}
+	if err := u.access.Uploads.Save(ctx, s); err != nil {
+		u.logger.Error("failed to save upload session", zap.Error(err))
+		return nil, ErrSaveFailed
+	}
+	return s, nil
+}
+
+func (u *fileUsecase) UploadPart(ctx context.Context, input UploadPartInput) (*gdomain.UploadSession, error) {
+	if u.access.Uploads == nil {
+		return nil, ErrUploadsDisabled
+	}
+	if input.Body == nil || input.Size <= 0 {
+		return nil, ErrInvalidInput
+	}
+	unlock, err := u.lockUpload(ctx, input.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	s, err := u.getUpload(ctx, input.UploadID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if input.Offset != s.Offset() {
+		return nil, ErrUploadOffsetMismatch
+	}
+	if input.Size != s.NextPartSize() {
+		return nil, ErrInvalidPart
+	}
+
+	body := input.Body
+	if s.MultipartID == "" {
+		if body, err = u.startMultipart(ctx, s, body, input.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	number := len(s.Parts) + 1
+	etag, err := u.repo.PutPart(ctx, s.ObjectKey, s.MultipartID, number, body, input.Size)
+	if err != nil {
+		if errors.Is(err, external.ErrUploadNotFound) {
+			u.dropSession(ctx, s.ID)
+			return nil, ErrUploadNotFound
+		}
+		// обычно клиент оборвал соединение; та же часть с того же места примется заново
+		u.logger.Warn("failed to put upload part", zap.Error(err), zap.String("upload_id", s.ID), zap.Int("part", number))
+		return nil, ErrSaveFailed
+	}
+
+	s.Parts = append(s.Parts, gdomain.UploadPart{Number: number, ETag: etag, Size: input.Size})
+	if err := u.access.Uploads.Save(ctx, s); err != nil {
+		if errors.Is(err, external.ErrUploadSessionNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		u.logger.Error("failed to save upload session", zap.Error(err), zap.String("upload_id", s.ID))
+		return nil, ErrSaveFailed
+	}
+	return s, nil
+}
+
+// startMultipart смотрит на первые байты, заводит объект с настоящим типом и отдаёт тело целиком обратно
+func (u *fileUsecase) startMultipart(ctx context.Context, s *gdomain.UploadSession, body io.Reader, size int64) (io.Reader, error) {
+	head := make([]byte, min(size, uploadSniffLen))
+	if _, err := io.ReadFull(body, head); err != nil {
+		return nil, ErrInvalidPart
+	}
+
+	contentType := "application/octet-stream"
+	if u.access.Detect != nil {
+		detected, err := u.access.Detect(bytes.NewReader(head), s.Filename)
+		if err != nil {
+			return nil, ErrContentTypeMismatch
+		}
+		contentType = detected
+	}
+
+	key, multipartID, err := u.repo.NewMultipartUpload(ctx, s.Filename, contentType)
+	if err != nil {
+		u.logger.Error("failed to start multipart upload", zap.Error(err), zap.String("upload_id", s.ID))
+		return nil, ErrSaveFailed
+	}
+	s.ObjectKey, s.MultipartID, s.ContentType = key, multipartID, contentType
+	// сразу запоминаем: если первая часть не дойдёт, загрузку в хранилище ещё надо будет бросить
+	if err := u.access.Uploads.Save(ctx, s); err != nil {
+		u.logger.Error("failed to save upload session", zap.Error(err), zap.String("upload_id", s.ID))
+		u.abortMultipart(ctx, s)
+		return nil, ErrSaveFailed
+	}
+	return io.MultiReader(bytes.NewReader(head), body), nil
+}
+
+func (u *fileUsecase) GetUpload(ctx context.Context, input UploadRefInput) (*gdomain.UploadSession, error) {
+	if u.access.Uploads == nil {
+		return nil, ErrUploadsDisabled
+	}
+	return u.getUpload(ctx, input.UploadID, input.UserID)
+}
+
+func (u *fileUsecase) CompleteUpload(ctx context.Context, input UploadRefInput) (*gdomain.UploadSession, error) {
+	if u.access.Uploads == nil {
+		return nil, ErrUploadsDisabled
+	}
+	unlock, err := u.lockUpload(ctx, input.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	s, err := u.getUpload(ctx, input.UploadID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if s.MultipartID == "" || s.Offset() != s.Size {
+		return nil, ErrUploadIncomplete
+	}
+
+	parts := make([]external.CompletedPart, len(s.Parts))
+	for i, p := range s.Parts {
+		parts[i] = external.CompletedPart{Number: p.Number, ETag: p.ETag}
+	}
+	if err := u.repo.CompleteMultipartUpload(ctx, s.ObjectKey, s.MultipartID, parts); err != nil {
+		if errors.Is(err, external.ErrUploadNotFound) {
+			u.dropSession(ctx, s.ID)
+			return nil, ErrUploadNotFound
+		}
+		u.logger.Error("failed to complete multipart upload", zap.Error(err), zap.String("upload_id", s.ID))
+		return nil, ErrSaveFailed
+	}
+	// multipart-загрузки больше нет, сессия ни для чего не нужна, чем бы ни кончился commit
+	u.dropSession(ctx, s.ID)
+
+	meta := &gdomain.File{
+		Bucket:      u.Bucket,
+		ObjectKey:   s.ObjectKey,
+		OwnerID:     s.UserID,
+		Kind:        s.Kind,
+		SpoolID:     s.SpoolID,
+		ThreadID:    s.ThreadID,
+		ContentType: s.ContentType,
+		Size:        s.Size,
+	}
+	if err := u.commit(ctx, meta); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (u *fileUsecase) AbortUpload(ctx context.Context, input UploadRefInput) error {
+	if u.access.Uploads == nil {
+		return ErrUploadsDisabled
+	}
+	unlock, err := u.lockUpload(ctx, input.UploadID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s, err := u.getUpload(ctx, input.UploadID, input.UserID)
+	if err != nil {
+		return err
+	}
+	u.abortMultipart(ctx, s)
+	u.dropSession(ctx, s.ID)
+	return nil
+}
+
+func (u *fileUsecase) CleanupUploads(ctx context.Context) (int, error) {
+	if u.access.Uploads == nil {
+		return 0, nil
+	}
+	// сессии старше TTL Redis уже забыл, их части в хранилище больше никто не завершит
+	return u.repo.AbortStaleUploads(ctx, time.Now().Add(-u.access.UploadTTL-uploadSweepSlack))
+}
+
+// getUpload — чужая загрузка выглядит так же, как несуществующая
+func (u *fileUsecase) getUpload(ctx context.Context, uploadID string, userID uint) (*gdomain.UploadSession, error) {
+	if uploadID == "" || userID == 0 {
+		return nil, ErrInvalidInput
+	}
+	s, err := u.access.Uploads.Get(ctx, uploadID)
+	if err != nil {
+		if errors.Is(err, external.ErrUploadSessionNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		u.logger.Error("failed to get upload session", zap.Error(err), zap.String("upload_id", uploadID))
+		return nil, err
+	}
+	if s.UserID != userID || s.Bucket != u.Bucket {
+		return nil, ErrUploadNotFound
+	}
+	return s, nil
+}
+
+// lockUpload — части пишутся строго по очереди, иначе два запроса примут одно и то же смещение
+func (u *fileUsecase) lockUpload(ctx context.Context, uploadID string) (func(), error) {
+	if uploadID == "" {
+		return nil, ErrInvalidInput
+	}
+	locked, err := u.access.Uploads.Lock(ctx, uploadID, uploadLockTTL)
+	if err != nil {
+		u.logger.Error("failed to lock upload", zap.Error(err), zap.String("upload_id", uploadID))
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrUploadBusy
+	}
+	return func() {
+		// контекст запроса мог уже кончиться, а блокировку снять всё равно надо
+		if err := u.access.Uploads.Unlock(context.WithoutCancel(ctx), uploadID); err != nil {
+			u.logger.Warn("failed to unlock upload", zap.Error(err), zap.String("upload_id", uploadID))
+		}
+	}, nil
+}
+
+// abortMultipart — не вышло, так не вышло: незавершённое потом подберёт CleanupUploads
+func (u *fileUsecase) abortMultipart(ctx context.Context, s *gdomain.UploadSession) {
+	if s.MultipartID == "" {
+		return
+	}
+	err := u.repo.AbortMultipartUpload(ctx, s.ObjectKey, s.MultipartID)
+	if err != nil && !errors.Is(err, external.ErrUploadNotFound) {
+		u.logger.Warn("failed to abort multipart upload", zap.Error(err), zap.String("upload_id", s.ID))
+	}
+}
+
+func (u *fileUsecase) dropSession(ctx context.Context, id string) {
+	if err := u.access.Uploads.Delete(ctx, id); err != nil {
+		u.logger.Warn("failed to delete upload session", zap.Error(err), zap.String("upload_id", id))
+	}
+}
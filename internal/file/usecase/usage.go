@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"go.uber.org/zap"
+)
+
+// spoolUsageTop — столько загрузивших и самых больших файлов показываем владельцу спула
+const spoolUsageTop = 20
+
+// checkQuota — быстрый отказ до загрузки. Гонку с параллельными загрузками закрывает CreateWithinQuota
+func (u *fileUsecase) checkQuota(ctx context.Context, ownerID uint, spoolID *uint, size int64) error {
+	if err := u.fitsQuota(ctx, gdomain.StorageScopeUser, ownerID, size, u.access.Quota.UserBytes); err != nil {
+		return err
+	}
+	if spoolID == nil {
+		return nil
+	}
+	return u.fitsQuota(ctx, gdomain.StorageScopeSpool, *spoolID, size, u.access.Quota.SpoolBytes)
+}
+
+func (u *fileUsecase) fitsQuota(ctx context.Context, scope string, scopeID uint, size, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+	usage, err := u.access.Meta.GetUsage(ctx, scope, scopeID)
+	if err != nil {
+		// не смогли посчитать — решит проверка при записи метаданных
+		u.logger.Warn("failed to get storage usage", zap.Error(err), zap.String("scope", scope), zap.Uint("scope_id", scopeID))
+		return nil
+	}
+	if usage.Bytes+size > limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (u *fileUsecase) GetUsage(ctx context.Context, userID uint) (*Usage, error) {
+	if userID == 0 {
+		return nil, ErrInvalidInput
+	}
+	usage, err := u.access.Meta.GetUsage(ctx, gdomain.StorageScopeUser, userID)
+	if err != nil {
+		u.logger.Error("failed to get storage usage", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, err
+	}
+	return &Usage{Bytes: usage.Bytes, Files: usage.Files, LimitBytes: u.access.Quota.UserBytes}, nil
+}
+
+func (u *fileUsecase) GetSpoolUsage(ctx context.Context, input GetSpoolUsageInput) (*SpoolUsage, error) {
+	if input.UserID == 0 || input.SpoolID == 0 {
+		return nil, ErrInvalidInput
+	}
+	isOwner, err := u.access.Membership.IsSpoolOwner(ctx, input.SpoolID, input.UserID)
+	if err != nil {
+		u.logger.Error("failed to check spool owner", zap.Error(err))
+		return nil, err
+	}
+	if !isOwner {
+		return nil, ErrAccessDenied
+	}
+
+	usage, err := u.access.Meta.GetUsage(ctx, gdomain.StorageScopeSpool, input.SpoolID)
+	if err != nil {
+		u.logger.Error("failed to get storage usage", zap.Error(err), zap.Uint("spool_id", input.SpoolID))
+		return nil, err
+	}
+	breakdown, err := u.access.Meta.SpoolUsageBreakdown(ctx, input.SpoolID, spoolUsageTop)
+	if err != nil {
+		u.logger.Error("failed to get spool usage breakdown", zap.Error(err), zap.Uint("spool_id", input.SpoolID))
+		return nil, err
+	}
+
+	return &SpoolUsage{
+		Usage:   Usage{Bytes: usage.Bytes, Files: usage.Files, LimitBytes: u.access.Quota.SpoolBytes},
+		ByKind:  breakdown.ByKind,
+		ByOwner: breakdown.ByOwner,
+		Largest: breakdown.Largest,
+	}, nil
+}
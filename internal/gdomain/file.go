@@ -0,0 +1,51 @@
+package gdomain
+
+import "time"
+
+// Что за файл — от этого зависит, кому его можно отдавать
+const (
+	FileKindAvatar      = "avatar"             // видно всем залогиненным
+	FileKindSpoolBanner = "spool_banner"       // видно всем залогиненным, баннер показывают и в приглашениях
+	FileKindAttachment  = "message_attachment" // только участникам треда
+	FileKindRecording   = "voice_recording"    // только участникам треда
+)
+
+// File — метаданные объекта в MinIO: чей он и к чему относится. Объекта без строки здесь не отдаём
+type File struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	Bucket      string    `gorm:"size:63;not null;uniqueIndex:idx_files_bucket_object_key,priority:1"`
+	ObjectKey   string    `gorm:"type:text;not null;uniqueIndex:idx_files_bucket_object_key,priority:2"`
+	OwnerID     uint      `gorm:"not null;index"` // кто загрузил
+	Kind        string    `gorm:"size:32;not null"`
+	SpoolID     *uint     `gorm:"index"`
+	ThreadID    *uint     `gorm:"index"`
+	MessageID   *uint     `gorm:"index"`              // проставляется, когда вложение прикрепили к сообщению
+	ParentID    *uint     `gorm:"index"`              // у уменьшенной копии картинки — id оригинала
+	Variant     int       `gorm:"not null;default:0"` // размер копии в px, 0 — оригинал
+	Quarantine  string    `gorm:"size:255"`           // сигнатура, по которой антивирус отправил файл в карантин
+	ContentType string    `gorm:"size:255"`
+	Size        int64     `gorm:"not null;default:0"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// ImageJob — задача в NATS: нарезать копии картинки и вычистить из неё метаданные
+type ImageJob struct {
+	Bucket    string `json:"bucket"`
+	ObjectKey string `json:"object_key"`
+}
+
+// Чьё место считаем в StorageUsage
+const (
+	StorageScopeUser  = "user"
+	StorageScopeSpool = "spool"
+)
+
+// StorageUsage — сколько занимают файлы пользователя или спула. Двигается в одной транзакции
+// со строками files, так что считать заново по всей таблице не нужно. Карантин не считается
+type StorageUsage struct {
+	Scope     string    `gorm:"size:16;primaryKey"`
+	ScopeID   uint      `gorm:"primaryKey"`
+	Bytes     int64     `gorm:"not null;default:0"`
+	Files     int64     `gorm:"not null;default:0"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,69 @@
+package gdomain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Виды упоминаний
+const (
+	MentionUser   = "user"   // @username
+	MentionHere   = "here"   // @here
+	MentionThread = "thread" // @thread
+)
+
+// MessageMention — строка "пользователя упомянули в сообщении".
+// Для групповых упоминаний (@here/@thread) на каждого участника своя строка.
+type MessageMention struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	MessageID uint      `gorm:"not null;uniqueIndex:idx_mention_message_user"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_mention_message_user;index"` // кого упомянули
+	ThreadID  uint      `gorm:"not null;index"`
+	AuthorID  uint      `gorm:"not null"`
+	Kind      string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	// связи
+	Message Message `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+	Thread  Thread  `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE"`
+	User    User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// ParsedMentions — что нашли в тексте сообщения
+type ParsedMentions struct {
+	Usernames []string // нормализованные, без дублей
+	Here      bool
+	Thread    bool
+}
+
+// @ должна стоять в начале строки или после не-словесного символа, чтобы не ловить e-mail
+var mentionRe = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_.\-]+)`)
+
+// ParseMentions вытаскивает @username, @here и @thread из текста
+func ParseMentions(content string) ParsedMentions {
+	var parsed ParsedMentions
+	seen := make(map[string]struct{})
+
+	for _, match := range mentionRe.FindAllStringSubmatch(content, -1) {
+		// точка в конце предложения к нику не относится
+		name := NormalizeUsername(strings.TrimRight(match[1], ".-"))
+		switch name {
+		case "":
+			continue
+		case MentionHere:
+			parsed.Here = true
+			continue
+		case MentionThread:
+			parsed.Thread = true
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		parsed.Usernames = append(parsed.Usernames, name)
+	}
+
+	return parsed
+}
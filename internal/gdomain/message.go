@@ -1,28 +1,65 @@
 package gdomain
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Message struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	ThreadID  uint      `gorm:"not null;index"` // связь с Thread
-	UserID    uint      `gorm:"not null;index"` // автор сообщения
-	Content   string    `gorm:"type:text;not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID        uint           `gorm:"primaryKey;autoIncrement;index:idx_messages_thread_id_id,priority:2"`
+	ThreadID  uint           `gorm:"not null;index;index:idx_messages_thread_id_id,priority:1;index:idx_messages_thread_id_seq,priority:1"` // связь с Thread; (thread_id, id) — под курсорную пагинацию
+	Seq       uint64         `gorm:"not null;default:0;index:idx_messages_thread_id_seq,priority:2"`                                        // номер в журнале событий треда (см. ThreadEvent)
+	UserID    uint           `gorm:"not null;index"`                                                                                        // автор сообщения
+	ReplyToID *uint          `gorm:"index"`                                                                                                 // сообщение, на которое отвечаем (nil — обычное сообщение)
+	Content   string         `gorm:"type:text;not null"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"` // мягкое удаление, чтобы ответы могли показать "надгробие"
 
 	// связи
 	Thread   Thread           `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE"`
 	User     User             `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ReplyTo  *Message         `gorm:"foreignKey:ReplyToID;constraint:OnDelete:SET NULL"`
 	Payloads []MessagePayload `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+	Mentions []MessageMention `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+}
+
+// ReplyPreviewLen — сколько символов родительского сообщения показываем в цитате
+const ReplyPreviewLen = 100
+
+// IsDeleted сообщает, что сообщение удалено (осталось только "надгробие")
+func (m *Message) IsDeleted() bool {
+	return m.DeletedAt.Valid
 }
 
+// Preview обрезает текст сообщения до limit символов (по рунам, чтобы не резать кириллицу)
+func (m *Message) Preview(limit int) string {
+	runes := []rune(m.Content)
+	if len(runes) <= limit {
+		return m.Content
+	}
+	return string(runes[:limit]) + "…"
+}
+
+// MessagePayload — вложение сообщения.
+// Файл сначала загружается отдельно (MessageID = nil), а потом прикрепляется при отправке сообщения.
 type MessagePayload struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement"`
-	MessageID uint      `gorm:"not null;index"` // связь с Message
-	FileLink  string    `gorm:"type:text;not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	MessageID   *uint     `gorm:"index"` // связь с Message (nil — ещё не прикреплено)
+	ThreadID    uint      `gorm:"index"` // тред, в который загружали
+	UploaderID  uint      `gorm:"index"` // кто загрузил
+	Bucket      string    `gorm:"size:63"`
+	FileLink    string    `gorm:"type:text;not null"`
+	Filename    string    `gorm:"type:text"` // исходное имя файла
+	ContentType string    `gorm:"size:255"`
+	Size        int64     `gorm:"not null;default:0"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
 
 	// связь
 	Message Message `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE;"`
 }
+
+// MaxAttachmentsPerMessage — сколько вложений можно прикрепить к одному сообщению
+const MaxAttachmentsPerMessage = 10
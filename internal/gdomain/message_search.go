@@ -0,0 +1,17 @@
+package gdomain
+
+// Границы подсветки в сниппете поиска. Управляющие символы не встречаются в тексте,
+// поэтому сниппет можно безопасно экранировать и только потом превратить их в разметку.
+const (
+	SearchHighlightStart = "\x02"
+	SearchHighlightStop  = "\x03"
+)
+
+// MessageSearchHit — найденное сообщение с подсвеченным фрагментом и релевантностью
+type MessageSearchHit struct {
+	Message     Message
+	SpoolID     uint
+	ThreadTitle string
+	Snippet     string
+	Rank        float64
+}
@@ -0,0 +1,24 @@
+package gdomain
+
+import "time"
+
+// OutboxEvent — real-time событие, записанное в одной транзакции с доменным изменением.
+// Релей забирает неотправленные строки и публикует их в Centrifugo, пока не получится.
+// Рассылка по нескольким каналам — по строке на канал с общим IdempotencyKey, релей склеивает их в один broadcast.
+type OutboxEvent struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement;index:idx_outbox_events_channel_id,priority:2"`
+	Channel        string     `gorm:"size:255;not null;index:idx_outbox_events_channel_id,priority:1;uniqueIndex:idx_outbox_events_channel_key,priority:1"` // user#1, thread#2, ...; порядок держим внутри канала
+	Payload        []byte     `gorm:"type:jsonb;not null"`                                                                                                  // готовый event.Event
+	IdempotencyKey string     `gorm:"size:64;not null;uniqueIndex:idx_outbox_events_channel_key,priority:2"`                                                // он же event.Event.ID, Centrifugo по нему отбрасывает повторы
+	Attempts       int        `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"not null;index"`
+	LastError      string     `gorm:"type:text"`
+	PublishedAt    *time.Time `gorm:"index"` // nil — ещё не отправлено
+	DeadAt         *time.Time // попытки кончились, больше не трогаем
+	CreatedAt      time.Time  `gorm:"autoCreateTime"`
+}
+
+// IsPending — событие ещё ждёт отправки
+func (e *OutboxEvent) IsPending() bool {
+	return e.PublishedAt == nil && e.DeadAt == nil
+}
@@ -0,0 +1,22 @@
+package gdomain
+
+import "time"
+
+const (
+	PresenceOnline  = "online"
+	PresenceOffline = "offline"
+)
+
+// SpoolMember — участник спула вместе со статусом присутствия
+type SpoolMember struct {
+	User   User
+	Online bool
+}
+
+// VoiceParticipant — кто сейчас в голосовой комнате треда (по вебхукам LiveKit)
+type VoiceParticipant struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"` // identity в LiveKit
+	SID      string    `json:"sid"`      // id участника в LiveKit, новый на каждый вход
+	JoinedAt time.Time `json:"joined_at"`
+}
@@ -0,0 +1,40 @@
+package gdomain
+
+import "time"
+
+// Статусы записи голосовой комнаты
+const (
+	RecordingStarting  = "starting"  // egress запрошен, ещё не подтвердил
+	RecordingActive    = "active"    // пишется
+	RecordingStopping  = "stopping"  // попросили остановить, ждём вебхук egress_ended
+	RecordingCompleted = "completed" // файл в бакете и приложен к сообщению
+	RecordingFailed    = "failed"
+)
+
+// VoiceRecording — запись голосовой комнаты треда через LiveKit Egress
+type VoiceRecording struct {
+	ID                uint      `gorm:"primaryKey;autoIncrement"`
+	ThreadID          uint      `gorm:"not null;index"`
+	StartedBy         uint      `gorm:"not null"`                // от его имени потом придёт сообщение с записью
+	StartedByUsername string    `gorm:"type:text;not null"`      // чтобы не ходить за ним при вебхуке
+	EgressID          string    `gorm:"size:64;not null;unique"` // id egress в LiveKit
+	Status            string    `gorm:"size:16;not null;index"`  // см. Recording*
+	Bucket            string    `gorm:"size:63"`
+	FileLink          string    `gorm:"type:text;not null"` // ключ объекта в бакете
+	Size              int64     `gorm:"not null;default:0"`
+	DurationMs        int64     `gorm:"not null;default:0"`
+	Error             string    `gorm:"type:text"`
+	MessageID         *uint     `gorm:"index"` // сообщение, к которому приложили файл
+	StartedAt         time.Time `gorm:"autoCreateTime"`
+	EndedAt           *time.Time
+	UpdatedAt         time.Time `gorm:"autoUpdateTime"`
+
+	// связи
+	Thread  Thread   `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE"`
+	Message *Message `gorm:"foreignKey:MessageID;constraint:OnDelete:SET NULL"`
+}
+
+// IsFinished — дальше статус уже не меняется
+func (r *VoiceRecording) IsFinished() bool {
+	return r.Status == RecordingCompleted || r.Status == RecordingFailed
+}
@@ -19,6 +19,16 @@ type Spool struct {
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
 
+// SpoolRole — роль пользователя относительно треда в спуле. Отдельной таблицы ролей нет:
+// владелец — создатель спула, модератор — создатель треда, остальные — участники
+type SpoolRole string
+
+const (
+	SpoolRoleOwner     SpoolRole = "owner"
+	SpoolRoleModerator SpoolRole = "moderator"
+	SpoolRoleMember    SpoolRole = "member"
+)
+
 // UserSpool — join таблица пользователь <-> спул
 type UserSpool struct {
 	UserID    uint `gorm:"primaryKey"`
@@ -46,8 +56,10 @@ func NewSpool(name, bannerLink string, creatorID uint) (*Spool, error) {
 }
 
 type SpoolWithCreator struct {
-	ID         uint
-	Name       string
-	BannerLink string
-	IsCreator  bool
+	ID           uint
+	Name         string
+	BannerLink   string
+	IsCreator    bool
+	UnreadCount  int64 // сумма непрочитанных по тредам спула, где пользователь участник
+	MentionCount int64
 }
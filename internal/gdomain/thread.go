@@ -11,6 +11,8 @@ type Thread struct {
 	Title     string    `gorm:"column:title;not null"`
 	Type      string    `gorm:"column:type;not null"`
 	IsClosed  bool      `gorm:"column:is_closed;not null"`
+	StageMode bool      `gorm:"column:stage_mode;not null;default:false"` // голосовая комната-сцена: говорят только спикеры
+	LastSeq   uint64    `gorm:"column:last_seq;not null;default:0"`       // последний выданный seq событий треда
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
 
@@ -20,7 +22,22 @@ type Thread struct {
 }
 
 type ThreadUser struct {
-	UserID   uint `gorm:"primaryKey"`
-	ThreadID uint `gorm:"primaryKey"`
-	IsMember bool `gorm:"default:true"`
+	UserID            uint `gorm:"primaryKey"`
+	ThreadID          uint `gorm:"primaryKey"`
+	IsMember          bool `gorm:"default:true"`
+	LastReadMessageID uint `gorm:"not null;default:0"` // маркер прочтения, двигается только вперёд
+}
+
+// ThreadUnread — счётчики непрочитанного пользователя в треде (свои сообщения не считаются)
+type ThreadUnread struct {
+	ThreadID          uint
+	LastReadMessageID uint
+	UnreadCount       int64
+	MentionCount      int64
+}
+
+// ThreadWithUnread — тред из списка спула вместе со счётчиками
+type ThreadWithUnread struct {
+	Thread *Thread
+	Unread ThreadUnread
 }
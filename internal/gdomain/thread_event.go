@@ -0,0 +1,19 @@
+package gdomain
+
+import "time"
+
+// ThreadEvent — запись журнала треда. Каждое сохранённое сообщение и каждое изменение
+// (правка, удаление, реакция) получает следующий seq треда, так что клиент после переподключения
+// забирает всё пропущенное по "since seq N" строго по порядку.
+type ThreadEvent struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ThreadID  uint      `gorm:"not null;uniqueIndex:idx_thread_events_thread_id_seq,priority:1"`
+	Seq       uint64    `gorm:"not null;uniqueIndex:idx_thread_events_thread_id_seq,priority:2"`
+	Type      string    `gorm:"size:64;not null"` // event.Type: message.created, message.updated, ...
+	MessageID *uint     `gorm:"index"`            // к какому сообщению относится (если относится)
+	Payload   []byte    `gorm:"type:jsonb"`       // то же, что ушло в thread#<id>; для message.created пусто — сообщение подгружается
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	Thread  Thread   `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE"`
+	Message *Message `gorm:"foreignKey:MessageID;constraint:OnDelete:SET NULL"`
+}
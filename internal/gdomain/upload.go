@@ -0,0 +1,43 @@
+package gdomain
+
+import "time"
+
+// UploadSession — докачиваемая загрузка. Объект собирается в хранилище по частям (multipart),
+// какие части уже приняты — помним в Redis, пока загрузка не завершена или не истекла
+type UploadSession struct {
+	ID          string       `json:"id"`
+	UserID      uint         `json:"user_id"`
+	Kind        string       `json:"kind"`
+	SpoolID     *uint        `json:"spool_id,omitempty"`
+	ThreadID    *uint        `json:"thread_id,omitempty"`
+	Bucket      string       `json:"bucket"`
+	ObjectKey   string       `json:"object_key"`
+	MultipartID string       `json:"multipart_id"`
+	Filename    string       `json:"filename"`
+	ContentType string       `json:"content_type"`
+	Size        int64        `json:"size"`      // объявленный при старте размер, больше не примем
+	PartSize    int64        `json:"part_size"` // все части, кроме последней, ровно такого размера
+	Parts       []UploadPart `json:"parts"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   time.Time    `json:"expires_at"` // не успели — загрузку бросаем целиком
+}
+
+type UploadPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// Offset — сколько байт уже принято; с этого места клиент продолжает
+func (s *UploadSession) Offset() int64 {
+	var offset int64
+	for _, p := range s.Parts {
+		offset += p.Size
+	}
+	return offset
+}
+
+// NextPartSize — сколько байт ждём следующей частью
+func (s *UploadSession) NextPartSize() int64 {
+	return min(s.PartSize, s.Size-s.Offset())
+}
@@ -0,0 +1,69 @@
+package dbtx
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type ctxKey struct{}
+
+// txState — открытая транзакция и то, что надо сделать после её коммита
+type txState struct {
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// TransactorInterface открывает транзакцию и кладёт её в контекст.
+// Репозитории достают её через DB, поэтому usecase может склеить записи разных репо в одну транзакцию.
+type TransactorInterface interface {
+	WithinTx(ctx context.Context, fn func(txCtx context.Context) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) TransactorInterface {
+	return &transactor{db: db}
+}
+
+// WithinTx выполняет fn в транзакции. Если в ctx уже есть транзакция — присоединяемся к ней,
+// коммит и хуки остаются за внешним вызовом.
+func (t *transactor) WithinTx(ctx context.Context, fn func(txCtx context.Context) error) error {
+	if _, ok := ctx.Value(ctxKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, ctxKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// DB возвращает транзакцию из ctx, а если её нет — обычное подключение
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := ctx.Value(ctxKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db.WithContext(ctx)
+}
+
+// AfterCommit откладывает fn до успешного коммита транзакции из ctx.
+// Вне транзакции fn вызывается сразу. При откате не вызывается вовсе.
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(ctxKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
@@ -8,14 +8,36 @@ const (
 	MessageUpdated Type = "message.updated"
 	MessageDeleted Type = "message.deleted"
 
+	// Mention Events (шлются в user#<id>)
+	MessageMentioned Type = "message.mentioned"
+
 	// Thread Events
 	ThreadCreated Type = "thread.created"
 	ThreadUpdated Type = "thread.updated"
+	ThreadClosed  Type = "thread.closed"
 	ThreadDeleted Type = "thread.deleted"
 
 	// Thread / Invite
 	ThreadInvited Type = "thread.invited"
 
+	// Thread / Read marker (шлётся в user#<id>, синхронизирует устройства)
+	ThreadRead Type = "thread.read"
+
+	// Typing (шлётся в thread#<id> без истории)
+	TypingStarted Type = "typing.started"
+
+	// Presence (шлётся в spool#<id>)
+	PresenceChanged Type = "presence.changed"
+
+	// Voice presence (шлётся в thread#<id>, по вебхукам LiveKit)
+	VoiceJoined Type = "voice.joined"
+	VoiceLeft   Type = "voice.left"
+	VoiceEnded  Type = "voice.ended"
+
+	// Запись голосовой комнаты (LiveKit Egress), шлётся в thread#<id>
+	VoiceRecordingStarted Type = "voice.recording_started"
+	VoiceRecordingStopped Type = "voice.recording_stopped"
+
 	// Spool Events
 	SpoolUpdated Type = "spool.updated"
 	SpoolDeleted Type = "spool.deleted"
@@ -24,26 +46,49 @@ const (
 	SpoolInvited Type = "spool.invited"
 )
 
-type Event struct {
-	Type    Type `json:"type"`
-	Payload any  `json:"payload"`
-}
-
-type ThreadSubTokenPayload struct {
-	Channel string `json:"channel"`
-	Token   string `json:"token"`
-}
-
 //
 // ---- Message Events ----
 //
 
 type MessageCreatedPayload struct {
+	MessageID   uint                       `json:"message_id"`
+	ThreadID    uint                       `json:"thread_id"`
+	Seq         uint64                     `json:"seq"` // клиент запоминает последний и после реконнекта догоняет через /thread/{id}/events
+	Content     string                     `json:"content"`
+	Username    string                     `json:"username"`
+	ReplyTo     *MessageReplyPayload       `json:"reply_to,omitempty"`
+	Attachments []MessageAttachmentPayload `json:"attachments,omitempty"`
+	CreatedAt   int64                      `json:"created_at"`
+}
+
+// MessageAttachmentPayload — метаданные вложения; файл отдаётся по /uploads/{bucket}/{file_link}
+type MessageAttachmentPayload struct {
+	ID          uint   `json:"id"`
+	Bucket      string `json:"bucket"`
+	FileLink    string `json:"file_link"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// MessageReplyPayload — краткая цитата сообщения, на которое ответили.
+// Для удалённого родителя приходит только message_id и deleted=true.
+type MessageReplyPayload struct {
 	MessageID uint   `json:"message_id"`
-	ThreadID  uint   `json:"thread_id"`
-	Content   string `json:"content"`
-	Username  string `json:"username"`
-	CreatedAt int64  `json:"created_at"`
+	Username  string `json:"username,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+}
+
+type MessageMentionedPayload struct {
+	MessageID   uint   `json:"message_id"`
+	ThreadID    uint   `json:"thread_id"`
+	SpoolID     uint   `json:"spool_id"`
+	ThreadTitle string `json:"thread_title"`
+	Username    string `json:"username"` // кто упомянул
+	Content     string `json:"content"`  // начало сообщения
+	Kind        string `json:"kind"`     // user | here | thread
+	CreatedAt   int64  `json:"created_at"`
 }
 
 type MessageUpdatedPayload struct {
@@ -80,6 +125,11 @@ type ThreadClosedPayload struct {
 	ThreadID uint `json:"thread_id"`
 }
 
+type ThreadDeletedPayload struct {
+	ThreadID  uint   `json:"thread_id"`
+	DeletedBy string `json:"deleted_by,omitempty"`
+}
+
 type ThreadInvitePayload struct {
 	ThreadID uint   `json:"thread_id"`
 	Title    string `json:"title"`
@@ -87,6 +137,62 @@ type ThreadInvitePayload struct {
 	Token    string `json:"token"`
 }
 
+type ThreadReadPayload struct {
+	ThreadID          uint  `json:"thread_id"`
+	SpoolID           uint  `json:"spool_id"`
+	LastReadMessageID uint  `json:"last_read_message_id"`
+	UnreadCount       int64 `json:"unread_count"`
+	MentionCount      int64 `json:"mention_count"`
+}
+
+type TypingPayload struct {
+	ThreadID  uint   `json:"thread_id"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	ExpiresIn int    `json:"expires_in"` // сек; если повторного события нет — перестал печатать
+}
+
+type PresenceChangedPayload struct {
+	SpoolID  uint   `json:"spool_id"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Status   string `json:"status"` // online / offline
+}
+
+type VoiceJoinedPayload struct {
+	ThreadID uint   `json:"thread_id"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	JoinedAt int64  `json:"joined_at"`
+}
+
+type VoiceLeftPayload struct {
+	ThreadID uint   `json:"thread_id"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// VoiceEndedPayload — комната закрылась, в голосе треда больше никого
+type VoiceEndedPayload struct {
+	ThreadID uint `json:"thread_id"`
+}
+
+// VoiceRecordingStartedPayload — участников предупреждаем, что их пишут
+type VoiceRecordingStartedPayload struct {
+	ThreadID    uint   `json:"thread_id"`
+	RecordingID uint   `json:"recording_id"`
+	StartedBy   string `json:"started_by"`
+	StartedAt   int64  `json:"started_at"`
+}
+
+// VoiceRecordingStoppedPayload — запись закончилась. MessageID есть, если файл уже приложен в тред
+type VoiceRecordingStoppedPayload struct {
+	ThreadID    uint   `json:"thread_id"`
+	RecordingID uint   `json:"recording_id"`
+	Status      string `json:"status"` // stopping / completed / failed
+	MessageID   uint   `json:"message_id,omitempty"`
+}
+
 //
 // ---- Spool Events ----
 //
@@ -0,0 +1,75 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion — версия конверта и payload'ов. Поднимаем при несовместимых изменениях:
+// удалили или переименовали поле, поменяли его смысл. Новые поля версию не меняют.
+const SchemaVersion = 1
+
+// Event — конверт real-time события, одинаковый для Centrifugo, SSE и журнала треда.
+// Тип берётся из payload, так что событие с чужим payload собрать нельзя.
+type Event struct {
+	ID      string  `json:"id"`   // уникален для каждого события; повторная доставка несёт тот же — клиент по нему отбрасывает дубли
+	Type    Type    `json:"type"` // по нему выбирается схема payload, см. Registry
+	Version int     `json:"version"`
+	Time    int64   `json:"ts"`              // когда произошло, unix-миллисекунды
+	Actor   *Actor  `json:"actor,omitempty"` // кто сделал; nil — система (sweeper, релей и т.п.)
+	Scope   Scope   `json:"scope"`
+	Payload Payload `json:"payload"`
+}
+
+// Actor — пользователь, из-за которого случилось событие
+type Actor struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username,omitempty"`
+}
+
+// Scope — к чему относится событие; клиент по нему раскладывает события, не заглядывая в payload
+type Scope struct {
+	SpoolID  uint `json:"spool_id,omitempty"`
+	ThreadID uint `json:"thread_id,omitempty"`
+}
+
+// New собирает событие с новым ID и текущим временем
+func New(payload Payload, scope Scope, actor *Actor) Event {
+	return Event{
+		ID:      uuid.NewString(),
+		Type:    payload.EventType(),
+		Version: SchemaVersion,
+		Time:    time.Now().UnixMilli(),
+		Actor:   actor,
+		Scope:   scope,
+		Payload: payload,
+	}
+}
+
+// UserActor — Actor для пользователя; username можно не знать
+func UserActor(userID uint, username string) *Actor {
+	return &Actor{UserID: userID, Username: username}
+}
+
+// Decode разбирает событие, payload — в структуру его типа из Registry.
+// Неизвестный тип — ошибка: значит, клиент или сервер отстали по схеме.
+func Decode(data []byte) (Event, error) {
+	var raw struct {
+		Event
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Event{}, fmt.Errorf("decode event: %w", err)
+	}
+
+	payload, err := decodePayload(raw.Type, raw.Payload)
+	if err != nil {
+		return Event{}, err
+	}
+	ev := raw.Event
+	ev.Payload = payload
+	return ev, nil
+}
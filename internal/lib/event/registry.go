@@ -0,0 +1,93 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Payload — тело события. EventType привязывает структуру к её типу на этапе компиляции:
+// New берёт Type из payload, а не из вызывающего кода.
+type Payload interface {
+	EventType() Type
+}
+
+func (MessageCreatedPayload) EventType() Type        { return MessageCreated }
+func (MessageUpdatedPayload) EventType() Type        { return MessageUpdated }
+func (MessageDeletedPayload) EventType() Type        { return MessageDeleted }
+func (MessageMentionedPayload) EventType() Type      { return MessageMentioned }
+func (ThreadCreatedPayload) EventType() Type         { return ThreadCreated }
+func (ThreadUpdatedPayload) EventType() Type         { return ThreadUpdated }
+func (ThreadClosedPayload) EventType() Type          { return ThreadClosed }
+func (ThreadDeletedPayload) EventType() Type         { return ThreadDeleted }
+func (ThreadInvitePayload) EventType() Type          { return ThreadInvited }
+func (ThreadReadPayload) EventType() Type            { return ThreadRead }
+func (TypingPayload) EventType() Type                { return TypingStarted }
+func (PresenceChangedPayload) EventType() Type       { return PresenceChanged }
+func (VoiceJoinedPayload) EventType() Type           { return VoiceJoined }
+func (VoiceLeftPayload) EventType() Type             { return VoiceLeft }
+func (VoiceEndedPayload) EventType() Type            { return VoiceEnded }
+func (VoiceRecordingStartedPayload) EventType() Type { return VoiceRecordingStarted }
+func (VoiceRecordingStoppedPayload) EventType() Type { return VoiceRecordingStopped }
+func (SpoolUpdatedPayload) EventType() Type          { return SpoolUpdated }
+func (SpoolDeletedPayload) EventType() Type          { return SpoolDeleted }
+func (SpoolInvitedPayload) EventType() Type          { return SpoolInvited }
+
+// payloads — по одному на каждый Type. Новый тип события: константа, структура, EventType и строчка здесь.
+var payloads = []Payload{
+	MessageCreatedPayload{},
+	MessageUpdatedPayload{},
+	MessageDeletedPayload{},
+	MessageMentionedPayload{},
+	ThreadCreatedPayload{},
+	ThreadUpdatedPayload{},
+	ThreadClosedPayload{},
+	ThreadDeletedPayload{},
+	ThreadInvitePayload{},
+	ThreadReadPayload{},
+	TypingPayload{},
+	PresenceChangedPayload{},
+	VoiceJoinedPayload{},
+	VoiceLeftPayload{},
+	VoiceEndedPayload{},
+	VoiceRecordingStartedPayload{},
+	VoiceRecordingStoppedPayload{},
+	SpoolUpdatedPayload{},
+	SpoolDeletedPayload{},
+	SpoolInvitedPayload{},
+}
+
+// registry — Type -> тип структуры payload
+var registry = func() map[Type]reflect.Type {
+	m := make(map[Type]reflect.Type, len(payloads))
+	for _, p := range payloads {
+		if _, dup := m[p.EventType()]; dup {
+			panic(fmt.Sprintf("event: payload for %q registered twice", p.EventType()))
+		}
+		m[p.EventType()] = reflect.TypeOf(p)
+	}
+	return m
+}()
+
+// Registry — все типы событий и их payload'ы, по алфавиту типов
+func Registry() []Payload {
+	res := make([]Payload, len(payloads))
+	copy(res, payloads)
+	sort.Slice(res, func(i, j int) bool { return res[i].EventType() < res[j].EventType() })
+	return res
+}
+
+func decodePayload(t Type, data json.RawMessage) (Payload, error) {
+	rt, ok := registry[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", t)
+	}
+	ptr := reflect.New(rt)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+			return nil, fmt.Errorf("decode %s payload: %w", t, err)
+		}
+	}
+	return ptr.Elem().Interface().(Payload), nil
+}
@@ -0,0 +1,140 @@
+package event
+
+//go:generate go run ../../../cmd/eventschema -o ../../../docs/events.schema.json
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// schemaDialect — JSON Schema, под который генерируем (его понимают ajv и zod-адаптеры)
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema — схема всех событий из Registry: oneOf по конвертам, различаются константой type.
+// Лишние поля схема допускает — новые поля версию не поднимают, старый фронт не должен на них падать.
+func JSONSchema() ([]byte, error) {
+	g := &schemaGen{defs: make(map[string]any)}
+	g.defs["Actor"] = g.object(reflect.TypeOf(Actor{}))
+	g.defs["Scope"] = g.object(reflect.TypeOf(Scope{}))
+
+	variants := make([]any, 0, len(payloads))
+	for _, p := range Registry() {
+		t := p.EventType()
+		name := "Event:" + string(t)
+		g.defs[name] = map[string]any{
+			"type":     "object",
+			"required": []string{"id", "type", "version", "ts", "scope", "payload"},
+			"properties": map[string]any{
+				"id":      map[string]any{"type": "string"},
+				"type":    map[string]any{"const": t},
+				"version": map[string]any{"const": SchemaVersion},
+				"ts":      map[string]any{"type": "integer", "description": "unix milliseconds"},
+				"actor":   ref("Actor"),
+				"scope":   ref("Scope"),
+				"payload": g.named(reflect.TypeOf(p)),
+			},
+		}
+		variants = append(variants, ref(name))
+	}
+
+	doc := map[string]any{
+		"$schema":        schemaDialect,
+		"$id":            "https://threadbook/schemas/events.schema.json",
+		"title":          "Threadbook real-time event",
+		"x-version":      SchemaVersion,
+		"oneOf":          variants,
+		"$defs":          g.defs,
+		"x-event-types":  eventTypes(),
+		"x-generated-by": "go generate ./internal/lib/event",
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func eventTypes() []Type {
+	res := make([]Type, 0, len(payloads))
+	for _, p := range Registry() {
+		res = append(res, p.EventType())
+	}
+	return res
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+type schemaGen struct {
+	defs map[string]any
+}
+
+// named — структура уезжает в $defs под своим именем, на месте остаётся ссылка
+func (g *schemaGen) named(t reflect.Type) map[string]any {
+	name := t.Name()
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = nil // от рекурсии
+		g.defs[name] = g.object(t)
+	}
+	return ref(name)
+}
+
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	required := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		omitempty := strings.Contains(opts, "omitempty")
+		props[name] = g.field(f.Type, omitempty)
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+	return map[string]any{
+		"type":       "object",
+		"required":   required,
+		"properties": props,
+	}
+}
+
+func (g *schemaGen) field(t reflect.Type, omitempty bool) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.field(t.Elem(), omitempty)
+	case reflect.Struct:
+		return g.named(t)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{} // json.RawMessage — что угодно
+		}
+		s := map[string]any{"type": "array", "items": g.field(t.Elem(), false)}
+		if !omitempty {
+			// пустой слайс без omitempty уходит как null
+			s["type"] = []string{"array", "null"}
+		}
+		return s
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Interface, reflect.Map:
+		return map[string]any{}
+	default:
+		panic(fmt.Sprintf("event: no JSON Schema mapping for %s", t))
+	}
+}
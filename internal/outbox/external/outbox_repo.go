@@ -0,0 +1,33 @@
+package external
+
+import (
+	"context"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+type OutboxRepoInterface interface {
+	// Enqueue пишет события; внутри dbtx-транзакции — в ту же транзакцию, что и доменное изменение
+	Enqueue(ctx context.Context, events []gdomain.OutboxEvent) error
+
+	// Методы релея, работают только внутри транзакции
+	// TryLockRelay — true, если этот инстанс релеит сейчас (остальные пропускают тик)
+	TryLockRelay(ctx context.Context) (bool, error)
+	// FetchDue отдаёт готовые к отправке события по возрастанию id.
+	// Событие не отдаётся, пока более раннее событие того же канала ждёт повтора, — так держим порядок в канале.
+	FetchDue(ctx context.Context, now time.Time, limit int) ([]gdomain.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uint, at time.Time) error
+	MarkFailed(ctx context.Context, id uint, attempts int, nextAttemptAt time.Time, lastErr string) error
+	MarkDead(ctx context.Context, id uint, attempts int, at time.Time, lastErr string) error
+
+	// DeletePublishedBefore чистит отправленные события; мёртвые оставляем для разбора
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+
+	// Догонялка SSE по Last-Event-ID
+	// FindLastID — id последней строки события с этим ключом среди каналов; 0 — такого уже (или ещё) нет
+	FindLastID(ctx context.Context, channels []string, idempotencyKey string) (uint, error)
+	// GetAfterID — не мёртвые события каналов с id > afterID по возрастанию; неотправленные тоже,
+	// иначе событие, которое релей уже разослал, но ещё не отметил, проскочило бы мимо
+	GetAfterID(ctx context.Context, channels []string, afterID uint, limit int) ([]gdomain.OutboxEvent, error)
+}
@@ -0,0 +1,107 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+)
+
+// outboxWebsocketRepo — WebsocketRepoInterface поверх outbox: вместо прямой публикации
+// событие пишется в outbox_events (в текущую транзакцию, если она есть), а отправит его релей.
+// Эфемерное (typing) и токены идут напрямую — их терять не страшно и копить незачем.
+type outboxWebsocketRepo struct {
+	outboxRepo OutboxRepoInterface
+	direct     threadExternal.WebsocketRepoInterface
+	notify     func() // будит релей после коммита, может быть nil
+}
+
+func NewOutboxWebsocketRepo(
+	outboxRepo OutboxRepoInterface,
+	direct threadExternal.WebsocketRepoInterface,
+	notify func(),
+) threadExternal.WebsocketRepoInterface {
+	return &outboxWebsocketRepo{
+		outboxRepo: outboxRepo,
+		direct:     direct,
+		notify:     notify,
+	}
+}
+
+func (r *outboxWebsocketRepo) PublishToUser(ctx context.Context, userID uint, data any) error {
+	return r.enqueue(ctx, []string{fmt.Sprintf("user#%d", userID)}, data)
+}
+
+func (r *outboxWebsocketRepo) PublishToUsers(ctx context.Context, userIDs []uint, data any) error {
+	channels := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		channels = append(channels, fmt.Sprintf("user#%d", id))
+	}
+	return r.enqueue(ctx, channels, data)
+}
+
+func (r *outboxWebsocketRepo) PublishToThread(ctx context.Context, threadID uint, data any) error {
+	return r.enqueue(ctx, []string{fmt.Sprintf("thread#%d", threadID)}, data)
+}
+
+func (r *outboxWebsocketRepo) PublishToSpool(ctx context.Context, spoolID uint, data any) error {
+	return r.enqueue(ctx, []string{fmt.Sprintf("spool#%d", spoolID)}, data)
+}
+
+func (r *outboxWebsocketRepo) PublishToThreadEphemeral(ctx context.Context, threadID uint, data any) error {
+	return r.direct.PublishToThreadEphemeral(ctx, threadID, data)
+}
+
+func (r *outboxWebsocketRepo) GenerateConnectToken(ctx context.Context, userID uint, ttl time.Duration) (string, error) {
+	return r.direct.GenerateConnectToken(ctx, userID, ttl)
+}
+
+func (r *outboxWebsocketRepo) GenerateSubscribeToken(ctx context.Context, userID uint, channel string, ttl time.Duration) (string, error) {
+	return r.direct.GenerateSubscribeToken(ctx, userID, channel, ttl)
+}
+
+// enqueue берёт ID события (или выдаёт новый) как ключ идемпотентности: ретраи релея несут тот же ID.
+// Строка на каждый канал, чтобы порядок и повторы считались по каналу.
+func (r *outboxWebsocketRepo) enqueue(ctx context.Context, channels []string, data any) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	key := uuid.NewString()
+	if ev, ok := data.(event.Event); ok {
+		if ev.ID == "" {
+			ev.ID = key
+			data = ev
+		}
+		key = ev.ID
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal publish data: %w", err)
+	}
+
+	now := time.Now()
+	events := make([]gdomain.OutboxEvent, 0, len(channels))
+	for _, channel := range channels {
+		events = append(events, gdomain.OutboxEvent{
+			Channel:        channel,
+			Payload:        payload,
+			IdempotencyKey: key,
+			NextAttemptAt:  now,
+		})
+	}
+	if err := r.outboxRepo.Enqueue(ctx, events); err != nil {
+		return fmt.Errorf("enqueue outbox event: %w", err)
+	}
+
+	if r.notify != nil {
+		dbtx.AfterCommit(ctx, r.notify)
+	}
+	return nil
+}
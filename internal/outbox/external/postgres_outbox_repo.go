@@ -0,0 +1,136 @@
+package external
+
+import (
+	"context"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// relayLockKey — ключ advisory lock'а, под которым работает релей ("outbox" в hex)
+const relayLockKey int64 = 0x6f7574626f78
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) OutboxRepoInterface {
+	return &outboxRepo{db: db}
+}
+
+func (r *outboxRepo) conn(ctx context.Context) *gorm.DB {
+	return dbtx.DB(ctx, r.db)
+}
+
+func (r *outboxRepo) Enqueue(ctx context.Context, events []gdomain.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.conn(ctx).Omit(clause.Associations).Create(&events).Error
+}
+
+// TryLockRelay берёт транзакционный advisory lock: отпустится сам на коммите/откате.
+// Релей один на весь кластер, иначе два инстанса могли бы разослать события канала вперемешку.
+func (r *outboxRepo) TryLockRelay(ctx context.Context) (bool, error) {
+	var locked bool
+	if err := r.conn(ctx).Raw("SELECT pg_try_advisory_xact_lock(?)", relayLockKey).Scan(&locked).Error; err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func (r *outboxRepo) FetchDue(ctx context.Context, now time.Time, limit int) ([]gdomain.OutboxEvent, error) {
+	var events []gdomain.OutboxEvent
+	err := r.conn(ctx).
+		Table("outbox_events AS o").
+		Where("o.published_at IS NULL AND o.dead_at IS NULL AND o.next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox_events p
+			WHERE p.channel = o.channel AND p.id < o.id
+				AND p.published_at IS NULL AND p.dead_at IS NULL AND p.next_attempt_at > ?)`, now).
+		Order("o.id ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepo) MarkPublished(ctx context.Context, ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.conn(ctx).
+		Model(&gdomain.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"published_at": at,
+			"last_error":   "",
+		}).Error
+}
+
+func (r *outboxRepo) MarkFailed(ctx context.Context, id uint, attempts int, nextAttemptAt time.Time, lastErr string) error {
+	return r.conn(ctx).
+		Model(&gdomain.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastErr,
+		}).Error
+}
+
+func (r *outboxRepo) MarkDead(ctx context.Context, id uint, attempts int, at time.Time, lastErr string) error {
+	return r.conn(ctx).
+		Model(&gdomain.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":   attempts,
+			"dead_at":    at,
+			"last_error": lastErr,
+		}).Error
+}
+
+func (r *outboxRepo) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := r.conn(ctx).
+		Where("published_at IS NOT NULL AND published_at < ?", before).
+		Delete(&gdomain.OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *outboxRepo) FindLastID(ctx context.Context, channels []string, idempotencyKey string) (uint, error) {
+	if len(channels) == 0 || idempotencyKey == "" {
+		return 0, nil
+	}
+	var id *uint
+	err := r.conn(ctx).
+		Model(&gdomain.OutboxEvent{}).
+		Select("MAX(id)").
+		Where("idempotency_key = ? AND channel IN ?", idempotencyKey, channels).
+		Scan(&id).Error
+	if err != nil || id == nil {
+		return 0, err
+	}
+	return *id, nil
+}
+
+func (r *outboxRepo) GetAfterID(ctx context.Context, channels []string, afterID uint, limit int) ([]gdomain.OutboxEvent, error) {
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	var events []gdomain.OutboxEvent
+	err := r.conn(ctx).
+		Where("channel IN ? AND id > ? AND dead_at IS NULL", channels, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
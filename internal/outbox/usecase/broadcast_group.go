@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+)
+
+// broadcastGroup — идущие подряд строки одного события (общий IdempotencyKey) в разные каналы
+type broadcastGroup []gdomain.OutboxEvent
+
+// groupBroadcasts склеивает соседние строки с одним ключом. Соседние — чтобы не переставить
+// события внутри канала: всё между ними уходит в том же порядке, что и лежало.
+func groupBroadcasts(events []gdomain.OutboxEvent) []broadcastGroup {
+	var groups []broadcastGroup
+	for _, ev := range events {
+		if n := len(groups); n > 0 && groups[n-1][0].IdempotencyKey == ev.IdempotencyKey {
+			groups[n-1] = append(groups[n-1], ev)
+			continue
+		}
+		groups = append(groups, broadcastGroup{ev})
+	}
+	return groups
+}
+
+func (g broadcastGroup) command() threadExternal.PublishCommand {
+	channels := make([]string, 0, len(g))
+	for _, ev := range g {
+		channels = append(channels, ev.Channel)
+	}
+	return threadExternal.PublishCommand{
+		Channels:       channels,
+		Data:           g[0].Payload,
+		IdempotencyKey: g[0].IdempotencyKey,
+	}
+}
+
+// blockedBy — хоть один канал группы уже застрял в этой пачке
+func (g broadcastGroup) blockedBy(channels map[string]bool) bool {
+	for _, ev := range g {
+		if channels[ev.Channel] {
+			return true
+		}
+	}
+	return false
+}
+
+func (g broadcastGroup) markChannels(channels map[string]bool) {
+	for _, ev := range g {
+		channels[ev.Channel] = true
+	}
+}
+
+func (g broadcastGroup) ids() []uint {
+	ids := make([]uint, 0, len(g))
+	for _, ev := range g {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	"github.com/onionfriend2004/threadbook_backend/internal/outbox/external"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+// publishTimeout — сколько ждём Centrifugo на всю пачку. Всё это время релей держит транзакцию:
+// advisory-лок и FOR UPDATE на строках пачки. Осознанно: пометка отправленными в той же транзакции,
+// что и выборка, поэтому упавший посреди отправки релей ничего не теряет, а второй инстанс
+// не шлёт ту же пачку. Цена — до publishTimeout висящие соединение и блокировки строк пачки
+// (новые события они не держат: SKIP LOCKED и вставки их не ждут)
+const publishTimeout = 5 * time.Second
+
+type RelayUsecaseInterface interface {
+	// RelayBatch отправляет одну пачку и возвращает, сколько событий успело уйти.
+	// Полная пачка — повод сразу звать ещё раз.
+	RelayBatch(ctx context.Context) (int, error)
+	// Cleanup удаляет отправленные события старше Retention
+	Cleanup(ctx context.Context) (int64, error)
+	// Notify будит релей, не дожидаясь тика (зовётся после коммита с новыми событиями)
+	Notify()
+	Wakeup() <-chan struct{}
+	BatchSize() int
+}
+
+type RelayConfig struct {
+	BatchSize   int
+	MaxAttempts int           // после стольких неудач событие помечается мёртвым
+	BaseBackoff time.Duration // пауза после первой неудачи, дальше удваивается
+	MaxBackoff  time.Duration
+	Retention   time.Duration // сколько храним отправленные
+}
+
+// Доставка at-least-once: событие помечается отправленным только после ответа Centrifugo,
+// поэтому при падении между отправкой и коммитом оно уйдёт ещё раз с тем же ключом идемпотентности.
+// Пачка уходит одним pipe-запросом, строки одного broadcast'а — одной командой.
+type relayUsecase struct {
+	outboxRepo external.OutboxRepoInterface
+	publisher  threadExternal.PublisherInterface
+	tx         dbtx.TransactorInterface
+	cfg        RelayConfig
+	wakeup     chan struct{}
+	logger     *zap.Logger
+}
+
+func NewRelayUsecase(
+	outboxRepo external.OutboxRepoInterface,
+	publisher threadExternal.PublisherInterface,
+	tx dbtx.TransactorInterface,
+	cfg RelayConfig,
+	logger *zap.Logger,
+) RelayUsecaseInterface {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = cfg.BaseBackoff
+	}
+	return &relayUsecase{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		tx:         tx,
+		cfg:        cfg,
+		wakeup:     make(chan struct{}, 1),
+		logger:     logger,
+	}
+}
+
+func (u *relayUsecase) RelayBatch(ctx context.Context) (int, error) {
+	sent := 0
+	err := u.tx.WithinTx(ctx, func(txCtx context.Context) error {
+		locked, err := u.outboxRepo.TryLockRelay(txCtx)
+		if err != nil {
+			return fmt.Errorf("failed to lock relay: %w", err)
+		}
+		if !locked {
+			return nil // релеит другой инстанс
+		}
+
+		events, err := u.outboxRepo.FetchDue(txCtx, time.Now(), u.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch outbox events: %w", err)
+		}
+
+		groups := groupBroadcasts(events)
+		cmds := make([]threadExternal.PublishCommand, 0, len(groups))
+		for _, group := range groups {
+			cmds = append(cmds, group.command())
+		}
+
+		pubCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		errs := u.publisher.Publish(pubCtx, cmds)
+		cancel()
+
+		published := make([]uint, 0, len(events))
+		// каналы, где в этой пачке что-то не ушло: всё, что в них дальше, отправленным не считаем,
+		// иначе клиент получит события не по порядку. Такие строки остаются как были и поедут
+		// вслед за упавшим — FetchDue придержит канал, пока оно не уйдёт
+		failedChannels := make(map[string]bool)
+		for i, group := range groups {
+			if errs[i] != nil {
+				group.markChannels(failedChannels)
+				if err := u.fail(txCtx, group, errs[i]); err != nil {
+					return err
+				}
+				continue
+			}
+			if group.blockedBy(failedChannels) {
+				// пусть и дошло — повтор уйдёт с тем же ключом идемпотентности
+				group.markChannels(failedChannels)
+				continue
+			}
+			published = append(published, group.ids()...)
+		}
+
+		if err := u.outboxRepo.MarkPublished(txCtx, published, time.Now()); err != nil {
+			return fmt.Errorf("failed to mark outbox events published: %w", err)
+		}
+		sent = len(published)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sent, nil
+}
+
+// fail откладывает событие на backoff или хоронит его, если попытки кончились
+func (u *relayUsecase) fail(ctx context.Context, group broadcastGroup, pubErr error) error {
+	head := group[0]
+	attempts := head.Attempts + 1
+	now := time.Now()
+
+	if attempts >= u.cfg.MaxAttempts {
+		u.logger.Error("outbox event is dead, giving up",
+			zap.Uint("eventID", head.ID),
+			zap.String("channel", head.Channel),
+			zap.Int("channels", len(group)),
+			zap.Int("attempts", attempts),
+			zap.Error(pubErr))
+		for _, ev := range group {
+			if err := u.outboxRepo.MarkDead(ctx, ev.ID, attempts, now, pubErr.Error()); err != nil {
+				return fmt.Errorf("failed to mark outbox event dead: %w", err)
+			}
+		}
+		return nil
+	}
+
+	delay := u.backoff(attempts)
+	u.logger.Warn("failed to publish outbox event, will retry",
+		zap.Uint("eventID", head.ID),
+		zap.String("channel", head.Channel),
+		zap.Int("channels", len(group)),
+		zap.Int("attempts", attempts),
+		zap.Duration("retryIn", delay),
+		zap.Error(pubErr))
+	for _, ev := range group {
+		if err := u.outboxRepo.MarkFailed(ctx, ev.ID, attempts, now.Add(delay), pubErr.Error()); err != nil {
+			return fmt.Errorf("failed to mark outbox event failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// backoff — экспонента от BaseBackoff с потолком MaxBackoff и джиттером в верхней половине,
+// чтобы после падения Centrifugo повторы не пришли одной волной
+func (u *relayUsecase) backoff(attempts int) time.Duration {
+	delay := u.cfg.MaxBackoff
+	if shift := attempts - 1; shift < 32 {
+		if d := u.cfg.BaseBackoff << shift; d > 0 && d < delay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+func (u *relayUsecase) Cleanup(ctx context.Context) (int64, error) {
+	if u.cfg.Retention <= 0 {
+		return 0, nil
+	}
+	deleted, err := u.outboxRepo.DeletePublishedBefore(ctx, time.Now().Add(-u.cfg.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup outbox: %w", err)
+	}
+	return deleted, nil
+}
+
+func (u *relayUsecase) Notify() {
+	select {
+	case u.wakeup <- struct{}{}:
+	default: // релей и так уже разбужен
+	}
+}
+
+func (u *relayUsecase) Wakeup() <-chan struct{} {
+	return u.wakeup
+}
+
+func (u *relayUsecase) BatchSize() int {
+	return u.cfg.BatchSize
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type VoiceParticipantResponse struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type ThreadVoiceResponse struct {
+	ThreadID     uint                       `json:"thread_id"`
+	Participants []VoiceParticipantResponse `json:"participants"`
+}
+
+// SpoolVoiceResponse — только треды, где сейчас кто-то есть
+type SpoolVoiceResponse struct {
+	SpoolID uint                  `json:"spool_id"`
+	Threads []ThreadVoiceResponse `json:"threads"`
+}
@@ -0,0 +1,60 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	"go.uber.org/zap"
+)
+
+// GetSpoolVoice — голосовые комнаты всех доступных тредов спула, где кто-то есть
+func (h *VoiceHandler) GetSpoolVoice(w http.ResponseWriter, r *http.Request) {
+	spoolID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid spool id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rooms, err := h.usecase.GetSpoolVoice(r.Context(), usecase.GetSpoolVoiceInput{
+		UserID:  userID,
+		SpoolID: uint(spoolID),
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to get spool voice", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.SpoolVoiceResponse{
+		SpoolID: uint(spoolID),
+		Threads: make([]dto.ThreadVoiceResponse, 0, len(rooms)),
+	}
+	for threadID, participants := range rooms {
+		resp.Threads = append(resp.Threads, dto.ThreadVoiceResponse{
+			ThreadID:     threadID,
+			Participants: toVoiceParticipants(participants),
+		})
+	}
+	sort.Slice(resp.Threads, func(i, j int) bool { return resp.Threads[i].ThreadID < resp.Threads[j].ThreadID })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode response", zap.Error(err))
+	}
+}
@@ -0,0 +1,52 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	"go.uber.org/zap"
+)
+
+// GetThreadVoice — кто сейчас в голосовой комнате треда
+func (h *VoiceHandler) GetThreadVoice(w http.ResponseWriter, r *http.Request) {
+	threadID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	participants, err := h.usecase.GetThreadVoice(r.Context(), usecase.GetThreadVoiceInput{
+		UserID:   userID,
+		ThreadID: uint(threadID),
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to get thread voice", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.ThreadVoiceResponse{
+		ThreadID:     uint(threadID),
+		Participants: toVoiceParticipants(participants),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode response", zap.Error(err))
+	}
+}
@@ -0,0 +1,45 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	"go.uber.org/zap"
+)
+
+// Heartbeat — клиент шлёт, пока открыт (раз в presence.ttl/2)
+func (h *PresenceHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	username, err := auth.GetUsernameFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	// AuthMiddleware уже проверил куку, тут она нужна только как id сессии
+	cookie, err := r.Cookie("sid")
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.usecase.Heartbeat(r.Context(), usecase.HeartbeatInput{
+		UserID:    userID,
+		Username:  username,
+		SessionID: cookie.Value,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to store heartbeat", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.WriteHeader(lib.StatusNoContent)
+}
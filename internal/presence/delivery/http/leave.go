@@ -0,0 +1,44 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	"go.uber.org/zap"
+)
+
+// Leave — явный уход в offline для текущей сессии (закрытие вкладки, выход)
+func (h *PresenceHandler) Leave(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	username, err := auth.GetUsernameFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	cookie, err := r.Cookie("sid")
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.usecase.Leave(r.Context(), usecase.LeaveInput{
+		UserID:    userID,
+		Username:  username,
+		SessionID: cookie.Value,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to leave presence", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.WriteHeader(lib.StatusNoContent)
+}
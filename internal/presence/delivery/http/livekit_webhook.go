@@ -0,0 +1,146 @@
+package deliveryHTTP
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	threadUsecase "github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxWebhookBody — вебхуки LiveKit маленькие, больше — явно не они
+const maxWebhookBody = 1 << 20
+
+// voiceUserIDAttribute — атрибут участника, в который GetVoiceToken кладёт id пользователя
+const voiceUserIDAttribute = "user_id"
+
+var errWebhookSignature = errors.New("invalid webhook signature")
+
+// LiveKitWebhook — participant_joined / participant_left / room_finished и egress_* от LiveKit.
+// Authorization — JWT, подписанный APISecret, с sha256 тела в claims.
+func (h *VoiceHandler) LiveKitWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		lib.WriteError(w, "invalid body", lib.StatusBadRequest)
+		return
+	}
+
+	if err := h.verifyWebhook(r.Header.Get("Authorization"), body); err != nil {
+		h.logger.Warn("rejected livekit webhook", zap.Error(err))
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var ev livekit.WebhookEvent
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true, AllowPartial: true}).Unmarshal(body, &ev); err != nil {
+		lib.WriteError(w, "invalid webhook", lib.StatusBadRequest)
+		return
+	}
+
+	if strings.HasPrefix(ev.GetEvent(), "egress_") {
+		h.handleEgress(w, r, &ev)
+		return
+	}
+
+	input := usecase.VoiceWebhookInput{
+		Event:    ev.GetEvent(),
+		RoomName: ev.GetRoom().GetName(),
+	}
+	if p := ev.GetParticipant(); p != nil {
+		input.Participant = toVoiceParticipant(p)
+	}
+
+	if err := h.usecase.HandleWebhook(r.Context(), input); err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to handle livekit webhook", zap.String("event", input.Event), zap.String("room", input.RoomName), zap.Error(err))
+		// 5xx — LiveKit повторит вебхук
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.WriteHeader(lib.StatusOK)
+}
+
+// handleEgress — нас интересует только egress_ended: запись готова (или не получилась)
+func (h *VoiceHandler) handleEgress(w http.ResponseWriter, r *http.Request, ev *livekit.WebhookEvent) {
+	info := ev.GetEgressInfo()
+	if ev.GetEvent() != "egress_ended" || info == nil {
+		w.WriteHeader(lib.StatusOK)
+		return
+	}
+
+	input := threadUsecase.EgressEndedInput{
+		EgressID:  info.GetEgressId(),
+		Completed: info.GetStatus() == livekit.EgressStatus_EGRESS_COMPLETE,
+		Error:     info.GetError(),
+	}
+	if files := info.GetFileResults(); len(files) > 0 {
+		input.ObjectKey = files[0].GetFilename()
+		input.Size = files[0].GetSize()
+		input.DurationMs = time.Duration(files[0].GetDuration()).Milliseconds() // LiveKit отдаёт наносекунды
+	}
+
+	if err := h.recording.HandleEgressEnded(r.Context(), input); err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to handle egress webhook", zap.String("egress_id", input.EgressID), zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.WriteHeader(lib.StatusOK)
+}
+
+func (h *VoiceHandler) verifyWebhook(token string, body []byte) error {
+	if token == "" {
+		return errWebhookSignature
+	}
+	v, err := auth.ParseAPIToken(token)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errWebhookSignature, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(v.APIKey()), []byte(h.apiKey)) != 1 {
+		return fmt.Errorf("%w: unknown api key", errWebhookSignature)
+	}
+	claims, err := v.Verify(h.apiSecret)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errWebhookSignature, err)
+	}
+
+	sum := sha256.Sum256(body)
+	if subtle.ConstantTimeCompare([]byte(claims.Sha256), []byte(base64.StdEncoding.EncodeToString(sum[:]))) != 1 {
+		return fmt.Errorf("%w: body checksum mismatch", errWebhookSignature)
+	}
+	return nil
+}
+
+func toVoiceParticipant(p *livekit.ParticipantInfo) *gdomain.VoiceParticipant {
+	// Токены выданы до появления атрибута — user_id неизвестен, остаётся 0
+	userID, _ := strconv.ParseUint(p.GetAttributes()[voiceUserIDAttribute], 10, 64)
+
+	joinedAt := time.UnixMilli(p.GetJoinedAtMs())
+	if p.GetJoinedAtMs() == 0 {
+		joinedAt = time.Unix(p.GetJoinedAt(), 0)
+	}
+
+	return &gdomain.VoiceParticipant{
+		UserID:   uint(userID),
+		Username: p.GetIdentity(),
+		SID:      p.GetSid(),
+		JoinedAt: joinedAt,
+	}
+}
@@ -0,0 +1,28 @@
+package deliveryHTTP
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	"go.uber.org/zap"
+)
+
+type PresenceHandler struct {
+	usecase usecase.PresenceUsecaseInterface
+	logger  *zap.Logger
+}
+
+func NewPresenceHandler(u usecase.PresenceUsecaseInterface, logger *zap.Logger) *PresenceHandler {
+	return &PresenceHandler{
+		usecase: u,
+		logger:  logger,
+	}
+}
+
+func (h *PresenceHandler) Routes(r chi.Router, authenticator auth.AuthenticatorInterface) {
+	r.Route("/presence", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(authenticator))
+		r.Post("/heartbeat", h.Heartbeat)
+		r.Delete("/", h.Leave)
+	})
+}
@@ -0,0 +1,53 @@
+package deliveryHTTP
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/usecase"
+	threadUsecase "github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// VoiceHandler — вебхуки LiveKit и "кто в голосе"
+type VoiceHandler struct {
+	usecase   usecase.VoiceUsecaseInterface
+	recording threadUsecase.RecordingUsecaseInterface // egress_* из того же вебхука
+	apiKey    string                                  // вебхуки LiveKit подписаны той же парой ключей, что и токены
+	apiSecret string
+	logger    *zap.Logger
+}
+
+func NewVoiceHandler(u usecase.VoiceUsecaseInterface, recording threadUsecase.RecordingUsecaseInterface, apiKey, apiSecret string, logger *zap.Logger) *VoiceHandler {
+	return &VoiceHandler{
+		usecase:   u,
+		recording: recording,
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		logger:    logger,
+	}
+}
+
+func (h *VoiceHandler) Routes(r chi.Router, authenticator auth.AuthenticatorInterface) {
+	// без сессии: подлинность проверяется по подписи
+	r.Post("/livekit/webhook", h.LiveKitWebhook)
+
+	r.Route("/voice", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(authenticator))
+		r.Get("/thread/{id}", h.GetThreadVoice)
+		r.Get("/spool/{id}", h.GetSpoolVoice)
+	})
+}
+
+func toVoiceParticipants(participants []gdomain.VoiceParticipant) []dto.VoiceParticipantResponse {
+	res := make([]dto.VoiceParticipantResponse, 0, len(participants))
+	for _, p := range participants {
+		res = append(res, dto.VoiceParticipantResponse{
+			UserID:   p.UserID,
+			Username: p.Username,
+			JoinedAt: p.JoinedAt,
+		})
+	}
+	return res
+}
@@ -0,0 +1,7 @@
+package external
+
+import "errors"
+
+var (
+	ErrRedisScript = errors.New("presence script failed")
+)
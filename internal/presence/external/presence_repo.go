@@ -0,0 +1,16 @@
+package external
+
+import "context"
+
+type PresenceRepoInterface interface {
+	// Heartbeat продлевает сессию; true — пользователь только что стал онлайн
+	Heartbeat(ctx context.Context, userID uint, username, sessionID string) (bool, error)
+	// Leave убирает сессию; true — это была последняя живая сессия
+	Leave(ctx context.Context, userID uint, sessionID string) (bool, error)
+	// PopExpired снимает с онлайна пользователей без живых сессий и возвращает их id -> username
+	PopExpired(ctx context.Context) (map[uint]string, error)
+	GetOnline(ctx context.Context, userIDs []uint) (map[uint]bool, error)
+
+	// TryTyping — true, если событие "печатает" можно отправить (не чаще раза в typingTTL)
+	TryTyping(ctx context.Context, threadID, userID uint) (bool, error)
+}
@@ -0,0 +1,178 @@
+package external
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	presenceUserKeyPrefix = "presence:user:"     // ZSET сессий пользователя, score = когда протухнет (unix ms)
+	presenceOnlineKey     = "presence:online"    // ZSET онлайн-пользователей, score = самый поздний срок сессии
+	presenceNamesKey      = "presence:usernames" // HASH id -> username, чтобы отправить offline без похода в БД
+	typingKeyPrefix       = "typing:"            // typing:<thread>:<user> — троттлинг "печатает"
+)
+
+// KEYS: user sessions, online, names; ARGV: now, expiresAt, session, userID, username, ttl(sec)
+var heartbeatScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local before = redis.call('ZCARD', KEYS[1])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+redis.call('ZADD', KEYS[2], 'GT', ARGV[2], ARGV[4])
+redis.call('HSET', KEYS[3], ARGV[4], ARGV[5])
+return before
+`)
+
+// KEYS: user sessions, online, names; ARGV: now, session, userID
+var leaveScript = redis.NewScript(`
+local removed = redis.call('ZREM', KEYS[1], ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local left = redis.call('ZCARD', KEYS[1])
+if left == 0 then
+	redis.call('ZREM', KEYS[2], ARGV[3])
+	redis.call('HDEL', KEYS[3], ARGV[3])
+end
+if removed == 1 and left == 0 then
+	return 1
+end
+return 0
+`)
+
+// KEYS: user sessions, online, names; ARGV: now, userID. Возвращает username, если пользователь ушёл в offline
+var expireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) > 0 then
+	return false
+end
+-- уже снят другим инстансом — второй offline не нужен
+if redis.call('ZREM', KEYS[2], ARGV[2]) == 0 then
+	return false
+end
+local name = redis.call('HGET', KEYS[3], ARGV[2])
+redis.call('HDEL', KEYS[3], ARGV[2])
+return name or ''
+`)
+
+type redisPresenceRepo struct {
+	client    redis.UniversalClient
+	ttl       time.Duration
+	typingTTL time.Duration
+}
+
+func NewRedisPresenceRepo(client redis.UniversalClient, ttl, typingTTL time.Duration) PresenceRepoInterface {
+	return &redisPresenceRepo{
+		client:    client,
+		ttl:       ttl,
+		typingTTL: typingTTL,
+	}
+}
+
+func (r *redisPresenceRepo) userKey(userID uint) string {
+	return presenceUserKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// sessionMember — в Redis кладём не сам sid, а его хеш: sid это секрет из куки
+func sessionMember(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (r *redisPresenceRepo) Heartbeat(ctx context.Context, userID uint, username, sessionID string) (bool, error) {
+	now := time.Now()
+	before, err := heartbeatScript.Run(ctx, r.client,
+		[]string{r.userKey(userID), presenceOnlineKey, presenceNamesKey},
+		now.UnixMilli(),
+		now.Add(r.ttl).UnixMilli(),
+		sessionMember(sessionID),
+		userID,
+		username,
+		int(r.ttl.Seconds())+1,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("%w: heartbeat: %v", ErrRedisScript, err)
+	}
+	return before == 0, nil
+}
+
+func (r *redisPresenceRepo) Leave(ctx context.Context, userID uint, sessionID string) (bool, error) {
+	wentOffline, err := leaveScript.Run(ctx, r.client,
+		[]string{r.userKey(userID), presenceOnlineKey, presenceNamesKey},
+		time.Now().UnixMilli(),
+		sessionMember(sessionID),
+		userID,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("%w: leave: %v", ErrRedisScript, err)
+	}
+	return wentOffline == 1, nil
+}
+
+func (r *redisPresenceRepo) PopExpired(ctx context.Context) (map[uint]string, error) {
+	now := time.Now().UnixMilli()
+	ids, err := r.client.ZRangeByScore(ctx, presenceOnlineKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now, 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired presence: %w", err)
+	}
+
+	expired := make(map[uint]string, len(ids))
+	for _, idStr := range ids {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			r.client.ZRem(ctx, presenceOnlineKey, idStr)
+			continue
+		}
+		// Скрипт перепроверяет сессии: пользователь мог успеть прислать heartbeat
+		name, err := expireScript.Run(ctx, r.client,
+			[]string{r.userKey(uint(id)), presenceOnlineKey, presenceNamesKey},
+			now,
+			idStr,
+		).Text()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return expired, fmt.Errorf("%w: expire: %v", ErrRedisScript, err)
+		}
+		expired[uint(id)] = name
+	}
+	return expired, nil
+}
+
+func (r *redisPresenceRepo) GetOnline(ctx context.Context, userIDs []uint) (map[uint]bool, error) {
+	online := make(map[uint]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return online, nil
+	}
+
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(userIDs))
+	for i, id := range userIDs {
+		cmds[i] = pipe.ZCount(ctx, r.userKey(id), "("+now, "+inf")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get presence: %w", err)
+	}
+	for i, id := range userIDs {
+		online[id] = cmds[i].Val() > 0
+	}
+	return online, nil
+}
+
+func (r *redisPresenceRepo) TryTyping(ctx context.Context, threadID, userID uint) (bool, error) {
+	key := fmt.Sprintf("%s%d:%d", typingKeyPrefix, threadID, userID)
+	ok, err := r.client.SetNX(ctx, key, 1, r.typingTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to throttle typing: %w", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,135 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	voiceRoomKeyPrefix = "voice:thread:" // HASH username -> VoiceParticipant (json)
+	voiceLeftKeyPrefix = "voice:left:"   // voice:left:<sid> — участник уже вышел, запоздавший joined игнорируем
+
+	// voiceRoomTTL — страховка на случай потерянного room_finished, продлевается на каждый вход
+	voiceRoomTTL = 12 * time.Hour
+	// voiceLeftTTL — сколько помним вышедших; LiveKit ретраит вебхуки заметно меньше
+	voiceLeftTTL = 10 * time.Minute
+)
+
+// KEYS: room, left(sid); ARGV: username, participant json, sid, room ttl(sec). 1 — новый участник
+var voiceJoinScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+local prev = redis.call('HGET', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+if prev then
+	return 0
+end
+return 1
+`)
+
+// KEYS: room, left(sid); ARGV: username, sid, left ttl(sec). 1 — участника убрали
+var voiceLeaveScript = redis.NewScript(`
+redis.call('SET', KEYS[2], '1', 'EX', ARGV[3])
+local prev = redis.call('HGET', KEYS[1], ARGV[1])
+if not prev then
+	return 0
+end
+-- зашёл заново с другого устройства: старый left нового участника не трогает
+if cjson.decode(prev).sid ~= ARGV[2] then
+	return 0
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+return 1
+`)
+
+type redisVoiceRepo struct {
+	client redis.UniversalClient
+}
+
+func NewRedisVoiceRepo(client redis.UniversalClient) VoiceRepoInterface {
+	return &redisVoiceRepo{client: client}
+}
+
+func (r *redisVoiceRepo) roomKey(threadID uint) string {
+	return voiceRoomKeyPrefix + strconv.FormatUint(uint64(threadID), 10)
+}
+
+func (r *redisVoiceRepo) Join(ctx context.Context, threadID uint, p gdomain.VoiceParticipant) (bool, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return false, fmt.Errorf("marshal voice participant: %w", err)
+	}
+	res, err := voiceJoinScript.Run(ctx, r.client,
+		[]string{r.roomKey(threadID), voiceLeftKeyPrefix + p.SID},
+		p.Username, data, p.SID, int64(voiceRoomTTL.Seconds()),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (r *redisVoiceRepo) Leave(ctx context.Context, threadID uint, username, sid string) (bool, error) {
+	res, err := voiceLeaveScript.Run(ctx, r.client,
+		[]string{r.roomKey(threadID), voiceLeftKeyPrefix + sid},
+		username, sid, int64(voiceLeftTTL.Seconds()),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (r *redisVoiceRepo) Finish(ctx context.Context, threadID uint) (bool, error) {
+	n, err := r.client.Del(ctx, r.roomKey(threadID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetParticipants — одним пайплайном по всем тредам; участники по времени входа
+func (r *redisVoiceRepo) GetParticipants(ctx context.Context, threadIDs []uint) (map[uint][]gdomain.VoiceParticipant, error) {
+	res := make(map[uint][]gdomain.VoiceParticipant)
+	if len(threadIDs) == 0 {
+		return res, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(threadIDs))
+	for i, id := range threadIDs {
+		cmds[i] = pipe.HGetAll(ctx, r.roomKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			continue
+		}
+		participants := make([]gdomain.VoiceParticipant, 0, len(fields))
+		for _, raw := range fields {
+			var p gdomain.VoiceParticipant
+			if err := json.Unmarshal([]byte(raw), &p); err != nil {
+				return nil, fmt.Errorf("decode voice participant: %w", err)
+			}
+			participants = append(participants, p)
+		}
+		sort.Slice(participants, func(a, b int) bool {
+			return participants[a].JoinedAt.Before(participants[b].JoinedAt)
+		})
+		res[threadIDs[i]] = participants
+	}
+	return res, nil
+}
@@ -0,0 +1,19 @@
+package external
+
+import (
+	"context"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+// VoiceRepoInterface — кто в голосовых комнатах тредов. Пишется только из вебхуков LiveKit,
+// а они могут прийти дважды и не по порядку — методы это переживают.
+type VoiceRepoInterface interface {
+	// Join — true, если участник только что появился (не повтор и не запоздавший joined после left)
+	Join(ctx context.Context, threadID uint, p gdomain.VoiceParticipant) (bool, error)
+	// Leave — true, если участник с этим sid был в комнате и его убрали
+	Leave(ctx context.Context, threadID uint, username, sid string) (bool, error)
+	// Finish очищает комнату; true — в ней кто-то был
+	Finish(ctx context.Context, threadID uint) (bool, error)
+	GetParticipants(ctx context.Context, threadIDs []uint) (map[uint][]gdomain.VoiceParticipant, error)
+}
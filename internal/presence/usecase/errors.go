@@ -0,0 +1,9 @@
+package usecase
+
+import "errors"
+
+var (
+	ErrInvalidInput     = errors.New("invalid input")
+	ErrNoAccessToThread = errors.New("user has no access to this thread")
+	ErrNoAccessToSpool  = errors.New("user has no access to this spool")
+)
@@ -0,0 +1,37 @@
+package usecase
+
+import "github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+
+// ---------- Heartbeat ----------
+type HeartbeatInput struct {
+	UserID    uint
+	Username  string
+	SessionID string
+}
+
+// ---------- Leave ----------
+type LeaveInput struct {
+	UserID    uint
+	Username  string
+	SessionID string
+}
+
+// ---------- VoiceWebhook ----------
+// VoiceWebhookInput — уже проверенный вебхук LiveKit
+type VoiceWebhookInput struct {
+	Event       string // participant_joined | participant_left | room_finished, остальные игнорируем
+	RoomName    string
+	Participant *gdomain.VoiceParticipant // для participant_*
+}
+
+// ---------- GetThreadVoice ----------
+type GetThreadVoiceInput struct {
+	UserID   uint
+	ThreadID uint
+}
+
+// ---------- GetSpoolVoice ----------
+type GetSpoolVoiceInput struct {
+	UserID  uint
+	SpoolID uint
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/external"
+	spoolExternal "github.com/onionfriend2004/threadbook_backend/internal/spool/external"
+	wsExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+type PresenceUsecaseInterface interface {
+	Heartbeat(ctx context.Context, input HeartbeatInput) error
+	Leave(ctx context.Context, input LeaveInput) error
+	SweepExpired(ctx context.Context) error
+}
+
+// Присутствие живёт на heartbeat'ах привязанных к сессии (sid): сессия протухла или разлогинились —
+// heartbeat перестают проходить авторизацию, и через TTL пользователь уходит в offline.
+type presenceUsecase struct {
+	presenceRepo external.PresenceRepoInterface
+	spoolRepo    spoolExternal.SpoolRepoInterface
+	wsRepo       wsExternal.WebsocketRepoInterface
+	logger       *zap.Logger
+}
+
+func NewPresenceUsecase(
+	presenceRepo external.PresenceRepoInterface,
+	spoolRepo spoolExternal.SpoolRepoInterface,
+	wsRepo wsExternal.WebsocketRepoInterface,
+	logger *zap.Logger,
+) PresenceUsecaseInterface {
+	return &presenceUsecase{
+		presenceRepo: presenceRepo,
+		spoolRepo:    spoolRepo,
+		wsRepo:       wsRepo,
+		logger:       logger,
+	}
+}
+
+func (u *presenceUsecase) Heartbeat(ctx context.Context, input HeartbeatInput) error {
+	if input.UserID == 0 || input.SessionID == "" {
+		return ErrInvalidInput
+	}
+
+	cameOnline, err := u.presenceRepo.Heartbeat(ctx, input.UserID, input.Username, input.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to store heartbeat: %w", err)
+	}
+	if cameOnline {
+		u.broadcast(ctx, input.UserID, input.Username, gdomain.PresenceOnline)
+	}
+	return nil
+}
+
+func (u *presenceUsecase) Leave(ctx context.Context, input LeaveInput) error {
+	if input.UserID == 0 || input.SessionID == "" {
+		return ErrInvalidInput
+	}
+
+	wentOffline, err := u.presenceRepo.Leave(ctx, input.UserID, input.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to remove session presence: %w", err)
+	}
+	if wentOffline {
+		u.broadcast(ctx, input.UserID, input.Username, gdomain.PresenceOffline)
+	}
+	return nil
+}
+
+// SweepExpired переводит в offline тех, кто перестал слать heartbeat (закрыл вкладку, потерял сеть)
+func (u *presenceUsecase) SweepExpired(ctx context.Context) error {
+	expired, err := u.presenceRepo.PopExpired(ctx)
+	for userID, username := range expired {
+		u.broadcast(ctx, userID, username, gdomain.PresenceOffline)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sweep presence: %w", err)
+	}
+	return nil
+}
+
+// broadcast рассылает смену статуса во все спулы пользователя (spool#<id>)
+func (u *presenceUsecase) broadcast(ctx context.Context, userID uint, username, status string) {
+	spoolIDs, err := u.spoolRepo.GetSpoolIDsByUser(ctx, userID)
+	if err != nil {
+		u.logger.Warn("failed to get user spools for presence event", zap.Uint("userID", userID), zap.Error(err))
+		return
+	}
+
+	for _, spoolID := range spoolIDs {
+		ev := event.New(event.PresenceChangedPayload{
+			SpoolID:  spoolID,
+			UserID:   userID,
+			Username: username,
+			Status:   status,
+		}, event.Scope{SpoolID: spoolID}, event.UserActor(userID, username))
+		if err := u.wsRepo.PublishToSpool(ctx, spoolID, ev); err != nil {
+			u.logger.Warn("failed to publish presence event",
+				zap.Uint("spoolID", spoolID),
+				zap.Uint("userID", userID),
+				zap.Error(err))
+		}
+	}
+}
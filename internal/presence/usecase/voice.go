@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+	"github.com/onionfriend2004/threadbook_backend/internal/presence/external"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+// События вебхуков LiveKit, которые нас интересуют
+const (
+	voiceParticipantJoined = "participant_joined"
+	voiceParticipantLeft   = "participant_left"
+	voiceRoomFinished      = "room_finished"
+)
+
+// voiceRoomPrefix — голосовые комнаты тредов называются thread_<id> (см. thread usecase.VoiceRoomName)
+const voiceRoomPrefix = "thread_"
+
+type VoiceUsecaseInterface interface {
+	HandleWebhook(ctx context.Context, input VoiceWebhookInput) error
+	GetThreadVoice(ctx context.Context, input GetThreadVoiceInput) ([]gdomain.VoiceParticipant, error)
+	// GetSpoolVoice — thread id -> кто в голосе, только по тредам спула, которые пользователь видит
+	GetSpoolVoice(ctx context.Context, input GetSpoolVoiceInput) (map[uint][]gdomain.VoiceParticipant, error)
+}
+
+type voiceUsecase struct {
+	voiceRepo  external.VoiceRepoInterface
+	threadRepo threadExternal.ThreadRepoInterface
+	wsRepo     threadExternal.WebsocketRepoInterface
+	logger     *zap.Logger
+}
+
+func NewVoiceUsecase(
+	voiceRepo external.VoiceRepoInterface,
+	threadRepo threadExternal.ThreadRepoInterface,
+	wsRepo threadExternal.WebsocketRepoInterface,
+	logger *zap.Logger,
+) VoiceUsecaseInterface {
+	return &voiceUsecase{
+		voiceRepo:  voiceRepo,
+		threadRepo: threadRepo,
+		wsRepo:     wsRepo,
+		logger:     logger,
+	}
+}
+
+// HandleWebhook обновляет состав комнаты и, если он поменялся, шлёт событие в thread#<id>.
+// Повторы и перепутанный порядок вебхуков отсекает репозиторий — тогда событий нет.
+func (u *voiceUsecase) HandleWebhook(ctx context.Context, input VoiceWebhookInput) error {
+	threadID, ok := parseVoiceRoom(input.RoomName)
+	if !ok {
+		return nil // не наша комната
+	}
+
+	var (
+		changed bool
+		payload event.Payload
+		actor   *event.Actor
+		err     error
+	)
+	switch input.Event {
+	case voiceParticipantJoined, voiceParticipantLeft:
+		p := input.Participant
+		if p == nil || p.Username == "" || p.SID == "" {
+			return ErrInvalidInput
+		}
+		actor = event.UserActor(p.UserID, p.Username)
+		if input.Event == voiceParticipantJoined {
+			changed, err = u.voiceRepo.Join(ctx, threadID, *p)
+			payload = event.VoiceJoinedPayload{ThreadID: threadID, UserID: p.UserID, Username: p.Username, JoinedAt: p.JoinedAt.Unix()}
+		} else {
+			changed, err = u.voiceRepo.Leave(ctx, threadID, p.Username, p.SID)
+			payload = event.VoiceLeftPayload{ThreadID: threadID, UserID: p.UserID, Username: p.Username}
+		}
+	case voiceRoomFinished:
+		changed, err = u.voiceRepo.Finish(ctx, threadID)
+		payload = event.VoiceEndedPayload{ThreadID: threadID}
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update voice room: %w", err)
+	}
+	if !changed {
+		return nil
+	}
+
+	scope := event.Scope{ThreadID: threadID}
+	if thread, err := u.threadRepo.GetThreadByID(ctx, threadID); err == nil {
+		scope.SpoolID = thread.SpoolID
+	} else if !errors.Is(err, threadExternal.ErrThreadNotFound) {
+		u.logger.Warn("failed to get thread for voice event", zap.Uint("threadID", threadID), zap.Error(err))
+	}
+
+	if err := u.wsRepo.PublishToThread(ctx, threadID, event.New(payload, scope, actor)); err != nil {
+		return fmt.Errorf("failed to publish voice event: %w", err)
+	}
+	return nil
+}
+
+func (u *voiceUsecase) GetThreadVoice(ctx context.Context, input GetThreadVoiceInput) ([]gdomain.VoiceParticipant, error) {
+	if input.UserID == 0 || input.ThreadID == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	hasRights, err := u.threadRepo.CheckRightsUserOnThreadRoom(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rights: %w", err)
+	}
+	if !hasRights {
+		return nil, ErrNoAccessToThread
+	}
+
+	rooms, err := u.voiceRepo.GetParticipants(ctx, []uint{input.ThreadID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voice participants: %w", err)
+	}
+	return rooms[input.ThreadID], nil
+}
+
+func (u *voiceUsecase) GetSpoolVoice(ctx context.Context, input GetSpoolVoiceInput) (map[uint][]gdomain.VoiceParticipant, error) {
+	if input.UserID == 0 || input.SpoolID == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	inSpool, err := u.threadRepo.IsUserInSpool(ctx, input.UserID, input.SpoolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check spool membership: %w", err)
+	}
+	if !inSpool {
+		return nil, ErrNoAccessToSpool
+	}
+
+	threadIDs, err := u.threadRepo.GetAccessibleThreadIDsBySpool(ctx, input.UserID, input.SpoolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spool threads: %w", err)
+	}
+
+	rooms, err := u.voiceRepo.GetParticipants(ctx, threadIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voice participants: %w", err)
+	}
+	return rooms, nil
+}
+
+// parseVoiceRoom — thread id из имени комнаты thread_<id>
+func parseVoiceRoom(name string) (uint, bool) {
+	raw, ok := strings.CutPrefix(name, voiceRoomPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
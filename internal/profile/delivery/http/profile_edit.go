@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
 	"github.com/onionfriend2004/threadbook_backend/internal/profile/delivery/dto"
@@ -55,12 +56,18 @@ func (h *ProfileHandler) EditProfile(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		contentType, err := h.fileConfig.DetectContentType(file, fileHeader.Filename)
+		if err != nil {
+			lib.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		avatarInput = &usecase.Avatar{
 			File:        file,
 			Size:        fileHeader.Size,
 			Filename:    fileHeader.Filename,
 			Filetype:    "avatar",
-			ContentType: h.fileConfig.GetContentTypeByExtension(fileHeader.Filename),
+			ContentType: contentType,
 		}
 	} else if err != http.ErrMissingFile {
 		lib.WriteError(w, "invalid avatar file", http.StatusBadRequest)
@@ -73,8 +80,9 @@ func (h *ProfileHandler) EditProfile(w http.ResponseWriter, r *http.Request) {
 		Avatar:   avatarInput,
 	})
 	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
 		h.logger.Error("failed to update profile", zap.Error(err))
-		lib.WriteError(w, "failed to update profile", http.StatusInternalServerError)
+		lib.WriteError(w, clientErr.Error(), code)
 		return
 	}
 	resp := dto.UpdateProfileResponse{
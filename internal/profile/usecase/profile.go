@@ -46,13 +46,23 @@ func (uc *ProfileUsecase) UpdateProfile(ctx context.Context, input UpdateProfile
 		return nil, ErrEmptyProfileUpdate
 	}
 
-	var avatarLink string
+	var avatarLink, oldAvatarLink string
 	var err error
 
 	userIDstr := strconv.Itoa(input.UserID)
 
 	// Если передан аватар, сохраняем через fileUC
 	if input.Avatar != nil {
+		// старую аватарку удалим после обновления, иначе она так и останется лежать в бакете
+		current, getErr := uc.profileRepo.GetProfileByUserID(ctx, input.UserID)
+		if getErr != nil {
+			uc.logger.Error("failed to get profile", zap.Error(getErr))
+			return nil, getErr
+		}
+		if current != nil {
+			oldAvatarLink = current.AvatarLink
+		}
+
 		avatarLink, err = uc.fileUC.SaveFile(ctx, file.SaveFile{
 			File:        input.Avatar.File,
 			Size:        input.Avatar.Size,
@@ -71,8 +81,14 @@ func (uc *ProfileUsecase) UpdateProfile(ctx context.Context, input UpdateProfile
 	profile, err := uc.profileRepo.UpdateProfile(ctx, input.UserID, input.Nickname, avatarLink)
 	if err != nil {
 		uc.logger.Error("failed to update profile", zap.Error(err))
+		if avatarLink != "" {
+			uc.deleteAvatar(ctx, avatarLink)
+		}
 		return nil, err
 	}
+	if oldAvatarLink != "" && oldAvatarLink != profile.AvatarLink {
+		uc.deleteAvatar(ctx, oldAvatarLink)
+	}
 
 	// Формируем ответ
 	output := &UpdateProfileOutput{
@@ -86,6 +102,13 @@ func (uc *ProfileUsecase) UpdateProfile(ctx context.Context, input UpdateProfile
 	return output, nil
 }
 
+// deleteAvatar — ошибку только логируем: профиль уже обновлён, а забытый файл потом уберёт GC
+func (uc *ProfileUsecase) deleteAvatar(ctx context.Context, avatarLink string) {
+	if err := uc.fileUC.DeleteFile(context.WithoutCancel(ctx), file.DeleteFileInput{Filename: avatarLink}); err != nil {
+		uc.logger.Warn("failed to delete avatar", zap.Error(err), zap.String("avatar_link", avatarLink))
+	}
+}
+
 func (u *ProfileUsecase) GetProfilesByUsernames(ctx context.Context, usernames []string) ([]dto.GetProfilesResponseItem, error) {
 	if len(usernames) == 0 {
 		return nil, nil
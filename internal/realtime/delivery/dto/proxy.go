@@ -0,0 +1,62 @@
+package dto
+
+// Формат HTTP proxy Centrifugo: на любой запрос отвечаем 200 и одним из result / error / disconnect
+
+type ConnectRequest struct {
+	Client    string `json:"client"`
+	Transport string `json:"transport"`
+	Protocol  string `json:"protocol"`
+	Encoding  string `json:"encoding"`
+}
+
+type RefreshRequest struct {
+	Client    string `json:"client"`
+	Transport string `json:"transport"`
+	User      string `json:"user"`
+}
+
+// SubscribeRequest — и для subscribe, и для sub_refresh
+type SubscribeRequest struct {
+	Client    string `json:"client"`
+	Transport string `json:"transport"`
+	User      string `json:"user"`
+	Channel   string `json:"channel"`
+}
+
+type ProxyResponse struct {
+	Result     any              `json:"result,omitempty"`
+	Error      *ProxyError      `json:"error,omitempty"`
+	Disconnect *ProxyDisconnect `json:"disconnect,omitempty"`
+}
+
+// ProxyError — код из диапазона 400-1999, клиент получит его как ошибку
+type ProxyError struct {
+	Code    uint32 `json:"code"`
+	Message string `json:"message"`
+}
+
+// ProxyDisconnect — код из диапазона 4500-4999, клиент не будет переподключаться
+type ProxyDisconnect struct {
+	Code   uint32 `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type ConnectResult struct {
+	User     string `json:"user"`
+	ExpireAt int64  `json:"expire_at,omitempty"`
+	Info     any    `json:"info,omitempty"`
+}
+
+type ConnectInfo struct {
+	Username string `json:"username"`
+}
+
+// RefreshResult — и для refresh, и для sub_refresh
+type RefreshResult struct {
+	Expired  bool  `json:"expired,omitempty"`
+	ExpireAt int64 `json:"expire_at,omitempty"`
+}
+
+type SubscribeResult struct {
+	ExpireAt int64 `json:"expire_at,omitempty"`
+}
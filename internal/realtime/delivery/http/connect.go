@@ -0,0 +1,31 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
+)
+
+// Connect — Centrifugo спрашивает, чьё это соединение; токен подключения не нужен, хватает куки
+func (h *ProxyHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	var req dto.ConnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProxy(w, dto.ProxyResponse{Error: errProxyBadRequest})
+		return
+	}
+
+	res, err := h.usecase.Connect(r.Context(), usecase.ConnectInput{SessionID: sessionID(r)})
+	if err != nil {
+		h.proxyError(w, err, "failed to authorize centrifugo connect")
+		return
+	}
+
+	writeProxy(w, dto.ProxyResponse{Result: dto.ConnectResult{
+		User:     strconv.FormatUint(uint64(res.UserID), 10),
+		ExpireAt: res.ExpireAt.Unix(),
+		Info:     dto.ConnectInfo{Username: res.Username},
+	}})
+}
@@ -0,0 +1,103 @@
+package deliveryHTTP
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
+	"go.uber.org/zap"
+)
+
+// proxySecretHeader — Centrifugo кладёт его через proxy_static_http_headers, чтобы снаружи эндпоинты не дёргали
+const proxySecretHeader = "X-Centrifugo-Proxy-Secret"
+
+// Коды ответов proxy: ошибки 400-1999, отключения без переподключения 4500-4999
+var (
+	errProxyBadRequest     = &dto.ProxyError{Code: 400, Message: "bad request"}
+	errProxyPermission     = &dto.ProxyError{Code: 403, Message: "permission denied"}
+	errProxyUnknown        = &dto.ProxyError{Code: 404, Message: "unknown channel"}
+	errProxyInternal       = &dto.ProxyError{Code: 500, Message: "internal error"}
+	disconnectUnauthorized = &dto.ProxyDisconnect{Code: 4501, Reason: "unauthorized"}
+)
+
+type ProxyHandler struct {
+	usecase usecase.RealtimeUsecaseInterface
+	secret  string // без него proxy-эндпоинты не поднимаются, см. apiRouter
+	logger  *zap.Logger
+}
+
+func NewProxyHandler(u usecase.RealtimeUsecaseInterface, secret string, logger *zap.Logger) *ProxyHandler {
+	return &ProxyHandler{
+		usecase: u,
+		secret:  secret,
+		logger:  logger,
+	}
+}
+
+func (h *ProxyHandler) Routes(r chi.Router) {
+	r.Route("/centrifugo", func(r chi.Router) {
+		r.Use(h.checkSecret)
+		r.Post("/connect", h.Connect)
+		r.Post("/refresh", h.Refresh)
+		r.Post("/subscribe", h.Subscribe)
+		r.Post("/sub_refresh", h.SubRefresh)
+	})
+}
+
+func (h *ProxyHandler) checkSecret(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(proxySecretHeader)), []byte(h.secret)) != 1 {
+			lib.WriteError(w, "forbidden", lib.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sessionID — кука сессии из исходного запроса клиента (Centrifugo пробрасывает Cookie через proxy_http_headers)
+func sessionID(r *http.Request) string {
+	cookie, err := r.Cookie("sid")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// parseUser — Centrifugo передаёт user строкой
+func parseUser(user string) uint {
+	id, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+// writeProxy — proxy всегда отвечает 200, суть в теле
+func writeProxy(w http.ResponseWriter, resp dto.ProxyResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// proxyError переводит ошибку usecase в ответ proxy
+func (h *ProxyHandler) proxyError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, usecase.ErrUnauthorized):
+		writeProxy(w, dto.ProxyResponse{Disconnect: disconnectUnauthorized})
+	case errors.Is(err, usecase.ErrPermissionDenied):
+		writeProxy(w, dto.ProxyResponse{Error: errProxyPermission})
+	case errors.Is(err, usecase.ErrUnknownChannel):
+		writeProxy(w, dto.ProxyResponse{Error: errProxyUnknown})
+	case errors.Is(err, usecase.ErrInvalidInput):
+		writeProxy(w, dto.ProxyResponse{Error: errProxyBadRequest})
+	default:
+		h.logger.Error(msg, zap.Error(err))
+		writeProxy(w, dto.ProxyResponse{Error: errProxyInternal})
+	}
+}
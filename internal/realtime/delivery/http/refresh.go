@@ -0,0 +1,36 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
+)
+
+// Refresh — соединение доживает до expire_at, дальше Centrifugo переспрашивает; expired — отключить
+func (h *ProxyHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProxy(w, dto.ProxyResponse{Error: errProxyBadRequest})
+		return
+	}
+
+	res, err := h.usecase.Refresh(r.Context(), usecase.RefreshInput{
+		SessionID: sessionID(r),
+		UserID:    parseUser(req.User),
+	})
+	if err != nil {
+		h.proxyError(w, err, "failed to refresh centrifugo connection")
+		return
+	}
+
+	writeProxy(w, dto.ProxyResponse{Result: toRefreshResult(res)})
+}
+
+func toRefreshResult(res *usecase.RefreshResult) dto.RefreshResult {
+	if res.Expired {
+		return dto.RefreshResult{Expired: true}
+	}
+	return dto.RefreshResult{ExpireAt: res.ExpireAt.Unix()}
+}
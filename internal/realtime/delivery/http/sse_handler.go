@@ -0,0 +1,34 @@
+package deliveryHTTP
+
+import (
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
+	"go.uber.org/zap"
+)
+
+// SSEHandler — запасной транспорт real-time событий поверх обычного HTTP
+type SSEHandler struct {
+	usecase   usecase.StreamUsecaseInterface
+	heartbeat time.Duration
+	shutdown  <-chan struct{} // закрывается, когда сервер останавливается: потоки сами не кончаются
+	logger    *zap.Logger
+}
+
+func NewSSEHandler(u usecase.StreamUsecaseInterface, heartbeat time.Duration, shutdown <-chan struct{}, logger *zap.Logger) *SSEHandler {
+	return &SSEHandler{
+		usecase:   u,
+		heartbeat: heartbeat,
+		shutdown:  shutdown,
+		logger:    logger,
+	}
+}
+
+func (h *SSEHandler) Routes(r chi.Router, authenticator auth.AuthenticatorInterface) {
+	r.Route("/sse", func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(authenticator))
+		r.Get("/", h.Stream)
+	})
+}
@@ -0,0 +1,97 @@
+package deliveryHTTP
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
+	"go.uber.org/zap"
+)
+
+// sseRetryMs — через сколько браузер переподключается после обрыва
+const sseRetryMs = 3000
+
+// Stream — text/event-stream с событиями user#<id> и thread#<id> пользователя.
+// id: у каждого события — его event.Event.ID; после обрыва EventSource сам пришлёт его в Last-Event-ID.
+// event: resync — пропущенное догнать не вышло, клиент перечитывает состояние через REST.
+func (h *SSEHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		// EventSource заголовки не настраиваются, при первом подключении ID можно передать в query
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	stream, err := h.usecase.Open(r.Context(), usecase.OpenStreamInput{
+		UserID:      userID,
+		LastEventID: lastEventID,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to open event stream", zap.Uint("userID", userID), zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+	defer stream.Close()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx не должен копить ответ
+	w.WriteHeader(lib.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMs)
+	if stream.Resync {
+		fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+	}
+	if err := rc.Flush(); err != nil {
+		h.logger.Warn("streaming is not supported", zap.Error(err))
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ev, ok := <-stream.Events():
+			if !ok {
+				return
+			}
+			writeEvent(w, ev)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case <-r.Context().Done():
+			return
+		case <-h.shutdown:
+			// EventSource переподключится к живому инстансу и догонит по Last-Event-ID
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent пишет событие; data многострочной быть не должна, но на всякий случай режем по строкам
+func writeEvent(w http.ResponseWriter, ev usecase.StreamEvent) {
+	if ev.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", ev.ID)
+	}
+	for _, line := range bytes.Split(ev.Data, []byte("\n")) {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,30 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
+)
+
+// SubRefresh — переспрос подписки; если пользователя убрали из треда, отвечаем expired и его отписывает
+func (h *ProxyHandler) SubRefresh(w http.ResponseWriter, r *http.Request) {
+	var req dto.SubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProxy(w, dto.ProxyResponse{Error: errProxyBadRequest})
+		return
+	}
+
+	res, err := h.usecase.SubRefresh(r.Context(), usecase.SubscribeInput{
+		SessionID: sessionID(r),
+		UserID:    parseUser(req.User),
+		Channel:   req.Channel,
+	})
+	if err != nil {
+		h.proxyError(w, err, "failed to refresh centrifugo subscription")
+		return
+	}
+
+	writeProxy(w, dto.ProxyResponse{Result: toRefreshResult(res)})
+}
@@ -0,0 +1,31 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/usecase"
+)
+
+// Subscribe — права на канал проверяются по thread_users прямо сейчас, токен подписки не нужен.
+// expire_at заставляет Centrifugo периодически звать sub_refresh.
+func (h *ProxyHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var req dto.SubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProxy(w, dto.ProxyResponse{Error: errProxyBadRequest})
+		return
+	}
+
+	res, err := h.usecase.Subscribe(r.Context(), usecase.SubscribeInput{
+		SessionID: sessionID(r),
+		UserID:    parseUser(req.User),
+		Channel:   req.Channel,
+	})
+	if err != nil {
+		h.proxyError(w, err, "failed to authorize centrifugo subscribe")
+		return
+	}
+
+	writeProxy(w, dto.ProxyResponse{Result: dto.SubscribeResult{ExpireAt: res.ExpireAt.Unix()}})
+}
@@ -0,0 +1,40 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+type natsSubscriber struct {
+	nc     *nats.Conn
+	prefix string
+	logger *zap.Logger
+}
+
+func NewNatsSubscriber(nc *nats.Conn, prefix string, logger *zap.Logger) SubscriberInterface {
+	return &natsSubscriber{
+		nc:     nc,
+		prefix: prefix,
+		logger: logger,
+	}
+}
+
+func (s *natsSubscriber) Subscribe(channel string, handler func(channel string, msg threadExternal.MirrorMessage)) (func(), error) {
+	sub, err := s.nc.Subscribe(threadExternal.MirrorSubject(s.prefix, channel), func(m *nats.Msg) {
+		var msg threadExternal.MirrorMessage
+		if err := json.Unmarshal(m.Data, &msg); err != nil {
+			s.logger.Warn("failed to decode mirrored publication", zap.String("subject", m.Subject), zap.Error(err))
+			return
+		}
+		handler(strings.TrimPrefix(m.Subject, s.prefix+"."), msg)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %s: %w", channel, err)
+	}
+	return func() { _ = sub.Unsubscribe() }, nil
+}
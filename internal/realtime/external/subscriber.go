@@ -0,0 +1,9 @@
+package external
+
+import threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+
+// SubscriberInterface — подписка на зеркало публикаций Centrifugo (см. threadExternal.NewNatsMirrorPublisher).
+// handler зовётся из чужой горутины и не должен блокироваться.
+type SubscriberInterface interface {
+	Subscribe(channel string, handler func(channel string, msg threadExternal.MirrorMessage)) (unsubscribe func(), err error)
+}
@@ -0,0 +1,10 @@
+package usecase
+
+import "errors"
+
+var (
+	ErrInvalidInput     = errors.New("invalid input")
+	ErrUnauthorized     = errors.New("unauthorized")      // нет сессии или она чужая
+	ErrPermissionDenied = errors.New("permission denied") // на канал нет прав
+	ErrUnknownChannel   = errors.New("unknown channel")
+)
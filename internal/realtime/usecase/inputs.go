@@ -0,0 +1,45 @@
+package usecase
+
+import "time"
+
+// ---------- Connect ----------
+type ConnectInput struct {
+	SessionID string
+}
+
+type ConnectResult struct {
+	UserID   uint
+	Username string
+	ExpireAt time.Time
+}
+
+// ---------- Refresh ----------
+type RefreshInput struct {
+	SessionID string
+	UserID    uint // кого Centrifugo считает владельцем соединения
+}
+
+// ---------- Subscribe ----------
+type SubscribeInput struct {
+	SessionID string
+	UserID    uint
+	Channel   string
+}
+
+// RefreshResult — Expired: соединение/подписку пора закрыть, иначе продлеваем до ExpireAt
+type RefreshResult struct {
+	Expired  bool
+	ExpireAt time.Time
+}
+
+// ---------- OpenStream ----------
+type OpenStreamInput struct {
+	UserID      uint
+	LastEventID string // из заголовка Last-Event-ID; пусто — только новые события
+}
+
+// StreamEvent — событие для SSE: ID пуст у эфемерных (typing), их не догоняем
+type StreamEvent struct {
+	ID   string
+	Data []byte // event.Event в том виде, в каком его получил бы Centrifugo-клиент
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	authExternal "github.com/onionfriend2004/threadbook_backend/internal/auth/external"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+// RealtimeUsecaseInterface — ответы на proxy-запросы Centrifugo.
+// Права проверяются на каждом запросе по сессии и thread_users, а не зашиваются в токен,
+// так что выход из треда или разлогин закрывают доступ не позже, чем через refreshTTL.
+type RealtimeUsecaseInterface interface {
+	Connect(ctx context.Context, input ConnectInput) (*ConnectResult, error)
+	Refresh(ctx context.Context, input RefreshInput) (*RefreshResult, error)
+	Subscribe(ctx context.Context, input SubscribeInput) (*RefreshResult, error)
+	SubRefresh(ctx context.Context, input SubscribeInput) (*RefreshResult, error)
+}
+
+type realtimeUsecase struct {
+	sessionRepo authExternal.SessionRepoInterface
+	threadRepo  threadExternal.ThreadRepoInterface
+	refreshTTL  time.Duration // как часто Centrifugo переспрашивает соединение и подписки
+	logger      *zap.Logger
+}
+
+func NewRealtimeUsecase(
+	sessionRepo authExternal.SessionRepoInterface,
+	threadRepo threadExternal.ThreadRepoInterface,
+	refreshTTL time.Duration,
+	logger *zap.Logger,
+) RealtimeUsecaseInterface {
+	return &realtimeUsecase{
+		sessionRepo: sessionRepo,
+		threadRepo:  threadRepo,
+		refreshTTL:  refreshTTL,
+		logger:      logger,
+	}
+}
+
+func (u *realtimeUsecase) Connect(ctx context.Context, input ConnectInput) (*ConnectResult, error) {
+	if input.SessionID == "" {
+		return nil, ErrUnauthorized
+	}
+	session, err := u.sessionRepo.GetSessionByID(ctx, input.SessionID)
+	if err != nil {
+		if errors.Is(err, authExternal.ErrSessionNotFound) || errors.Is(err, authExternal.ErrInvalidSessionData) {
+			return nil, ErrUnauthorized
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &ConnectResult{
+		UserID:   session.UserID,
+		Username: session.Username,
+		ExpireAt: u.expireAt(session.ExpiresAt),
+	}, nil
+}
+
+// Refresh продлевает соединение, пока жива сессия того же пользователя
+func (u *realtimeUsecase) Refresh(ctx context.Context, input RefreshInput) (*RefreshResult, error) {
+	if input.UserID == 0 {
+		return nil, ErrInvalidInput
+	}
+	conn, err := u.Connect(ctx, ConnectInput{SessionID: input.SessionID})
+	if err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return &RefreshResult{Expired: true}, nil
+		}
+		return nil, err
+	}
+	if conn.UserID != input.UserID {
+		return &RefreshResult{Expired: true}, nil
+	}
+	return &RefreshResult{ExpireAt: conn.ExpireAt}, nil
+}
+
+func (u *realtimeUsecase) Subscribe(ctx context.Context, input SubscribeInput) (*RefreshResult, error) {
+	expireAt, err := u.authorizeChannel(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &RefreshResult{ExpireAt: expireAt}, nil
+}
+
+// SubRefresh — то же, что Subscribe, но отказ не ошибка, а сигнал отписать клиента
+func (u *realtimeUsecase) SubRefresh(ctx context.Context, input SubscribeInput) (*RefreshResult, error) {
+	expireAt, err := u.authorizeChannel(ctx, input)
+	if err != nil {
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrUnknownChannel) {
+			return &RefreshResult{Expired: true}, nil
+		}
+		return nil, err
+	}
+	return &RefreshResult{ExpireAt: expireAt}, nil
+}
+
+// authorizeChannel проверяет сессию и права на канал, возвращает, до какого момента доступ подтверждён
+func (u *realtimeUsecase) authorizeChannel(ctx context.Context, input SubscribeInput) (time.Time, error) {
+	if input.UserID == 0 || input.Channel == "" {
+		return time.Time{}, ErrInvalidInput
+	}
+	conn, err := u.Connect(ctx, ConnectInput{SessionID: input.SessionID})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if conn.UserID != input.UserID {
+		return time.Time{}, ErrUnauthorized
+	}
+
+	kind, id, err := parseChannel(input.Channel)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var allowed bool
+	switch kind {
+	case "user":
+		allowed = id == input.UserID
+	case "thread":
+		allowed, err = u.threadRepo.CheckRightsUserOnThreadRoom(ctx, id, input.UserID)
+	case "spool":
+		allowed, err = u.threadRepo.IsUserInSpool(ctx, input.UserID, id)
+	default:
+		return time.Time{}, ErrUnknownChannel
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to check channel access: %w", err)
+	}
+	if !allowed {
+		return time.Time{}, ErrPermissionDenied
+	}
+	return conn.ExpireAt, nil
+}
+
+// expireAt — через refreshTTL, но не позже конца сессии
+func (u *realtimeUsecase) expireAt(sessionExpiresAt time.Time) time.Time {
+	expireAt := time.Now().Add(u.refreshTTL)
+	if !sessionExpiresAt.IsZero() && sessionExpiresAt.Before(expireAt) {
+		return sessionExpiresAt
+	}
+	return expireAt
+}
+
+// parseChannel разбирает "thread#12" на ("thread", 12)
+func parseChannel(channel string) (string, uint, error) {
+	kind, rawID, ok := strings.Cut(channel, "#")
+	if !ok {
+		return "", 0, ErrUnknownChannel
+	}
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil || id == 0 {
+		return "", 0, ErrUnknownChannel
+	}
+	return kind, uint(id), nil
+}
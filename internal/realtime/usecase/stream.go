@@ -0,0 +1,345 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+	outboxExternal "github.com/onionfriend2004/threadbook_backend/internal/outbox/external"
+	"github.com/onionfriend2004/threadbook_backend/internal/realtime/external"
+	threadExternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+// StreamUsecaseInterface — SSE вместо WebSocket для тех, у кого прокси режут WebSocket до Centrifugo.
+// Поток содержит то же, что пришло бы в user#<id> и в thread#<id> всех тредов пользователя.
+type StreamUsecaseInterface interface {
+	Open(ctx context.Context, input OpenStreamInput) (*Stream, error)
+}
+
+type streamUsecase struct {
+	subscriber  external.SubscriberInterface
+	outboxRepo  outboxExternal.OutboxRepoInterface
+	threadRepo  threadExternal.ThreadRepoInterface
+	bufferSize  int
+	replayLimit int
+	logger      *zap.Logger
+}
+
+func NewStreamUsecase(
+	subscriber external.SubscriberInterface,
+	outboxRepo outboxExternal.OutboxRepoInterface,
+	threadRepo threadExternal.ThreadRepoInterface,
+	bufferSize int,
+	replayLimit int,
+	logger *zap.Logger,
+) StreamUsecaseInterface {
+	return &streamUsecase{
+		subscriber:  subscriber,
+		outboxRepo:  outboxRepo,
+		threadRepo:  threadRepo,
+		bufferSize:  bufferSize,
+		replayLimit: replayLimit,
+		logger:      logger,
+	}
+}
+
+// Open подписывается на каналы пользователя и, если передан Last-Event-ID, достаёт из outbox
+// всё, что было после него. Подписка идёт до догонялки, поэтому между ними ничего не теряется,
+// а пересечение отсекается по ID. Поток живёт до отмены ctx или до Close.
+func (u *streamUsecase) Open(ctx context.Context, input OpenStreamInput) (*Stream, error) {
+	if input.UserID == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	threadIDs, err := u.threadRepo.GetAccessibleThreadIDs(ctx, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user threads: %w", err)
+	}
+	channels := make([]string, 0, len(threadIDs)+1)
+	channels = append(channels, fmt.Sprintf("user#%d", input.UserID))
+	for _, id := range threadIDs {
+		channels = append(channels, fmt.Sprintf("thread#%d", id))
+	}
+
+	s := newStream(u.subscriber, u.bufferSize, max(u.replayLimit, minRecentKeys), u.logger)
+	s.canRead = func(ctx context.Context, threadID uint) (bool, error) {
+		return u.threadRepo.CheckRightsUserOnThreadRoom(ctx, threadID, input.UserID)
+	}
+	for _, channel := range channels {
+		if err := s.subscribe(channel); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	replay, resync, err := u.replay(ctx, channels, input.LastEventID)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	s.Resync = resync
+
+	go s.run(ctx, replay)
+	return s, nil
+}
+
+// replay — пропущенные события по возрастанию. resync — догнать нельзя (событие уже вычищено
+// из outbox или пропущено слишком много), клиенту надо перечитать состояние через REST.
+func (u *streamUsecase) replay(ctx context.Context, channels []string, lastEventID string) ([]streamItem, bool, error) {
+	if lastEventID == "" {
+		return nil, false, nil
+	}
+
+	afterID, err := u.outboxRepo.FindLastID(ctx, channels, lastEventID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find last event: %w", err)
+	}
+	if afterID == 0 {
+		return nil, true, nil
+	}
+
+	rows, err := u.outboxRepo.GetAfterID(ctx, channels, afterID, u.replayLimit+1)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch missed events: %w", err)
+	}
+	if len(rows) > u.replayLimit {
+		return nil, true, nil
+	}
+
+	items := make([]streamItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, streamItem{
+			channel: row.Channel,
+			event:   StreamEvent{ID: row.IdempotencyKey, Data: row.Payload},
+		})
+	}
+	return items, false, nil
+}
+
+// minRecentKeys — сколько последних ID помнит поток, чтобы отбрасывать повторы релея
+const minRecentKeys = 256
+
+// accessRecheck — как долго верим последней проверке прав на тред. Событий о выходе и кике нет,
+// так что отозванный доступ поток замечает не позже чем через столько
+const accessRecheck = 5 * time.Second
+
+type streamItem struct {
+	channel string
+	event   StreamEvent
+}
+
+// Stream — открытый SSE-поток одного соединения
+type Stream struct {
+	Resync bool // Last-Event-ID догнать не удалось
+
+	subscriber external.SubscriberInterface
+	logger     *zap.Logger
+
+	raw          chan streamItem // сюда пишут обработчики NATS, не блокируясь
+	events       chan StreamEvent
+	overflow     chan struct{} // закрыт — клиент не успевает читать
+	overflowOnce sync.Once
+	recent       *recentKeys
+
+	// canRead — может ли пользователь сейчас читать тред; проверяется перед отдачей событий thread#
+	canRead func(ctx context.Context, threadID uint) (bool, error)
+	checked map[string]time.Time // канал → когда права подтвердились; трогает только run
+
+	mu     sync.Mutex
+	unsubs map[string]func()
+	closed bool
+}
+
+func newStream(subscriber external.SubscriberInterface, bufferSize, recentSize int, logger *zap.Logger) *Stream {
+	return &Stream{
+		subscriber: subscriber,
+		logger:     logger,
+		raw:        make(chan streamItem, bufferSize),
+		events:     make(chan StreamEvent),
+		overflow:   make(chan struct{}),
+		recent:     newRecentKeys(recentSize),
+		checked:    make(map[string]time.Time),
+		unsubs:     make(map[string]func()),
+	}
+}
+
+// Events — сначала догонялка, потом живые события. Закрывается, когда поток кончился:
+// отменён ctx или клиент отстал больше чем на буфер (тогда пусть переподключается с Last-Event-ID).
+func (s *Stream) Events() <-chan StreamEvent {
+	return s.events
+}
+
+// Close снимает все подписки; можно звать несколько раз
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for _, unsub := range s.unsubs {
+		unsub()
+	}
+	s.unsubs = nil
+}
+
+func (s *Stream) subscribe(channel string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	if _, ok := s.unsubs[channel]; ok {
+		return nil
+	}
+	unsub, err := s.subscriber.Subscribe(channel, s.push)
+	if err != nil {
+		return err
+	}
+	s.unsubs[channel] = unsub
+	return nil
+}
+
+func (s *Stream) unsubscribe(channel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if unsub, ok := s.unsubs[channel]; ok {
+		unsub()
+		delete(s.unsubs, channel)
+	}
+}
+
+func (s *Stream) push(channel string, msg threadExternal.MirrorMessage) {
+	select {
+	case s.raw <- streamItem{channel: channel, event: StreamEvent{ID: msg.ID, Data: msg.Data}}:
+	default:
+		s.overflowOnce.Do(func() { close(s.overflow) })
+	}
+}
+
+func (s *Stream) run(ctx context.Context, replay []streamItem) {
+	defer close(s.events)
+
+	for _, item := range replay {
+		if !s.emit(ctx, item) {
+			return
+		}
+	}
+	for {
+		select {
+		case item := <-s.raw:
+			if !s.emit(ctx, item) {
+				return
+			}
+		case <-s.overflow:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// emit отдаёт событие читателю, если его ещё не отдавали. false — поток кончился.
+func (s *Stream) emit(ctx context.Context, item streamItem) bool {
+	allowed, err := s.allowed(ctx, item.channel)
+	if err != nil {
+		// не знаем, можно ли отдавать — рвём поток, клиент переподключится и догонит по Last-Event-ID
+		s.logger.Warn("failed to check thread access", zap.String("channel", item.channel), zap.Error(err))
+		return false
+	}
+	if !allowed {
+		return true
+	}
+	if item.event.ID != "" && !s.recent.add(item.event.ID) {
+		return true
+	}
+	s.followNewThread(item)
+
+	select {
+	case s.events <- item.event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// allowed — события thread# отдаём, только пока пользователь в треде. Выгнали — отписываемся
+func (s *Stream) allowed(ctx context.Context, channel string) (bool, error) {
+	idStr, ok := strings.CutPrefix(channel, "thread#")
+	if !ok || s.canRead == nil {
+		return true, nil
+	}
+	if at, ok := s.checked[channel]; ok && time.Since(at) < accessRecheck {
+		return true, nil
+	}
+	threadID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	ok, err = s.canRead(ctx, uint(threadID))
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		delete(s.checked, channel)
+		s.unsubscribe(channel)
+		return false, nil
+	}
+	s.checked[channel] = time.Now()
+	return true, nil
+}
+
+// followNewThread — пользователя добавили в тред, пока поток открыт: подписываемся и на него.
+// В свой user# канал события кладёт только сервер, так что права здесь уже проверены.
+func (s *Stream) followNewThread(item streamItem) {
+	if !strings.HasPrefix(item.channel, "user#") {
+		return
+	}
+	ev, err := event.Decode(item.event.Data)
+	if err != nil {
+		return
+	}
+	var channel string
+	switch p := ev.Payload.(type) {
+	case event.ThreadCreatedPayload:
+		channel = p.Channel
+	case event.ThreadInvitePayload:
+		channel = p.Channel
+	}
+	if !strings.HasPrefix(channel, "thread#") {
+		return
+	}
+	if err := s.subscribe(channel); err != nil {
+		s.logger.Warn("failed to follow new thread", zap.String("channel", channel), zap.Error(err))
+	}
+}
+
+// recentKeys — последние n ID в порядке прихода
+type recentKeys struct {
+	ring []string
+	next int
+	set  map[string]struct{}
+}
+
+func newRecentKeys(n int) *recentKeys {
+	return &recentKeys{ring: make([]string, n), set: make(map[string]struct{}, n)}
+}
+
+// add — false, если ID уже видели
+func (r *recentKeys) add(key string) bool {
+	if _, ok := r.set[key]; ok {
+		return false
+	}
+	if old := r.ring[r.next]; old != "" {
+		delete(r.set, old)
+	}
+	r.ring[r.next] = key
+	r.next = (r.next + 1) % len(r.ring)
+	r.set[key] = struct{}{}
+	return true
+}
@@ -5,7 +5,9 @@ type GetSpoolMembersResponse struct {
 }
 
 type MemberShortInfo struct {
+	ID         uint   `json:"id"`
 	Username   string `json:"username"`
 	Nickname   string `json:"nickname,omitempty"`
 	AvatarPath string `json:"avatar_link,omitempty"`
+	Online     bool   `json:"online"`
 }
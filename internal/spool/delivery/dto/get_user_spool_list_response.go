@@ -5,8 +5,10 @@ type GetUserSpoolListResponse struct {
 }
 
 type SpoolShortInfo struct {
-	SpoolID    uint   `json:"id"`
-	Name       string `json:"name"`
-	IsCreator  bool   `json:"is_creator"`
-	BannerLink string `json:"banner_link,omitempty"`
+	SpoolID      uint   `json:"id"`
+	Name         string `json:"name"`
+	IsCreator    bool   `json:"is_creator"`
+	BannerLink   string `json:"banner_link,omitempty"`
+	UnreadCount  int64  `json:"unread_count"`
+	MentionCount int64  `json:"mention_count"`
 }
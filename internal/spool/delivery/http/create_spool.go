@@ -58,11 +58,17 @@ func (h *SpoolHandler) CreateSpool(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		contentType, err := h.fileConfig.DetectContentType(file, fileHeader.Filename)
+		if err != nil {
+			lib.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		bannerInput = &usecase.BannerInput{
 			File:        file,
 			Size:        fileHeader.Size,
 			Filename:    fileHeader.Filename,
-			ContentType: h.fileConfig.GetContentTypeByExtension(fileHeader.Filename),
+			ContentType: contentType,
 		}
 	} else if err != http.ErrMissingFile {
 		lib.WriteError(w, "invalid banner file", http.StatusBadRequest)
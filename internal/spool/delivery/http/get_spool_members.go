@@ -41,10 +41,12 @@ func (h *SpoolHandler) GetSpoolMembers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := dto.GetSpoolMembersResponse{}
-	for _, u := range users {
+	for _, m := range users {
 		resp.Members = append(resp.Members, dto.MemberShortInfo{
-			Username: u.Username,
+			ID:       m.User.ID,
+			Username: m.User.Username,
 			// Avatar:   u.AvatarLink,
+			Online: m.Online,
 		})
 	}
 
@@ -28,10 +28,12 @@ func (h *SpoolHandler) GetUserSpoolList(w http.ResponseWriter, r *http.Request)
 	resp := dto.GetUserSpoolListResponse{}
 	for _, s := range spools {
 		resp.Spools = append(resp.Spools, dto.SpoolShortInfo{
-			SpoolID:    s.ID,
-			Name:       s.Name,
-			IsCreator:  s.IsCreator,
-			BannerLink: s.BannerLink,
+			SpoolID:      s.ID,
+			Name:         s.Name,
+			IsCreator:    s.IsCreator,
+			BannerLink:   s.BannerLink,
+			UnreadCount:  s.UnreadCount,
+			MentionCount: s.MentionCount,
 		})
 	}
 
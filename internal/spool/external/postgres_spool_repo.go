@@ -126,8 +126,9 @@ func (r *spoolRepo) DeleteSpool(ctx context.Context, spoolID uint) error {
 	return r.db.WithContext(ctx).Delete(&gdomain.Spool{}, spoolID).Error
 }
 
-func (r *spoolRepo) AddUserToSpoolByUsername(ctx context.Context, username string, spoolID uint) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+func (r *spoolRepo) AddUserToSpoolByUsername(ctx context.Context, username string, spoolID uint) (uint, error) {
+	var userID uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var user gdomain.User
 		if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -135,6 +136,7 @@ func (r *spoolRepo) AddUserToSpoolByUsername(ctx context.Context, username strin
 			}
 			return err
 		}
+		userID = user.ID
 
 		userSpool := gdomain.UserSpool{
 			UserID:  user.ID,
@@ -171,6 +173,10 @@ func (r *spoolRepo) AddUserToSpoolByUsername(ctx context.Context, username strin
 
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
 }
 
 func (r *spoolRepo) RemoveUserFromSpool(ctx context.Context, userID, spoolID uint) error {
@@ -197,7 +203,18 @@ func (r *spoolRepo) GetSpoolsByUser(ctx context.Context, userID uint) ([]gdomain
 			spools.id,
 			spools.name,
 			spools.banner_link,
-			CASE WHEN spools.creator_id = ? THEN TRUE ELSE FALSE END AS is_creator
+			CASE WHEN spools.creator_id = ? THEN TRUE ELSE FALSE END AS is_creator,
+			(SELECT COUNT(*) FROM thread_users tu
+				JOIN threads t ON t.id = tu.thread_id AND t.spool_id = spools.id
+				JOIN messages m ON m.thread_id = tu.thread_id AND m.id > tu.last_read_message_id
+					AND m.user_id <> tu.user_id AND m.deleted_at IS NULL
+				WHERE tu.user_id = us.user_id AND tu.is_member = TRUE) AS unread_count,
+			(SELECT COUNT(*) FROM thread_users tu
+				JOIN threads t ON t.id = tu.thread_id AND t.spool_id = spools.id
+				JOIN message_mentions mm ON mm.thread_id = tu.thread_id AND mm.user_id = tu.user_id
+					AND mm.message_id > tu.last_read_message_id
+				JOIN messages m ON m.id = mm.message_id AND m.deleted_at IS NULL
+				WHERE tu.user_id = us.user_id AND tu.is_member = TRUE) AS mention_count
 		`, userID).
 		Joins("JOIN user_spools us ON us.spool_id = spools.id").
 		Where("us.user_id = ?", userID).
@@ -206,6 +223,15 @@ func (r *spoolRepo) GetSpoolsByUser(ctx context.Context, userID uint) ([]gdomain
 	return result, err
 }
 
+func (r *spoolRepo) GetSpoolIDsByUser(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Table("user_spools").
+		Where("user_id = ?", userID).
+		Pluck("spool_id", &ids).Error
+	return ids, err
+}
+
 func (r *spoolRepo) GetMembersBySpoolID(ctx context.Context, spoolID uint) ([]gdomain.User, error) {
 	var users []gdomain.User
 	err := r.db.WithContext(ctx).
@@ -13,9 +13,11 @@ type SpoolRepoInterface interface {
 	DeleteSpool(ctx context.Context, spoolID uint) error
 
 	// join-таблицы spool <-> user
-	AddUserToSpoolByUsername(ctx context.Context, username string, spoolID uint) error
+	// AddUserToSpoolByUsername возвращает id добавленного пользователя
+	AddUserToSpoolByUsername(ctx context.Context, username string, spoolID uint) (uint, error)
 	RemoveUserFromSpool(ctx context.Context, userID, spoolID uint) error
 	GetSpoolsByUser(ctx context.Context, userID uint) ([]gdomain.SpoolWithCreator, error)
+	GetSpoolIDsByUser(ctx context.Context, userID uint) ([]uint, error)
 	GetMembersBySpoolID(ctx context.Context, spoolID uint) ([]gdomain.User, error)
 
 	IsUserInSpool(ctx context.Context, userID uint, spoolID uint) (bool, error)
@@ -8,6 +8,7 @@ import (
 	"github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
 	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+	presenceExternal "github.com/onionfriend2004/threadbook_backend/internal/presence/external"
 	"github.com/onionfriend2004/threadbook_backend/internal/spool/external"
 	wsexternal "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
 	"go.uber.org/zap"
@@ -20,34 +21,38 @@ type SpoolUsecaseInterface interface {
 	InviteMemberInSpool(ctx context.Context, input InviteMemberInSpoolInput) error
 	UpdateSpool(ctx context.Context, input UpdateSpoolInput) (*gdomain.Spool, error)
 	GetSpoolInfoById(ctx context.Context, input GetSpoolInfoByIdInput) (*gdomain.Spool, error)
-	GetSpoolMembers(ctx context.Context, input GetSpoolMembersInput) ([]gdomain.User, error)
+	GetSpoolMembers(ctx context.Context, input GetSpoolMembersInput) ([]gdomain.SpoolMember, error)
 }
 
 type spoolUsecase struct {
-	spoolRepo external.SpoolRepoInterface
-	wsRepo    wsexternal.WebsocketRepoInterface
-	fileUC    usecase.FileUsecaseInterface
-	logger    *zap.Logger
+	spoolRepo    external.SpoolRepoInterface
+	wsRepo       wsexternal.WebsocketRepoInterface
+	presenceRepo presenceExternal.PresenceRepoInterface
+	fileUC       usecase.FileUsecaseInterface
+	logger       *zap.Logger
 }
 
 func NewSpoolUsecase(
 	spoolRepo external.SpoolRepoInterface,
 	wsRepo wsexternal.WebsocketRepoInterface,
+	presenceRepo presenceExternal.PresenceRepoInterface,
 	fileUC usecase.FileUsecaseInterface,
 	logger *zap.Logger,
 ) SpoolUsecaseInterface {
 	return &spoolUsecase{
-		spoolRepo: spoolRepo,
-		wsRepo:    wsRepo,
-		fileUC:    fileUC,
-		logger:    logger,
+		spoolRepo:    spoolRepo,
+		wsRepo:       wsRepo,
+		presenceRepo: presenceRepo,
+		fileUC:       fileUC,
+		logger:       logger,
 	}
 }
 
 // ---------- Create ----------
 func (u *spoolUsecase) CreateSpool(ctx context.Context, input CreateSpoolInput) (*gdomain.Spool, error) {
 	var bannerLink string
-	var bannerUploaded bool
+	// спул записан в БД; пока нет — загруженный баннер при любой ошибке удаляем
+	committed := false
 
 	if input.BannerInput != nil {
 		fileInput := usecase.SaveFile{
@@ -64,18 +69,20 @@ func (u *spoolUsecase) CreateSpool(ctx context.Context, input CreateSpoolInput)
 		if saveErr != nil {
 			return nil, fmt.Errorf("failed to save banner: %w", saveErr)
 		}
-		bannerUploaded = true
-
-		defer func(bannerLink string, uploaded bool) {
-			if !uploaded {
-				if deleteErr := u.fileUC.DeleteFile(ctx, usecase.DeleteFileInput{Filename: bannerLink}); deleteErr != nil {
-					u.logger.Error("failed to cleanup banner after error",
-						zap.Error(deleteErr),
-						zap.String("banner_link", bannerLink),
-					)
-				}
+
+		// замыкание, а не аргументы: committed должен читаться на выходе, а не в момент defer
+		defer func() {
+			if committed {
+				return
+			}
+			// запрос могли отменить — убрать файл всё равно надо
+			if deleteErr := u.fileUC.DeleteFile(context.WithoutCancel(ctx), usecase.DeleteFileInput{Filename: bannerLink}); deleteErr != nil {
+				u.logger.Error("failed to cleanup banner after error",
+					zap.Error(deleteErr),
+					zap.String("banner_link", bannerLink),
+				)
 			}
-		}(bannerLink, bannerUploaded)
+		}()
 	}
 
 	// Создаем доменную модель спула
@@ -98,11 +105,12 @@ func (u *spoolUsecase) CreateSpool(ctx context.Context, input CreateSpoolInput)
 		)
 		return nil, fmt.Errorf("failed to save spool to database: %w", err)
 	}
+	committed = true
 
 	u.logger.Info("spool created successfully",
 		zap.Uint("spool_id", createdSpool.ID),
 		zap.String("spool_name", createdSpool.Name),
-		zap.Bool("has_banner", bannerUploaded),
+		zap.Bool("has_banner", bannerLink != ""),
 	)
 
 	return createdSpool, nil
@@ -157,31 +165,31 @@ func (u *spoolUsecase) InviteMemberInSpool(ctx context.Context, input InviteMemb
 		return ErrInvalidInput
 	}
 
+	spool, err := u.spoolRepo.GetSpoolByID(ctx, input.SpoolID)
+	if err != nil {
+		u.logger.Error("failed to get spool", zap.Uint("spool_id", input.SpoolID), zap.Error(err))
+		return ErrInternal
+	}
+	payload := event.SpoolInvitedPayload{
+		SpoolID:    spool.ID,
+		BannerLink: spool.BannerLink,
+		Name:       spool.Name,
+	}
+
 	for _, username := range input.MemberUsernames {
 		if username == "" {
 			continue
 		}
-		if err := u.spoolRepo.AddUserToSpoolByUsername(ctx, username, input.SpoolID); err != nil {
+		inviteeID, err := u.spoolRepo.AddUserToSpoolByUsername(ctx, username, input.SpoolID)
+		if err != nil {
 			u.logger.Error("failed to add user to spool", zap.String("username", username), zap.Error(err))
 			return err
 		}
-		spool, err := u.spoolRepo.GetSpoolByID(ctx, input.SpoolID)
-		if err != nil {
-			u.logger.Error("failed to get spool", zap.Uint("spool_id", input.SpoolID), zap.Error(err))
-			return ErrInternal
-		}
 
-		payload := event.SpoolInvitedPayload{
-			SpoolID:    spool.ID,
-			BannerLink: spool.BannerLink,
-			Name:       spool.Name,
-		}
-
-		if err := u.wsRepo.PublishToUser(ctx, input.UserID, event.Event{
-			Type:    event.ThreadInvited,
-			Payload: payload,
-		}); err != nil {
-			u.logger.Warn("failed to publish ThreadInvited event", zap.Uint("userID", input.UserID), zap.Error(err))
+		// Приглашение уходит приглашённому, а не тому, кто пригласил
+		ev := event.New(payload, event.Scope{SpoolID: spool.ID}, event.UserActor(input.UserID, input.Username))
+		if err := u.wsRepo.PublishToUser(ctx, inviteeID, ev); err != nil {
+			u.logger.Warn("failed to publish SpoolInvited event", zap.Uint("userID", inviteeID), zap.Error(err))
 		}
 	}
 	return nil
@@ -203,7 +211,7 @@ func (u *spoolUsecase) UpdateSpool(ctx context.Context, input UpdateSpoolInput)
 }
 
 // ---------- Get members ----------
-func (u *spoolUsecase) GetSpoolMembers(ctx context.Context, input GetSpoolMembersInput) ([]gdomain.User, error) {
+func (u *spoolUsecase) GetSpoolMembers(ctx context.Context, input GetSpoolMembersInput) ([]gdomain.SpoolMember, error) {
 	if input.SpoolID == 0 || input.UserID == 0 {
 		return nil, ErrInvalidInput
 	}
@@ -239,7 +247,21 @@ func (u *spoolUsecase) GetSpoolMembers(ctx context.Context, input GetSpoolMember
 		zap.Int("members_count", len(members)),
 	)
 
-	return members, nil
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.ID)
+	}
+	// Присутствие не критично: если Redis недоступен, отдаём всех оффлайн
+	online, err := u.presenceRepo.GetOnline(ctx, ids)
+	if err != nil {
+		u.logger.Warn("failed to get members presence", zap.Uint("spool_id", input.SpoolID), zap.Error(err))
+	}
+
+	result := make([]gdomain.SpoolMember, 0, len(members))
+	for _, m := range members {
+		result = append(result, gdomain.SpoolMember{User: m, Online: online[m.ID]})
+	}
+	return result, nil
 }
 
 // ---------- Get info ----------
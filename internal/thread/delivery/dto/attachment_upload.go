@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type InitAttachmentUploadRequest struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"` // весь файл целиком, больше этого не примем
+}
+
+// AttachmentUploadResponse — докачиваемая загрузка: следующую часть шлём с offset, размером part_size (последняя — остаток)
+type AttachmentUploadResponse struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	PartSize  int64     `json:"part_size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
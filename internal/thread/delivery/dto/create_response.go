@@ -8,6 +8,7 @@ type ThreadCreateResponse struct {
 	Title     string    `json:"title"`
 	Type      string    `json:"type"`
 	IsClosed  bool      `json:"is_closed"`
+	StageMode bool      `json:"stage_mode"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
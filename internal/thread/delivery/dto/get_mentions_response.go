@@ -0,0 +1,13 @@
+package dto
+
+import "time"
+
+type MentionResponse struct {
+	ID          uint            `json:"id"`
+	Kind        string          `json:"kind"` // user | here | thread
+	SpoolID     uint            `json:"spool_id"`
+	ThreadID    uint            `json:"thread_id"`
+	ThreadTitle string          `json:"thread_title"`
+	Message     MessageResponse `json:"message"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
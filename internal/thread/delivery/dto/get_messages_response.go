@@ -2,12 +2,40 @@ package dto
 
 import "time"
 
+// GetMessagesResponse — страница истории; курсоры передаются обратно в ?cursor=
+type GetMessagesResponse struct {
+	Messages   []MessageResponse `json:"messages"`
+	PrevCursor string            `json:"prev_cursor,omitempty"` // более старые сообщения
+	NextCursor string            `json:"next_cursor,omitempty"` // более новые сообщения
+}
+
 type MessageResponse struct {
-	ID        uint      `json:"id"`
-	ThreadID  uint      `json:"thread_id"`
-	Username  string    `json:"username"`
-	Content   string    `json:"content"`
-	Payloads  []any     `json:"payloads,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint                  `json:"id"`
+	ThreadID    uint                  `json:"thread_id"`
+	Seq         uint64                `json:"seq"`
+	Username    string                `json:"username"`
+	Content     string                `json:"content"`
+	ReplyTo     *MessageReplyResponse `json:"reply_to,omitempty"`
+	Attachments []AttachmentResponse  `json:"attachments,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// MessageReplyResponse — встроенная цитата сообщения, на которое ответили.
+// Если родитель удалён, заполнены только id и deleted.
+type MessageReplyResponse struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+// AttachmentResponse — метаданные вложения; сам файл лежит по /uploads/{bucket}/{file_link}
+type AttachmentResponse struct {
+	ID          uint   `json:"id"`
+	Bucket      string `json:"bucket"`
+	FileLink    string `json:"file_link"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
 }
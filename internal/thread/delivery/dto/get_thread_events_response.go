@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ThreadEventsResponse — события треда после ?since=; если has_more, запрашиваем дальше с seq последнего
+type ThreadEventsResponse struct {
+	Events  []ThreadEventResponse `json:"events"`
+	LastSeq uint64                `json:"last_seq"`
+	HasMore bool                  `json:"has_more"`
+}
+
+// ThreadEventResponse — одна запись журнала. Для message.created заполнено message
+// (если сообщение с тех пор удалено — его нет), для остальных — payload как в real-time событии.
+type ThreadEventResponse struct {
+	Seq       uint64           `json:"seq"`
+	Type      string           `json:"type"`
+	MessageID *uint            `json:"message_id,omitempty"`
+	Message   *MessageResponse `json:"message,omitempty"`
+	Payload   json.RawMessage  `json:"payload,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
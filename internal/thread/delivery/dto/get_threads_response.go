@@ -0,0 +1,9 @@
+package dto
+
+// ThreadListItemResponse — тред в списке спула со счётчиками непрочитанного
+type ThreadListItemResponse struct {
+	ThreadCreateResponse
+	LastReadMessageID uint  `json:"last_read_message_id"`
+	UnreadCount       int64 `json:"unread_count"`
+	MentionCount      int64 `json:"mention_count"`
+}
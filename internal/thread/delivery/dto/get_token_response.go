@@ -1,5 +1,11 @@
 package dto
 
+import "time"
+
 type GetVoiceTokenResponse struct {
-	Token string `json:"token"`
+	Token     string    `json:"token"`
+	Role      string    `json:"role"`       // роль в спуле: owner / moderator / member
+	VoiceRole string    `json:"voice_role"` // speaker / listener
+	Stage     bool      `json:"stage"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
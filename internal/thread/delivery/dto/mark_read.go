@@ -0,0 +1,12 @@
+package dto
+
+type MarkReadRequest struct {
+	MessageID uint `json:"message_id,omitempty"` // не передан — прочитано всё
+}
+
+type MarkReadResponse struct {
+	ThreadID          uint  `json:"thread_id"`
+	LastReadMessageID uint  `json:"last_read_message_id"`
+	UnreadCount       int64 `json:"unread_count"`
+	MentionCount      int64 `json:"mention_count"`
+}
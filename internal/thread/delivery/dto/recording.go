@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+type RecordingResponse struct {
+	ID        uint      `json:"id"`
+	ThreadID  uint      `json:"thread_id"`
+	Status    string    `json:"status"`
+	StartedBy string    `json:"started_by"`
+	StartedAt time.Time `json:"started_at"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type SearchMessagesResponse struct {
+	Results []SearchResultResponse `json:"results"`
+}
+
+// SearchResultResponse — найденное сообщение.
+// Snippet уже экранирован, совпадения обёрнуты в <mark>...</mark>.
+type SearchResultResponse struct {
+	SpoolID     uint            `json:"spool_id"`
+	ThreadTitle string          `json:"thread_title"`
+	Snippet     string          `json:"snippet"`
+	Rank        float64         `json:"rank"`
+	Message     MessageResponse `json:"message"`
+}
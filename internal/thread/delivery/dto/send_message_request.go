@@ -1,5 +1,7 @@
 package dto
 
 type SendMessageRequest struct {
-	Content string `json:"content"`
+	Content       string `json:"content"`
+	ReplyToID     *uint  `json:"reply_to_id,omitempty"`
+	AttachmentIDs []uint `json:"attachment_ids,omitempty"` // id из POST /thread/{id}/attachments
 }
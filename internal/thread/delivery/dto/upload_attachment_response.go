@@ -0,0 +1,5 @@
+package dto
+
+type UploadAttachmentResponse struct {
+	Attachment AttachmentResponse `json:"attachment"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+type VoiceParticipantRequest struct {
+	Username string `json:"username"` // identity участника в LiveKit
+}
+
+type VoiceTrackResponse struct {
+	SID    string `json:"sid"`
+	Source string `json:"source"`
+	Muted  bool   `json:"muted"`
+}
+
+type VoiceParticipantResponse struct {
+	UserID   uint                 `json:"user_id"`
+	Username string               `json:"username"`
+	SID      string               `json:"sid"`
+	JoinedAt time.Time            `json:"joined_at"`
+	Tracks   []VoiceTrackResponse `json:"tracks"`
+
+	VoiceRole  string `json:"voice_role"` // speaker / listener
+	HandRaised bool   `json:"hand_raised"`
+}
+
+type SetStageModeRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type RaiseHandRequest struct {
+	Raised bool `json:"raised"`
+}
+
+type VoiceParticipantsResponse struct {
+	ThreadID     uint                       `json:"thread_id"`
+	Participants []VoiceParticipantResponse `json:"participants"`
+}
+
+type MuteVoiceParticipantResponse struct {
+	MutedTracks int `json:"muted_tracks"`
+}
@@ -0,0 +1,43 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// AbortAttachmentUpload бросает загрузку, принятые части удаляются
+func (h *ThreadHandler) AbortAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.messageUsecase.AbortAttachmentUpload(r.Context(), usecase.AttachmentUploadInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+		UploadID: chi.URLParam(r, "uploadID"),
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to abort attachment upload", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.WriteHeader(lib.StatusNoContent)
+}
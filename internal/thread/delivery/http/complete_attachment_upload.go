@@ -0,0 +1,53 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// CompleteAttachmentUpload собирает файл из частей. Ответ как у UploadAttachment: id вложения для attachment_ids
+func (h *ThreadHandler) CompleteAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := h.messageUsecase.CompleteAttachmentUpload(r.Context(), usecase.AttachmentUploadInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+		UploadID: chi.URLParam(r, "uploadID"),
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to complete attachment upload", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.UploadAttachmentResponse{
+		Attachment: toAttachmentResponse(payload),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode upload attachment response", zap.Error(err))
+	}
+}
@@ -47,6 +47,7 @@ func (h *ThreadHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Title:     createdThread.Title,
 		Type:      createdThread.Type,
 		IsClosed:  createdThread.IsClosed,
+		StageMode: createdThread.StageMode,
 		CreatedAt: createdThread.CreatedAt,
 		UpdatedAt: createdThread.UpdatedAt,
 	}
@@ -0,0 +1,51 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// DemoteVoiceParticipant возвращает спикера в слушатели. Только для модераторов треда
+func (h *ThreadHandler) DemoteVoiceParticipant(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	var req dto.VoiceParticipantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		lib.WriteError(w, "invalid JSON", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.roomUsecase.DemoteVoiceParticipant(r.Context(), usecase.VoiceParticipantInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+		Username: req.Username,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to demote voice participant", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.WriteHeader(lib.StatusNoContent)
+}
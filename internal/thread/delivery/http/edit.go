@@ -46,6 +46,7 @@ func (h *ThreadHandler) Update(w http.ResponseWriter, r *http.Request) {
 		Title:     updatedThread.Title,
 		Type:      updatedThread.Type,
 		IsClosed:  updatedThread.IsClosed,
+		StageMode: updatedThread.StageMode,
 		CreatedAt: updatedThread.CreatedAt,
 		UpdatedAt: updatedThread.UpdatedAt,
 	}
@@ -0,0 +1,42 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// EndVoiceRoom закрывает голосовую комнату треда для всех. Только для модераторов треда
+func (h *ThreadHandler) EndVoiceRoom(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.roomUsecase.EndVoiceRoom(r.Context(), usecase.VoiceRoomInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to end voice room", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.WriteHeader(lib.StatusNoContent)
+}
@@ -47,16 +47,23 @@ func (h *ThreadHandler) GetBySpoolID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := make([]dto.ThreadCreateResponse, 0, len(threads))
-	for _, t := range threads {
-		resp = append(resp, dto.ThreadCreateResponse{
-			ID:        t.ID,
-			SpoolID:   t.SpoolID,
-			Title:     t.Title,
-			Type:      t.Type,
-			IsClosed:  t.IsClosed,
-			CreatedAt: t.CreatedAt,
-			UpdatedAt: t.UpdatedAt,
+	resp := make([]dto.ThreadListItemResponse, 0, len(threads))
+	for _, item := range threads {
+		t := item.Thread
+		resp = append(resp, dto.ThreadListItemResponse{
+			ThreadCreateResponse: dto.ThreadCreateResponse{
+				ID:        t.ID,
+				SpoolID:   t.SpoolID,
+				Title:     t.Title,
+				Type:      t.Type,
+				IsClosed:  t.IsClosed,
+				StageMode: t.StageMode,
+				CreatedAt: t.CreatedAt,
+				UpdatedAt: t.UpdatedAt,
+			},
+			LastReadMessageID: item.Unread.LastReadMessageID,
+			UnreadCount:       item.Unread.UnreadCount,
+			MentionCount:      item.Unread.MentionCount,
 		})
 	}
 
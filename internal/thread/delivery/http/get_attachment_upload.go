@@ -0,0 +1,44 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// GetAttachmentUpload — сколько уже принято; после обрыва клиент продолжает с этого смещения
+func (h *ThreadHandler) GetAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s, err := h.messageUsecase.GetAttachmentUpload(r.Context(), usecase.AttachmentUploadInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+		UploadID: chi.URLParam(r, "uploadID"),
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to get attachment upload", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeAttachmentUpload(w, lib.StatusOK, s)
+}
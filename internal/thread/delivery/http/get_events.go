@@ -0,0 +1,95 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 500
+)
+
+// GetThreadEvents — всё, что произошло в треде после seq, по порядку.
+// Query: since (последний увиденный seq, по умолчанию 0), limit.
+func (h *ThreadHandler) GetThreadEvents(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var since uint64
+	if sStr := query.Get("since"); sStr != "" {
+		since, err = strconv.ParseUint(sStr, 10, 64)
+		if err != nil {
+			lib.WriteError(w, "invalid since", lib.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := defaultEventsLimit
+	if lStr := query.Get("limit"); lStr != "" {
+		if l, err := strconv.Atoi(lStr); err == nil && l > 0 {
+			limit = min(l, maxEventsLimit)
+		}
+	}
+
+	page, err := h.messageUsecase.GetThreadEvents(r.Context(), usecase.GetThreadEventsInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+		SinceSeq: since,
+		Limit:    limit,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to get thread events", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.ThreadEventsResponse{
+		Events:  make([]dto.ThreadEventResponse, 0, len(page.Events)),
+		LastSeq: page.LastSeq,
+		HasMore: page.HasMore,
+	}
+	for i := range page.Events {
+		ev := &page.Events[i]
+		item := dto.ThreadEventResponse{
+			Seq:       ev.Seq,
+			Type:      ev.Type,
+			MessageID: ev.MessageID,
+			Payload:   ev.Payload,
+			CreatedAt: ev.CreatedAt,
+		}
+		if ev.Message != nil {
+			msg := toMessageResponse(ev.Message)
+			item.Message = &msg
+		}
+		resp.Events = append(resp.Events, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode response", zap.Error(err))
+	}
+}
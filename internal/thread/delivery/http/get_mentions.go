@@ -0,0 +1,71 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+func (h *ThreadHandler) GetMentions(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := 50 // default limit
+	var beforeID uint
+
+	if lStr := r.URL.Query().Get("limit"); lStr != "" {
+		if l, err := strconv.Atoi(lStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	if bStr := r.URL.Query().Get("before"); bStr != "" {
+		b, err := strconv.ParseUint(bStr, 10, 64)
+		if err != nil {
+			lib.WriteError(w, "invalid before", lib.StatusBadRequest)
+			return
+		}
+		beforeID = uint(b)
+	}
+
+	mentions, err := h.messageUsecase.GetMentions(r.Context(), usecase.GetMentionsInput{
+		UserID:   userID,
+		BeforeID: beforeID,
+		Limit:    limit,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to get mentions", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := make([]dto.MentionResponse, 0, len(mentions))
+	for i := range mentions {
+		m := &mentions[i]
+		resp = append(resp, dto.MentionResponse{
+			ID:          m.ID,
+			Kind:        m.Kind,
+			SpoolID:     m.Thread.SpoolID,
+			ThreadID:    m.ThreadID,
+			ThreadTitle: m.Thread.Title,
+			Message:     toMessageResponse(&m.Message),
+			CreatedAt:   m.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode response", zap.Error(err))
+	}
+}
@@ -8,11 +8,19 @@ import (
 	"github.com/goccy/go-json"
 	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
 	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
 	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
 	"go.uber.org/zap"
 )
 
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 100
+)
+
+// GetMessages — история треда по курсору.
+// Query: limit, и не больше одного из cursor / before / after / around (id сообщения).
 func (h *ThreadHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	threadID64, err := strconv.ParseUint(idStr, 10, 64)
@@ -22,27 +30,50 @@ func (h *ThreadHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	}
 	threadID := uint(threadID64)
 
-	limit := 50 // default limit
-	offset := 0 // default offset
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
 
-	if lStr := r.URL.Query().Get("limit"); lStr != "" {
+	limit := defaultMessagesLimit
+	if lStr := query.Get("limit"); lStr != "" {
 		if l, err := strconv.Atoi(lStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-	if oStr := r.URL.Query().Get("offset"); oStr != "" {
-		if o, err := strconv.Atoi(oStr); err == nil && o >= 0 {
-			offset = o
+			limit = min(l, maxMessagesLimit)
 		}
 	}
 
 	input := usecase.GetMessagesInput{
+		UserID:   userID,
 		ThreadID: threadID,
 		Limit:    limit,
-		Offset:   offset,
+		Cursor:   query.Get("cursor"),
 	}
 
-	msgs, err := h.messageUsecase.GetMessages(r.Context(), input)
+	anchors := []struct {
+		name string
+		dst  *uint
+	}{
+		{"before", &input.BeforeID},
+		{"after", &input.AfterID},
+		{"around", &input.AroundID},
+	}
+	for _, a := range anchors {
+		v := query.Get(a.name)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			lib.WriteError(w, "invalid "+a.name+" message id", lib.StatusBadRequest)
+			return
+		}
+		*a.dst = uint(id)
+	}
+
+	page, err := h.messageUsecase.GetMessages(r.Context(), input)
 	if err != nil {
 		code, clientErr := apperrors.GetErrAndCodeToSend(err)
 		h.logger.Warn("failed to get messages", zap.Error(err))
@@ -50,16 +81,13 @@ func (h *ThreadHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := make([]dto.MessageResponse, 0, len(msgs))
-	for _, m := range msgs {
-		resp = append(resp, dto.MessageResponse{
-			ID:        m.ID,
-			ThreadID:  m.ThreadID,
-			Username:  m.User.Username,
-			Content:   m.Content,
-			CreatedAt: m.CreatedAt,
-			UpdatedAt: m.UpdatedAt,
-		})
+	resp := dto.GetMessagesResponse{
+		Messages:   make([]dto.MessageResponse, 0, len(page.Messages)),
+		PrevCursor: page.PrevCursor,
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Messages {
+		resp.Messages = append(resp.Messages, toMessageResponse(&page.Messages[i]))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
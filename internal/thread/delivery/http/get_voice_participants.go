@@ -0,0 +1,73 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// GetVoiceParticipants — кто сейчас в голосовой комнате треда и что публикует (прямо из LiveKit)
+func (h *ThreadHandler) GetVoiceParticipants(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	participants, err := h.roomUsecase.ListVoiceParticipants(r.Context(), usecase.VoiceRoomInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to list voice participants", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.VoiceParticipantsResponse{
+		ThreadID:     uint(threadID64),
+		Participants: make([]dto.VoiceParticipantResponse, 0, len(participants)),
+	}
+	for _, p := range participants {
+		voiceRole := "listener"
+		if p.CanPublish {
+			voiceRole = "speaker"
+		}
+		tracks := make([]dto.VoiceTrackResponse, 0, len(p.Tracks))
+		for _, t := range p.Tracks {
+			tracks = append(tracks, dto.VoiceTrackResponse{SID: t.SID, Source: t.Source, Muted: t.Muted})
+		}
+		resp.Participants = append(resp.Participants, dto.VoiceParticipantResponse{
+			UserID:   p.UserID,
+			Username: p.Identity,
+			SID:      p.SID,
+			JoinedAt: p.JoinedAt,
+			Tracks:   tracks,
+
+			VoiceRole:  voiceRole,
+			HandRaised: p.Attributes["hand_raised"] == "true",
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode voice participants response", zap.Error(err))
+	}
+}
@@ -47,8 +47,17 @@ func (h *ThreadHandler) GetVoiceToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	voiceRole := "listener"
+	if token.Speaker {
+		voiceRole = "speaker"
+	}
+
 	resp := dto.GetVoiceTokenResponse{
-		Token: token,
+		Token:     token.Token,
+		Role:      string(token.Role),
+		VoiceRole: voiceRole,
+		Stage:     token.Stage,
+		ExpiresAt: token.ExpiresAt,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,92 @@
+package deliveryHTTP
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// uploadOffsetHeader — сколько байт загрузки уже принято; в PATCH — с какого байта эта часть
+const uploadOffsetHeader = "Upload-Offset"
+
+// InitAttachmentUpload начинает докачиваемую загрузку большого вложения. Размер и формат проверяем сразу,
+// чтобы не принимать гигабайты, которые потом всё равно отвергнем
+func (h *ThreadHandler) InitAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.InitAttachmentUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		lib.WriteError(w, "invalid request body", lib.StatusBadRequest)
+		return
+	}
+	if req.Filename == "" || req.Size <= 0 {
+		lib.WriteError(w, "filename and size are required", lib.StatusBadRequest)
+		return
+	}
+
+	maxSize := h.fileConfig.Resumable.MaxSizeBytes
+	if req.Size > maxSize {
+		lib.WriteError(w, fmt.Sprintf("attachment size exceeds limit of %dMB", maxSize>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !h.fileConfig.IsAllowedFormatFor(attachmentFileType, req.Filename) {
+		allowedFormats := strings.Join(h.fileConfig.GetAllowedFormatsFor(attachmentFileType), ", ")
+		lib.WriteError(w, fmt.Sprintf("allowed formats: %s", allowedFormats), http.StatusBadRequest)
+		return
+	}
+
+	s, err := h.messageUsecase.InitAttachmentUpload(r.Context(), usecase.InitAttachmentUploadInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+		Filename: req.Filename,
+		Size:     req.Size,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to init attachment upload", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	h.writeAttachmentUpload(w, lib.StatusCreated, s)
+}
+
+func (h *ThreadHandler) writeAttachmentUpload(w http.ResponseWriter, status int, s *gdomain.UploadSession) {
+	resp := dto.AttachmentUploadResponse{
+		ID:        s.ID,
+		Filename:  s.Filename,
+		Size:      s.Size,
+		Offset:    s.Offset(),
+		PartSize:  s.PartSize,
+		ExpiresAt: s.ExpiresAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(resp.Offset, 10))
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode attachment upload response", zap.Error(err))
+	}
+}
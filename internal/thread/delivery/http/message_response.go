@@ -0,0 +1,58 @@
+package deliveryHTTP
+
+import (
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+)
+
+// toMessageResponse переводит доменное сообщение в ответ API
+func toMessageResponse(m *gdomain.Message) dto.MessageResponse {
+	return dto.MessageResponse{
+		ID:          m.ID,
+		ThreadID:    m.ThreadID,
+		Seq:         m.Seq,
+		Username:    m.User.Username,
+		Content:     m.Content,
+		ReplyTo:     toReplyResponse(m),
+		Attachments: toAttachmentsResponse(m.Payloads),
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+func toReplyResponse(m *gdomain.Message) *dto.MessageReplyResponse {
+	if m.ReplyToID == nil {
+		return nil
+	}
+	// Родителя нет или он удалён — отдаём "надгробие"
+	if m.ReplyTo == nil || m.ReplyTo.IsDeleted() {
+		return &dto.MessageReplyResponse{ID: *m.ReplyToID, Deleted: true}
+	}
+	return &dto.MessageReplyResponse{
+		ID:       m.ReplyTo.ID,
+		Username: m.ReplyTo.User.Username,
+		Content:  m.ReplyTo.Preview(gdomain.ReplyPreviewLen),
+	}
+}
+
+func toAttachmentsResponse(payloads []gdomain.MessagePayload) []dto.AttachmentResponse {
+	if len(payloads) == 0 {
+		return nil
+	}
+	res := make([]dto.AttachmentResponse, 0, len(payloads))
+	for i := range payloads {
+		res = append(res, toAttachmentResponse(&payloads[i]))
+	}
+	return res
+}
+
+func toAttachmentResponse(p *gdomain.MessagePayload) dto.AttachmentResponse {
+	return dto.AttachmentResponse{
+		ID:          p.ID,
+		Bucket:      p.Bucket,
+		FileLink:    p.FileLink,
+		Filename:    p.Filename,
+		ContentType: p.ContentType,
+		Size:        p.Size,
+	}
+}
@@ -0,0 +1,142 @@
+package deliveryHTTP
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// SearchMessages — GET /thread/search?q=...
+// Фильтры: spool_id, thread_id, author (username), from/to (RFC3339 или YYYY-MM-DD), has_attachment; limit/offset.
+func (h *ThreadHandler) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	input := usecase.SearchMessagesInput{
+		UserID: userID,
+		Query:  query.Get("q"),
+		Author: query.Get("author"),
+		Limit:  defaultSearchLimit,
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		lib.WriteError(w, "query is required", lib.StatusBadRequest)
+		return
+	}
+
+	if v := query.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 {
+			input.Limit = min(l, maxSearchLimit)
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if o, err := strconv.Atoi(v); err == nil && o >= 0 {
+			input.Offset = o
+		}
+	}
+
+	for name, dst := range map[string]*uint{"spool_id": &input.SpoolID, "thread_id": &input.ThreadID} {
+		if v := query.Get(name); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				lib.WriteError(w, "invalid "+name, lib.StatusBadRequest)
+				return
+			}
+			*dst = uint(id)
+		}
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, _, err := parseSearchTime(v)
+		if err != nil {
+			lib.WriteError(w, "invalid from", lib.StatusBadRequest)
+			return
+		}
+		input.From = &from
+	}
+	if v := query.Get("to"); v != "" {
+		to, dateOnly, err := parseSearchTime(v)
+		if err != nil {
+			lib.WriteError(w, "invalid to", lib.StatusBadRequest)
+			return
+		}
+		// to=2025-01-31 включает весь день
+		if dateOnly {
+			to = to.Add(24 * time.Hour)
+		}
+		input.To = &to
+	}
+
+	if v := query.Get("has_attachment"); v != "" {
+		has, err := strconv.ParseBool(v)
+		if err != nil {
+			lib.WriteError(w, "invalid has_attachment", lib.StatusBadRequest)
+			return
+		}
+		input.HasAttachment = &has
+	}
+
+	hits, err := h.messageUsecase.SearchMessages(r.Context(), input)
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to search messages", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.SearchMessagesResponse{
+		Results: make([]dto.SearchResultResponse, 0, len(hits)),
+	}
+	for i := range hits {
+		resp.Results = append(resp.Results, dto.SearchResultResponse{
+			SpoolID:     hits[i].SpoolID,
+			ThreadTitle: hits[i].ThreadTitle,
+			Snippet:     highlightSnippet(hits[i].Snippet),
+			Rank:        hits[i].Rank,
+			Message:     toMessageResponse(&hits[i].Message),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Warn("failed to encode search response", zap.Error(err))
+	}
+}
+
+// parseSearchTime принимает RFC3339 или просто дату; второй результат — была ли это дата без времени
+func parseSearchTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	return t, true, err
+}
+
+// highlightSnippet экранирует текст сообщения и только потом ставит <mark>
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(
+		gdomain.SearchHighlightStart, "<mark>",
+		gdomain.SearchHighlightStop, "</mark>",
+	).Replace(escaped)
+}
@@ -42,10 +42,12 @@ func (h *ThreadHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	input := usecase.SendMessageInput{
-		ThreadID: threadID,
-		UserID:   userID,
-		Username: username,
-		Content:  req.Content,
+		ThreadID:      threadID,
+		UserID:        userID,
+		Username:      username,
+		Content:       req.Content,
+		ReplyToID:     req.ReplyToID,
+		AttachmentIDs: req.AttachmentIDs,
 	}
 
 	msg, err := h.messageUsecase.SendMessage(r.Context(), input)
@@ -57,14 +59,7 @@ func (h *ThreadHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := dto.SendMessageResponse{
-		Message: dto.MessageResponse{
-			ID:        msg.ID,
-			ThreadID:  msg.ThreadID,
-			Username:  msg.User.Username,
-			Content:   msg.Content,
-			CreatedAt: msg.CreatedAt,
-			UpdatedAt: msg.UpdatedAt,
-		},
+		Message: toMessageResponse(msg),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -2,29 +2,36 @@ package deliveryHTTP
 
 import (
 	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/config"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
 	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
 	"go.uber.org/zap"
 )
 
 type ThreadHandler struct {
-	threadUsecase  usecase.ThreadUsecaseInterface
-	messageUsecase *usecase.MessageUsecase
-	roomUsecase    usecase.RoomUsecaseInterface
-	logger         *zap.Logger
+	threadUsecase    usecase.ThreadUsecaseInterface
+	messageUsecase   *usecase.MessageUsecase
+	roomUsecase      usecase.RoomUsecaseInterface
+	recordingUsecase usecase.RecordingUsecaseInterface
+	logger           *zap.Logger
+	fileConfig       *config.FileConfig
 }
 
 func NewThreadHandler(
 	threadUC usecase.ThreadUsecaseInterface,
 	messageUC *usecase.MessageUsecase,
 	roomUC usecase.RoomUsecaseInterface,
+	recordingUC usecase.RecordingUsecaseInterface,
 	logger *zap.Logger,
+	fileConfig *config.FileConfig,
 ) *ThreadHandler {
 	return &ThreadHandler{
-		threadUsecase:  threadUC,
-		messageUsecase: messageUC,
-		roomUsecase:    roomUC,
-		logger:         logger,
+		threadUsecase:    threadUC,
+		messageUsecase:   messageUC,
+		roomUsecase:      roomUC,
+		recordingUsecase: recordingUC,
+		logger:           logger,
+		fileConfig:       fileConfig,
 	}
 }
 
@@ -38,9 +45,30 @@ func (h *ThreadHandler) Routes(r chi.Router, authenticator auth.AuthenticatorInt
 		r.Post("/invite", h.InviteToThread)
 		r.Post("/sfu/token", h.GetVoiceToken)
 		r.Put("/update", h.Update)
+		r.Get("/mentions", h.GetMentions)
+		r.Get("/search", h.SearchMessages)
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/messages", h.GetMessages)
+			r.Get("/events", h.GetThreadEvents)
 			r.Post("/messages", h.SendMessage)
+			r.Post("/attachments", h.UploadAttachment)
+			r.Post("/attachments/uploads", h.InitAttachmentUpload)
+			r.Get("/attachments/uploads/{uploadID}", h.GetAttachmentUpload)
+			r.Patch("/attachments/uploads/{uploadID}", h.UploadAttachmentPart)
+			r.Post("/attachments/uploads/{uploadID}/complete", h.CompleteAttachmentUpload)
+			r.Delete("/attachments/uploads/{uploadID}", h.AbortAttachmentUpload)
+			r.Post("/read", h.MarkRead)
+			r.Post("/typing", h.Typing)
+			r.Get("/voice/participants", h.GetVoiceParticipants)
+			r.Post("/voice/mute", h.MuteVoiceParticipant)
+			r.Post("/voice/kick", h.KickVoiceParticipant)
+			r.Delete("/voice", h.EndVoiceRoom)
+			r.Put("/voice/stage", h.SetStageMode)
+			r.Post("/voice/hand", h.RaiseHand)
+			r.Post("/voice/promote", h.PromoteVoiceParticipant)
+			r.Post("/voice/demote", h.DemoteVoiceParticipant)
+			r.Post("/voice/recording", h.StartRecording)
+			r.Delete("/voice/recording", h.StopRecording)
 		})
 		r.Get("/ws/token", h.GetSubscribeToken)
 	})
@@ -0,0 +1,99 @@
+package deliveryHTTP
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/goccy/go-json"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/delivery/dto"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+const attachmentFileType = "message_attachment"
+
+// UploadAttachment загружает файл заранее; полученный id потом передаётся в attachment_ids при отправке сообщения
+func (h *ThreadHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// 1. Парсим multipart/form-data
+	maxSize := h.fileConfig.GetMaxSize(attachmentFileType)
+	if err := r.ParseMultipartForm(maxSize); err != nil {
+		lib.WriteError(w, "failed to parse form data", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if r.MultipartForm != nil {
+			r.MultipartForm.RemoveAll()
+		}
+	}()
+
+	// 2. Достаём файл и проверяем размер и формат
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		lib.WriteError(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if !h.fileConfig.ValidateSize(attachmentFileType, fileHeader.Size) {
+		lib.WriteError(w, fmt.Sprintf("attachment size exceeds limit of %dMB", maxSize>>20), http.StatusBadRequest)
+		return
+	}
+
+	if !h.fileConfig.IsAllowedFormatFor(attachmentFileType, fileHeader.Filename) {
+		allowedFormats := strings.Join(h.fileConfig.GetAllowedFormatsFor(attachmentFileType), ", ")
+		lib.WriteError(w, fmt.Sprintf("allowed formats: %s", allowedFormats), http.StatusBadRequest)
+		return
+	}
+
+	// тип берём из содержимого: расширению верить нельзя
+	contentType, err := h.fileConfig.DetectContentType(file, fileHeader.Filename)
+	if err != nil {
+		lib.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 3. Сохраняем
+	payload, err := h.messageUsecase.UploadAttachment(r.Context(), usecase.UploadAttachmentInput{
+		UserID:      userID,
+		ThreadID:    uint(threadID64),
+		File:        file,
+		Size:        fileHeader.Size,
+		Filename:    fileHeader.Filename,
+		ContentType: contentType,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to upload attachment", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	resp := dto.UploadAttachmentResponse{
+		Attachment: toAttachmentResponse(payload),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(lib.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode upload attachment response", zap.Error(err))
+	}
+}
@@ -0,0 +1,58 @@
+package deliveryHTTP
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/onionfriend2004/threadbook_backend/internal/apperrors"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/middleware/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/usecase"
+	"go.uber.org/zap"
+)
+
+// UploadAttachmentPart принимает следующую часть загрузки. Тело — сырые байты части,
+// Upload-Offset — с какого байта она. Не совпало — 409, текущее смещение отдаёт GET
+func (h *ThreadHandler) UploadAttachmentPart(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	threadID64, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		lib.WriteError(w, "invalid thread id", lib.StatusBadRequest)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		lib.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		lib.WriteError(w, "invalid Upload-Offset header", lib.StatusBadRequest)
+		return
+	}
+	// размер части сверяется с ожидаемым до чтения тела, так что chunked не принимаем
+	if r.ContentLength <= 0 {
+		lib.WriteError(w, "Content-Length is required", http.StatusLengthRequired)
+		return
+	}
+
+	s, err := h.messageUsecase.UploadAttachmentPart(r.Context(), usecase.AttachmentPartInput{
+		UserID:   userID,
+		ThreadID: uint(threadID64),
+		UploadID: chi.URLParam(r, "uploadID"),
+		Offset:   offset,
+		Body:     r.Body,
+		Size:     r.ContentLength,
+	})
+	if err != nil {
+		code, clientErr := apperrors.GetErrAndCodeToSend(err)
+		h.logger.Warn("failed to upload attachment part", zap.Error(err))
+		lib.WriteError(w, clientErr.Error(), code)
+		return
+	}
+
+	h.writeAttachmentUpload(w, lib.StatusOK, s)
+}
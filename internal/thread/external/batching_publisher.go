@@ -0,0 +1,115 @@
+package external
+
+import (
+	"context"
+	"time"
+)
+
+type publishRequest struct {
+	cmds   []PublishCommand
+	result chan []error
+}
+
+// BatchingPublisher копит публикации из параллельных запросов и отправляет их одним вызовом inner.
+// Первый запрос ждёт не дольше maxDelay, пачка уходит раньше, если набралось maxBatch команд.
+// Работает, пока крутится Run; после его выхода Publish сразу отвечает ErrPublisherClosed.
+type BatchingPublisher struct {
+	inner    PublisherInterface
+	maxBatch int
+	maxDelay time.Duration
+	requests chan publishRequest
+	done     chan struct{} // закрывается, когда Run вышел
+}
+
+func NewBatchingPublisher(inner PublisherInterface, maxBatch int, maxDelay time.Duration) *BatchingPublisher {
+	if maxBatch <= 0 {
+		maxBatch = defaultPipeSize
+	}
+	return &BatchingPublisher{
+		inner:    inner,
+		maxBatch: maxBatch,
+		maxDelay: maxDelay,
+		requests: make(chan publishRequest, maxBatch),
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *BatchingPublisher) Publish(ctx context.Context, cmds []PublishCommand) []error {
+	if len(cmds) == 0 {
+		return nil
+	}
+	req := publishRequest{cmds: cmds, result: make(chan []error, 1)}
+
+	select {
+	case <-p.done:
+		return fillErrors(len(cmds), ErrPublisherClosed)
+	default:
+	}
+
+	select {
+	case p.requests <- req:
+	case <-p.done:
+		return fillErrors(len(cmds), ErrPublisherClosed)
+	case <-ctx.Done():
+		return fillErrors(len(cmds), ctx.Err())
+	}
+
+	select {
+	case errs := <-req.result:
+		return errs
+	case <-p.done:
+		// Run вышел, не взяв запрос, — ответа не будет
+		return fillErrors(len(cmds), ErrPublisherClosed)
+	case <-ctx.Done():
+		// Команды уже в пачке и, скорее всего, уйдут — просто не ждём ответа
+		return fillErrors(len(cmds), ctx.Err())
+	}
+}
+
+// Run собирает и отправляет пачки до отмены ctx
+func (p *BatchingPublisher) Run(ctx context.Context) {
+	defer close(p.done)
+	for {
+		var first publishRequest
+		select {
+		case <-ctx.Done():
+			return
+		case first = <-p.requests:
+		}
+
+		batch := []publishRequest{first}
+		size := len(first.cmds)
+		timer := time.NewTimer(p.maxDelay)
+	collect:
+		for size < p.maxBatch {
+			select {
+			case req := <-p.requests:
+				batch = append(batch, req)
+				size += len(req.cmds)
+			case <-timer.C:
+				break collect
+			case <-ctx.Done():
+				break collect
+			}
+		}
+		timer.Stop()
+
+		p.flush(ctx, batch, size)
+	}
+}
+
+func (p *BatchingPublisher) flush(ctx context.Context, batch []publishRequest, size int) {
+	cmds := make([]PublishCommand, 0, size)
+	for _, req := range batch {
+		cmds = append(cmds, req.cmds...)
+	}
+
+	// Запросы уже ушли от своих вызывающих, отмена приложения не должна их рвать на середине
+	errs := p.inner.Publish(context.WithoutCancel(ctx), cmds)
+
+	offset := 0
+	for _, req := range batch {
+		req.result <- errs[offset : offset+len(req.cmds)]
+		offset += len(req.cmds)
+	}
+}
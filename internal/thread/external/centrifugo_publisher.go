@@ -0,0 +1,99 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/centrifugal/gocent/v3"
+	"github.com/goccy/go-json"
+)
+
+// defaultPipeSize — сколько команд максимум кладём в один HTTP-запрос к Centrifugo
+const defaultPipeSize = 100
+
+type centrifugoPublisher struct {
+	client   *gocent.Client
+	pipeSize int
+}
+
+// NewCentrifugoPublisher шлёт команды через pipe: пачка команд — один HTTP-запрос
+func NewCentrifugoPublisher(client *gocent.Client, pipeSize int) PublisherInterface {
+	if pipeSize <= 0 {
+		pipeSize = defaultPipeSize
+	}
+	return &centrifugoPublisher{
+		client:   client,
+		pipeSize: pipeSize,
+	}
+}
+
+func (p *centrifugoPublisher) Publish(ctx context.Context, cmds []PublishCommand) []error {
+	errs := make([]error, 0, len(cmds))
+	for start := 0; start < len(cmds); start += p.pipeSize {
+		end := min(start+p.pipeSize, len(cmds))
+		errs = append(errs, p.sendPipe(ctx, cmds[start:end])...)
+	}
+	return errs
+}
+
+func (p *centrifugoPublisher) sendPipe(ctx context.Context, cmds []PublishCommand) []error {
+	pipe := p.client.Pipe()
+	for _, cmd := range cmds {
+		opts := []gocent.PublishOption{gocent.WithSkipHistory(cmd.SkipHistory)}
+		if cmd.IdempotencyKey != "" {
+			opts = append(opts, gocent.WithIdempotencyKey(cmd.IdempotencyKey))
+		}
+
+		var err error
+		if len(cmd.Channels) == 1 {
+			err = pipe.AddPublish(cmd.Channels[0], cmd.Data, opts...)
+		} else {
+			err = pipe.AddBroadcast(cmd.Channels, cmd.Data, opts...)
+		}
+		if err != nil {
+			return fillErrors(len(cmds), fmt.Errorf("build centrifugo pipe: %w", err))
+		}
+	}
+
+	replies, err := p.client.SendPipe(ctx, pipe)
+	if err != nil {
+		return fillErrors(len(cmds), fmt.Errorf("centrifugo publish failed: %w", err))
+	}
+
+	errs := make([]error, len(cmds))
+	for i := range errs {
+		// ответов меньше, чем команд: про остальные ничего не знаем — значит, не доставлены
+		if i >= len(replies) {
+			errs[i] = ErrMissingReply
+			continue
+		}
+		reply := replies[i]
+		if reply.Error != nil {
+			errs[i] = fmt.Errorf("centrifugo publish failed: %w", reply.Error)
+			continue
+		}
+		if len(cmds[i].Channels) > 1 {
+			errs[i] = broadcastError(reply.Result)
+		}
+	}
+	return errs
+}
+
+// broadcastError достаёт ошибки по отдельным каналам broadcast'а
+func broadcastError(result []byte) error {
+	var res gocent.BroadcastResult
+	if err := json.Unmarshal(result, &res); err != nil {
+		return fmt.Errorf("decode broadcast result: %w", err)
+	}
+	var errs []error
+	for _, resp := range res.Responses {
+		if resp.Error != nil {
+			errs = append(errs, resp.Error)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("centrifugo broadcast failed: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -6,19 +6,18 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/centrifugal/gocent/v3"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type websocketRepo struct {
-	client      *gocent.Client
+	publisher   PublisherInterface
 	secret      string // JWT secret
 	tokenIssuer string
 }
 
-func NewWebsocketRepo(client *gocent.Client, secret, tokenIssuer string) WebsocketRepoInterface {
+func NewWebsocketRepo(publisher PublisherInterface, secret, tokenIssuer string) WebsocketRepoInterface {
 	return &websocketRepo{
-		client:      client,
+		publisher:   publisher,
 		secret:      secret,
 		tokenIssuer: tokenIssuer,
 	}
@@ -34,34 +33,47 @@ func (r *websocketRepo) threadChannel(threadID uint) string {
 	return fmt.Sprintf("thread#%d", threadID)
 }
 
+// spool channel: "spool#{id}"
+func (r *websocketRepo) spoolChannel(spoolID uint) string {
+	return fmt.Sprintf("spool#%d", spoolID)
+}
+
 func (r *websocketRepo) PublishToUser(ctx context.Context, userID uint, data any) error {
-	channel := r.userChannel(userID)
+	return r.publish(ctx, PublishCommand{Channels: []string{r.userChannel(userID)}}, data)
+}
 
-	payload, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("marshal publish data: %w", err)
+// PublishToUsers — одно событие в личные каналы пачки пользователей одним broadcast'ом
+func (r *websocketRepo) PublishToUsers(ctx context.Context, userIDs []uint, data any) error {
+	if len(userIDs) == 0 {
+		return nil
 	}
-
-	if _, err := r.client.Publish(ctx, channel, payload); err != nil {
-		return fmt.Errorf("centrifugo publish failed: %w", err)
+	channels := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		channels = append(channels, r.userChannel(id))
 	}
-
-	return nil
+	return r.publish(ctx, PublishCommand{Channels: channels}, data)
 }
 
 func (r *websocketRepo) PublishToThread(ctx context.Context, threadID uint, data any) error {
-	channel := r.threadChannel(threadID)
+	return r.publish(ctx, PublishCommand{Channels: []string{r.threadChannel(threadID)}}, data)
+}
+
+func (r *websocketRepo) PublishToSpool(ctx context.Context, spoolID uint, data any) error {
+	return r.publish(ctx, PublishCommand{Channels: []string{r.spoolChannel(spoolID)}}, data)
+}
 
+func (r *websocketRepo) PublishToThreadEphemeral(ctx context.Context, threadID uint, data any) error {
+	return r.publish(ctx, PublishCommand{Channels: []string{r.threadChannel(threadID)}, SkipHistory: true}, data)
+}
+
+func (r *websocketRepo) publish(ctx context.Context, cmd PublishCommand, data any) error {
 	payload, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("marshal publish data: %w", err)
 	}
+	cmd.Data = payload
 
-	if _, err := r.client.Publish(ctx, channel, payload); err != nil {
-		return fmt.Errorf("centrifugo publish failed: %w", err)
-	}
-
-	return nil
+	return r.publisher.Publish(ctx, []PublishCommand{cmd})[0]
 }
 
 // CONNECT JWT
@@ -0,0 +1,23 @@
+package external
+
+import "context"
+
+// EgressInterface — запись комнат SFU в файл
+type EgressInterface interface {
+	// StartRoomRecording пишет комнату целиком в objectKey бакета записей, возвращает id egress
+	StartRoomRecording(ctx context.Context, roomName, objectKey string) (string, error)
+	StopEgress(ctx context.Context, egressID string) error
+	// IsEgressActive — egress ещё пишет или хотя бы дописывает файл
+	IsEgressActive(ctx context.Context, egressID string) (bool, error)
+	// ContentType — что за файл получится на выходе
+	ContentType() string
+}
+
+// EgressS3Target — куда egress заливает файлы. Это наш MinIO, но адрес — как его видит контейнер egress
+type EgressS3Target struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+}
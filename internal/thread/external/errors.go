@@ -8,4 +8,15 @@ var (
 	ErrPermissionDenied = errors.New("permission denied")
 	ErrUserNoAccess     = errors.New("user not owner")
 	ErrUserNotFound     = errors.New("user not found")
+
+	ErrPayloadNotPending = errors.New("attachment is already attached or not found")
+
+	ErrRoomNotFound        = errors.New("room not found")
+	ErrParticipantNotFound = errors.New("participant not found in room")
+
+	ErrRecordingExists = errors.New("thread already has an active recording")
+	ErrEgressNotActive = errors.New("egress is not active")
+
+	ErrPublisherClosed = errors.New("publisher is stopped")
+	ErrMissingReply    = errors.New("missing centrifugo reply")
 )
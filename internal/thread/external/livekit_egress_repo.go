@@ -0,0 +1,76 @@
+package external
+
+import (
+	"context"
+	"errors"
+
+	livekit "github.com/livekit/protocol/livekit"
+	lksdk "github.com/livekit/server-sdk-go/v2"
+	"github.com/twitchtv/twirp"
+)
+
+type LiveKitEgressRepo struct {
+	client    *lksdk.EgressClient
+	target    EgressS3Target
+	audioOnly bool
+}
+
+func NewLiveKitEgressRepo(client *lksdk.EgressClient, target EgressS3Target, audioOnly bool) EgressInterface {
+	return &LiveKitEgressRepo{client: client, target: target, audioOnly: audioOnly}
+}
+
+func (r *LiveKitEgressRepo) StartRoomRecording(ctx context.Context, roomName, objectKey string) (string, error) {
+	fileType := livekit.EncodedFileType_MP4
+	if r.audioOnly {
+		fileType = livekit.EncodedFileType_OGG
+	}
+
+	info, err := r.client.StartRoomCompositeEgress(ctx, &livekit.RoomCompositeEgressRequest{
+		RoomName:  roomName,
+		AudioOnly: r.audioOnly,
+		FileOutputs: []*livekit.EncodedFileOutput{{
+			FileType: fileType,
+			Filepath: objectKey,
+			Output: &livekit.EncodedFileOutput_S3{S3: &livekit.S3Upload{
+				AccessKey:      r.target.AccessKey,
+				Secret:         r.target.SecretKey,
+				Region:         r.target.Region,
+				Endpoint:       r.target.Endpoint,
+				Bucket:         r.target.Bucket,
+				ForcePathStyle: true, // MinIO не умеет виртуальные хосты бакетов
+			}},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return info.GetEgressId(), nil
+}
+
+// StopEgress — egress уже завершился или его нет: ErrEgressNotActive, итог всё равно придёт вебхуком
+func (r *LiveKitEgressRepo) StopEgress(ctx context.Context, egressID string) error {
+	_, err := r.client.StopEgress(ctx, &livekit.StopEgressRequest{EgressId: egressID})
+	if err != nil {
+		var tErr twirp.Error
+		if errors.As(err, &tErr) && (tErr.Code() == twirp.NotFound || tErr.Code() == twirp.FailedPrecondition) {
+			return ErrEgressNotActive
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *LiveKitEgressRepo) IsEgressActive(ctx context.Context, egressID string) (bool, error) {
+	res, err := r.client.ListEgress(ctx, &livekit.ListEgressRequest{EgressId: egressID, Active: true})
+	if err != nil {
+		return false, err
+	}
+	return len(res.GetItems()) > 0, nil
+}
+
+func (r *LiveKitEgressRepo) ContentType() string {
+	if r.audioOnly {
+		return "audio/ogg"
+	}
+	return "video/mp4"
+}
@@ -2,9 +2,14 @@ package external
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	livekit "github.com/livekit/protocol/livekit"
 	lksdk "github.com/livekit/server-sdk-go/v2"
+	"github.com/twitchtv/twirp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -39,3 +44,153 @@ func (r *LiveKitRepo) EnsureRoom(ctx context.Context, roomName string) error {
 	}
 	return nil
 }
+
+// ListParticipants — кто сейчас в комнате. Комнаты нет — значит и участников нет
+func (r *LiveKitRepo) ListParticipants(ctx context.Context, roomName string) ([]SFUParticipant, error) {
+	resp, err := r.client.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: roomName})
+	if err != nil {
+		if isTwirpNotFound(err) {
+			return []SFUParticipant{}, nil
+		}
+		return nil, err
+	}
+
+	res := make([]SFUParticipant, 0, len(resp.GetParticipants()))
+	for _, p := range resp.GetParticipants() {
+		res = append(res, toSFUParticipant(p))
+	}
+	return res, nil
+}
+
+func (r *LiveKitRepo) GetParticipant(ctx context.Context, roomName, identity string) (*SFUParticipant, error) {
+	p, err := r.client.GetParticipant(ctx, &livekit.RoomParticipantIdentity{Room: roomName, Identity: identity})
+	if err != nil {
+		if isTwirpNotFound(err) {
+			return nil, ErrParticipantNotFound
+		}
+		return nil, err
+	}
+	res := toSFUParticipant(p)
+	return &res, nil
+}
+
+func (r *LiveKitRepo) UpdateParticipant(ctx context.Context, roomName, identity string, perm *SFUPermission, attrs map[string]string) error {
+	req := &livekit.UpdateParticipantRequest{
+		Room:       roomName,
+		Identity:   identity,
+		Attributes: attrs,
+	}
+	if perm != nil {
+		req.Permission = &livekit.ParticipantPermission{
+			CanSubscribe:      perm.CanSubscribe,
+			CanPublish:        perm.CanPublish,
+			CanPublishData:    perm.CanPublishData,
+			CanPublishSources: toTrackSources(perm.Sources),
+		}
+	}
+
+	if _, err := r.client.UpdateParticipant(ctx, req); err != nil {
+		if isTwirpNotFound(err) {
+			return ErrParticipantNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *LiveKitRepo) MuteParticipant(ctx context.Context, roomName, identity string) (int, error) {
+	p, err := r.client.GetParticipant(ctx, &livekit.RoomParticipantIdentity{Room: roomName, Identity: identity})
+	if err != nil {
+		if isTwirpNotFound(err) {
+			return 0, ErrParticipantNotFound
+		}
+		return 0, err
+	}
+
+	muted := 0
+	for _, t := range p.GetTracks() {
+		if t.GetMuted() {
+			continue
+		}
+		_, err := r.client.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+			Room:     roomName,
+			Identity: identity,
+			TrackSid: t.GetSid(),
+			Muted:    true,
+		})
+		if err != nil {
+			// участник мог успеть отпубликовать трек — не повод бросать остальные
+			if isTwirpNotFound(err) {
+				continue
+			}
+			return muted, err
+		}
+		muted++
+	}
+	return muted, nil
+}
+
+func (r *LiveKitRepo) RemoveParticipant(ctx context.Context, roomName, identity string) error {
+	_, err := r.client.RemoveParticipant(ctx, &livekit.RoomParticipantIdentity{Room: roomName, Identity: identity})
+	if err != nil {
+		if isTwirpNotFound(err) {
+			return ErrParticipantNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteRoom выкидывает всех и закрывает комнату
+func (r *LiveKitRepo) DeleteRoom(ctx context.Context, roomName string) error {
+	_, err := r.client.DeleteRoom(ctx, &livekit.DeleteRoomRequest{Room: roomName})
+	if err != nil {
+		if isTwirpNotFound(err) {
+			return ErrRoomNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func toSFUParticipant(p *livekit.ParticipantInfo) SFUParticipant {
+	res := SFUParticipant{
+		Identity: p.GetIdentity(),
+		SID:      p.GetSid(),
+		JoinedAt: time.Unix(p.GetJoinedAt(), 0),
+		Tracks:   make([]SFUTrack, 0, len(p.GetTracks())),
+
+		CanPublish: p.GetPermission().GetCanPublish(),
+		Attributes: p.GetAttributes(),
+	}
+	if id, err := strconv.ParseUint(p.GetAttributes()["user_id"], 10, 64); err == nil {
+		res.UserID = uint(id)
+	}
+	for _, t := range p.GetTracks() {
+		res.Tracks = append(res.Tracks, SFUTrack{
+			SID:    t.GetSid(),
+			Source: strings.ToLower(t.GetSource().String()),
+			Muted:  t.GetMuted(),
+		})
+	}
+	return res
+}
+
+// toTrackSources — имена источников из конфига в enum LiveKit, незнакомые пропускаем
+func toTrackSources(sources []string) []livekit.TrackSource {
+	res := make([]livekit.TrackSource, 0, len(sources))
+	for _, s := range sources {
+		v, ok := livekit.TrackSource_value[strings.ToUpper(s)]
+		if !ok || v == int32(livekit.TrackSource_UNKNOWN) {
+			continue
+		}
+		res = append(res, livekit.TrackSource(v))
+	}
+	return res
+}
+
+// RoomServiceClient ходит в LiveKit по Twirp, так что и ошибки оттуда twirp'овые
+func isTwirpNotFound(err error) bool {
+	var tErr twirp.Error
+	return errors.As(err, &tErr) && tErr.Code() == twirp.NotFound
+}
@@ -2,15 +2,43 @@ package external
 
 import (
 	"context"
+	"time"
 
 	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
 )
 
 type MessageRepoInterface interface {
 	Create(ctx context.Context, m *gdomain.Message) error
+	// CreateWithPayloads — сообщение, вложения и упоминания; звать внутри транзакции dbtx
 	CreateWithPayloads(ctx context.Context, m *gdomain.Message) error
-	GetByThreadID(ctx context.Context, threadID uint, limit, offset int) ([]gdomain.Message, error)
+	GetBeforeID(ctx context.Context, threadID, beforeID uint, limit int) ([]gdomain.Message, error)
+	GetAfterID(ctx context.Context, threadID, afterID uint, limit int) ([]gdomain.Message, error)
 	GetByID(ctx context.Context, id uint) (*gdomain.Message, error)
 	DeleteByID(ctx context.Context, id uint) error
 	CountByThreadID(ctx context.Context, threadID uint) (int64, error)
+
+	CreatePayload(ctx context.Context, p *gdomain.MessagePayload) error
+	GetPendingPayloads(ctx context.Context, ids []uint, uploaderID, threadID uint) ([]gdomain.MessagePayload, error)
+
+	GetMentionsByUserID(ctx context.Context, userID, beforeID uint, limit int) ([]gdomain.MessageMention, error)
+
+	Search(ctx context.Context, filter MessageSearchFilter) ([]gdomain.MessageSearchHit, error)
+
+	AppendThreadEvent(ctx context.Context, ev *gdomain.ThreadEvent) error
+	// GetThreadEventsSince — до limit записей журнала с seq > sinceSeq по возрастанию, с подгруженными сообщениями
+	GetThreadEventsSince(ctx context.Context, threadID uint, sinceSeq uint64, limit int) ([]gdomain.ThreadEvent, error)
+}
+
+// MessageSearchFilter — параметры полнотекстового поиска; нулевые значения фильтров не применяются
+type MessageSearchFilter struct {
+	UserID        uint // кто ищет: видит только треды, где он участник
+	Query         string
+	SpoolID       uint
+	ThreadID      uint
+	Author        string // username автора
+	From          *time.Time
+	To            *time.Time
+	HasAttachment *bool
+	Limit         int
+	Offset        int
 }
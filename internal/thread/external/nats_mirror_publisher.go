@@ -0,0 +1,60 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// MirrorMessage — то, что уходит в NATS на каждый канал: ID события (ключ идемпотентности) и его JSON как есть
+type MirrorMessage struct {
+	ID   string          `json:"id,omitempty"`
+	Data json.RawMessage `json:"data"`
+}
+
+// MirrorSubject — NATS-субъект для канала Centrifugo: "<prefix>.user#1", "<prefix>.thread#2"
+func MirrorSubject(prefix, channel string) string {
+	return prefix + "." + channel
+}
+
+// natsMirrorPublisher — после успешной публикации в inner дублирует её в NATS по субъекту на канал.
+// Так события получают все инстансы, и каждый раздаёт их своим SSE-клиентам.
+type natsMirrorPublisher struct {
+	inner  PublisherInterface
+	nc     *nats.Conn
+	prefix string
+	logger *zap.Logger
+}
+
+func NewNatsMirrorPublisher(inner PublisherInterface, nc *nats.Conn, prefix string, logger *zap.Logger) PublisherInterface {
+	return &natsMirrorPublisher{
+		inner:  inner,
+		nc:     nc,
+		prefix: prefix,
+		logger: logger,
+	}
+}
+
+// Publish зеркалит только доставленные команды: SSE видит ровно то же, что и Centrifugo,
+// а повтор релея после ошибки придёт с тем же ID.
+func (p *natsMirrorPublisher) Publish(ctx context.Context, cmds []PublishCommand) []error {
+	errs := p.inner.Publish(ctx, cmds)
+	for i, cmd := range cmds {
+		if errs[i] != nil {
+			continue
+		}
+		msg, err := json.Marshal(MirrorMessage{ID: cmd.IdempotencyKey, Data: cmd.Data})
+		if err != nil {
+			p.logger.Warn("failed to marshal mirror message", zap.Error(err))
+			continue
+		}
+		for _, channel := range cmd.Channels {
+			if err := p.nc.Publish(MirrorSubject(p.prefix, channel), msg); err != nil {
+				p.logger.Warn("failed to mirror publication to NATS", zap.String("channel", channel), zap.Error(err))
+			}
+		}
+	}
+	return errs
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 
 	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type messageRepo struct {
@@ -16,11 +18,26 @@ func NewMessageRepo(db *gorm.DB) MessageRepoInterface {
 	return &messageRepo{db: db}
 }
 
+// withRelations подгружает автора, вложения и сообщение, на которое ответили.
+// ReplyTo грузим Unscoped, чтобы удалённый родитель превратился в "надгробие", а не пропал.
+func withRelations(q *gorm.DB) *gorm.DB {
+	return q.
+		Preload("User").
+		Preload("Payloads", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("ReplyTo", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("ReplyTo.User")
+}
+
+// conn — текущая транзакция из ctx (см. dbtx), иначе обычное подключение
+func (r *messageRepo) conn(ctx context.Context) *gorm.DB {
+	return dbtx.DB(ctx, r.db)
+}
+
 func (r *messageRepo) Create(ctx context.Context, m *gdomain.Message) error {
 	if m == nil {
 		return fmt.Errorf("message is nil")
 	}
-	return r.db.WithContext(ctx).Create(m).Error
+	return r.conn(ctx).Omit(clause.Associations).Create(m).Error
 }
 
 func (r *messageRepo) CreateWithPayloads(ctx context.Context, m *gdomain.Message) error {
@@ -28,49 +45,236 @@ func (r *messageRepo) CreateWithPayloads(ctx context.Context, m *gdomain.Message
 		return fmt.Errorf("message is nil")
 	}
 
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(m).Error; err != nil {
-			return err
-		}
-		// Если payloads есть — установить MessageID и вставить
-		if len(m.Payloads) > 0 {
-			for i := range m.Payloads {
-				m.Payloads[i].MessageID = m.ID
+	// Транзакцию открывает вызывающий (dbtx): вместе с сообщением в ней seq и события outbox
+	tx := r.conn(ctx)
+	// Связи не сохраняем автоматически: payloads и упоминания вставляем сами ниже
+	if err := tx.Omit(clause.Associations).Create(m).Error; err != nil {
+		return err
+	}
+	// Заранее загруженные вложения (с ID) привязываем, новые — вставляем
+	if len(m.Payloads) > 0 {
+		var pendingIDs []uint
+		for i := range m.Payloads {
+			m.Payloads[i].MessageID = &m.ID
+			if m.Payloads[i].ID != 0 {
+				pendingIDs = append(pendingIDs, m.Payloads[i].ID)
+				continue
 			}
-			if err := tx.Create(&m.Payloads).Error; err != nil {
+			if err := tx.Omit(clause.Associations).Create(&m.Payloads[i]).Error; err != nil {
 				return err
 			}
 		}
-		return nil
-	})
+		if len(pendingIDs) > 0 {
+			// message_id IS NULL не даёт прикрепить одно вложение к двум сообщениям
+			res := tx.Model(&gdomain.MessagePayload{}).
+				Where("id IN ? AND message_id IS NULL AND uploader_id = ?", pendingIDs, m.UserID).
+				Update("message_id", m.ID)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected != int64(len(pendingIDs)) {
+				return ErrPayloadNotPending
+			}
+		}
+	}
+	if len(m.Mentions) > 0 {
+		for i := range m.Mentions {
+			m.Mentions[i].MessageID = m.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&m.Mentions).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (r *messageRepo) GetByThreadID(ctx context.Context, threadID uint, limit, offset int) ([]gdomain.Message, error) {
+// GetBeforeID возвращает до limit сообщений с id < beforeID (beforeID = 0 — самые свежие).
+// Выбираем с конца по индексу (thread_id, id), а отдаём по возрастанию id.
+func (r *messageRepo) GetBeforeID(ctx context.Context, threadID, beforeID uint, limit int) ([]gdomain.Message, error) {
 	var msgs []gdomain.Message
-	q := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("Payloads").
+	q := withRelations(r.conn(ctx)).
 		Where("thread_id = ?", threadID).
-		Order("created_at ASC")
+		Order("id DESC").
+		Limit(limit)
+	if beforeID > 0 {
+		q = q.Where("id < ?", beforeID)
+	}
+
+	if err := q.Find(&msgs).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	return msgs, nil
+}
+
+// GetAfterID возвращает до limit сообщений с id > afterID по возрастанию id
+func (r *messageRepo) GetAfterID(ctx context.Context, threadID, afterID uint, limit int) ([]gdomain.Message, error) {
+	var msgs []gdomain.Message
+	if err := withRelations(r.conn(ctx)).
+		Where("thread_id = ? AND id > ?", threadID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&msgs).Error; err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// CreatePayload сохраняет загруженное, но ещё не прикреплённое вложение
+func (r *messageRepo) CreatePayload(ctx context.Context, p *gdomain.MessagePayload) error {
+	if p == nil {
+		return fmt.Errorf("payload is nil")
+	}
+	return r.conn(ctx).Omit(clause.Associations).Create(p).Error
+}
+
+// GetPendingPayloads возвращает неприкреплённые вложения пользователя в треде.
+// Чужие, уже прикреплённые и загруженные в другой тред вложения просто не попадут в выборку.
+func (r *messageRepo) GetPendingPayloads(ctx context.Context, ids []uint, uploaderID, threadID uint) ([]gdomain.MessagePayload, error) {
+	var payloads []gdomain.MessagePayload
+	if len(ids) == 0 {
+		return payloads, nil
+	}
+	if err := r.conn(ctx).
+		Where("id IN ? AND uploader_id = ? AND thread_id = ? AND message_id IS NULL", ids, uploaderID, threadID).
+		Order("id ASC").
+		Find(&payloads).Error; err != nil {
+		return nil, err
+	}
+	return payloads, nil
+}
+
+// GetMentionsByUserID — лента упоминаний пользователя по всем спулам, новые сверху.
+// Показываем только треды, где он всё ещё участник, и не удалённые сообщения.
+func (r *messageRepo) GetMentionsByUserID(ctx context.Context, userID, beforeID uint, limit int) ([]gdomain.MessageMention, error) {
+	var mentions []gdomain.MessageMention
+	q := r.conn(ctx).
+		Joins("JOIN thread_users tu ON tu.thread_id = message_mentions.thread_id AND tu.user_id = message_mentions.user_id AND tu.is_member = ?", true).
+		Joins("JOIN messages m ON m.id = message_mentions.message_id AND m.deleted_at IS NULL").
+		Where("message_mentions.user_id = ?", userID).
+		Preload("Thread").
+		Preload("Message.User").
+		Preload("Message.ReplyTo", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Message.ReplyTo.User").
+		Order("message_mentions.id DESC")
 
+	if beforeID > 0 {
+		q = q.Where("message_mentions.id < ?", beforeID)
+	}
 	if limit > 0 {
 		q = q.Limit(limit)
 	}
-	if offset > 0 {
-		q = q.Offset(offset)
+
+	if err := q.Find(&mentions).Error; err != nil {
+		return nil, err
 	}
+	return mentions, nil
+}
 
-	if err := q.Find(&msgs).Error; err != nil {
+// searchHeadlineOptions — параметры ts_headline: пара фрагментов, совпадения в \x02...\x03
+const searchHeadlineOptions = `StartSel="` + gdomain.SearchHighlightStart +
+	`", StopSel="` + gdomain.SearchHighlightStop +
+	`", MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "`
+
+type searchRow struct {
+	ID          uint
+	SpoolID     uint
+	ThreadTitle string
+	Snippet     string
+	Rank        float64
+}
+
+// Search ищет по search_vector (GIN) с учётом членства в thread_users.
+// Сначала выбираем id с рангом и сниппетом, затем догружаем сообщения со связями.
+func (r *messageRepo) Search(ctx context.Context, f MessageSearchFilter) ([]gdomain.MessageSearchHit, error) {
+	q := r.conn(ctx).
+		Table("messages").
+		Select(`messages.id,
+			t.spool_id,
+			t.title AS thread_title,
+			ts_headline('russian', messages.content, query, ?) AS snippet,
+			ts_rank_cd(messages.search_vector, query) AS rank`, searchHeadlineOptions).
+		Joins("CROSS JOIN websearch_to_tsquery('russian', ?) query", f.Query).
+		Joins("JOIN thread_users tu ON tu.thread_id = messages.thread_id AND tu.user_id = ? AND tu.is_member = ?", f.UserID, true).
+		Joins("JOIN threads t ON t.id = messages.thread_id").
+		Where("messages.search_vector @@ query AND messages.deleted_at IS NULL")
+
+	if f.SpoolID != 0 {
+		q = q.Where("t.spool_id = ?", f.SpoolID)
+	}
+	if f.ThreadID != 0 {
+		q = q.Where("messages.thread_id = ?", f.ThreadID)
+	}
+	if f.Author != "" {
+		q = q.Joins("JOIN users u ON u.id = messages.user_id").Where("u.username = ?", f.Author)
+	}
+	if f.From != nil {
+		q = q.Where("messages.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("messages.created_at < ?", *f.To)
+	}
+	if f.HasAttachment != nil {
+		exists := "EXISTS (SELECT 1 FROM message_payloads mp WHERE mp.message_id = messages.id)"
+		if *f.HasAttachment {
+			q = q.Where(exists)
+		} else {
+			q = q.Where("NOT " + exists)
+		}
+	}
+
+	q = q.Order("rank DESC").Order("messages.id DESC")
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	if f.Offset > 0 {
+		q = q.Offset(f.Offset)
+	}
+
+	var rows []searchRow
+	if err := q.Scan(&rows).Error; err != nil {
 		return nil, err
 	}
-	return msgs, nil
+	if len(rows) == 0 {
+		return []gdomain.MessageSearchHit{}, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	var msgs []gdomain.Message
+	if err := withRelations(r.conn(ctx)).Where("id IN ?", ids).Find(&msgs).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]gdomain.Message, len(msgs))
+	for _, m := range msgs {
+		byID[m.ID] = m
+	}
+
+	// Порядок — по рангу из первого запроса
+	hits := make([]gdomain.MessageSearchHit, 0, len(rows))
+	for _, row := range rows {
+		m, ok := byID[row.ID]
+		if !ok {
+			continue // удалили между запросами
+		}
+		hits = append(hits, gdomain.MessageSearchHit{
+			Message:     m,
+			SpoolID:     row.SpoolID,
+			ThreadTitle: row.ThreadTitle,
+			Snippet:     row.Snippet,
+			Rank:        row.Rank,
+		})
+	}
+	return hits, nil
 }
 
 func (r *messageRepo) GetByID(ctx context.Context, id uint) (*gdomain.Message, error) {
 	var m gdomain.Message
-	if err := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("Payloads").
+	if err := withRelations(r.conn(ctx)).
 		First(&m, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -81,13 +285,37 @@ func (r *messageRepo) GetByID(ctx context.Context, id uint) (*gdomain.Message, e
 }
 
 func (r *messageRepo) DeleteByID(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&gdomain.Message{}, id).Error
+	return r.conn(ctx).Delete(&gdomain.Message{}, id).Error
 }
 
 func (r *messageRepo) CountByThreadID(ctx context.Context, threadID uint) (int64, error) {
 	var cnt int64
-	if err := r.db.WithContext(ctx).Model(&gdomain.Message{}).Where("thread_id = ?", threadID).Count(&cnt).Error; err != nil {
+	if err := r.conn(ctx).Model(&gdomain.Message{}).Where("thread_id = ?", threadID).Count(&cnt).Error; err != nil {
 		return 0, err
 	}
 	return cnt, nil
 }
+
+func (r *messageRepo) AppendThreadEvent(ctx context.Context, ev *gdomain.ThreadEvent) error {
+	if ev == nil {
+		return fmt.Errorf("thread event is nil")
+	}
+	return r.conn(ctx).Omit(clause.Associations).Create(ev).Error
+}
+
+func (r *messageRepo) GetThreadEventsSince(ctx context.Context, threadID uint, sinceSeq uint64, limit int) ([]gdomain.ThreadEvent, error) {
+	var events []gdomain.ThreadEvent
+	if err := r.conn(ctx).
+		Preload("Message").
+		Preload("Message.User").
+		Preload("Message.Payloads", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Message.ReplyTo", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Message.ReplyTo.User").
+		Where("thread_id = ? AND seq > ?", threadID, sinceSeq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,70 @@
+package external
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type recordingRepo struct {
+	db *gorm.DB
+}
+
+func NewRecordingRepo(db *gorm.DB) RecordingRepoInterface {
+	return &recordingRepo{db: db}
+}
+
+func (r *recordingRepo) conn(ctx context.Context) *gorm.DB {
+	return dbtx.DB(ctx, r.db)
+}
+
+func (r *recordingRepo) Create(ctx context.Context, rec *gdomain.VoiceRecording) error {
+	err := r.conn(ctx).Omit(clause.Associations).Create(rec).Error
+	if err != nil {
+		// idx_voice_recordings_active_thread: вторая активная запись в том же треде
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrRecordingExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *recordingRepo) GetActiveByThreadID(ctx context.Context, threadID uint) (*gdomain.VoiceRecording, error) {
+	var rec gdomain.VoiceRecording
+	err := r.conn(ctx).
+		Where("thread_id = ? AND status IN ?", threadID, []string{gdomain.RecordingStarting, gdomain.RecordingActive, gdomain.RecordingStopping}).
+		Take(&rec).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *recordingRepo) GetByEgressIDForUpdate(ctx context.Context, egressID string) (*gdomain.VoiceRecording, error) {
+	var rec gdomain.VoiceRecording
+	err := r.conn(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("egress_id = ?", egressID).
+		Take(&rec).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *recordingRepo) Update(ctx context.Context, rec *gdomain.VoiceRecording) error {
+	return r.conn(ctx).Omit(clause.Associations).Save(rec).Error
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -23,10 +24,15 @@ func NewThreadRepo(db *gorm.DB, logger *zap.Logger) ThreadRepoInterface {
 	}
 }
 
+// db — текущая транзакция из ctx (см. dbtx), иначе обычное подключение
+func (r *ThreadRepo) db(ctx context.Context) *gorm.DB {
+	return dbtx.DB(ctx, r.Db)
+}
+
 func (r *ThreadRepo) Create(ctx context.Context, creatorID, spoolID uint, title, threadType string) (*gdomain.Thread, error) {
 	var thread gdomain.Thread
 
-	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
 		var count int64
 		if err := tx.
 			Table("user_spools").
@@ -114,14 +120,14 @@ func (r *ThreadRepo) GetBySpoolID(ctx context.Context, userID, spoolID uint) ([]
 	return threads, nil
 }
 
-func (r *ThreadRepo) CloseThread(id uint, userID uint) (*gdomain.Thread, error) {
+func (r *ThreadRepo) CloseThread(ctx context.Context, id uint, userID uint) (*gdomain.Thread, error) {
 	var thread gdomain.Thread
-	if err := r.Db.First(&thread, id).Error; err != nil {
+	if err := r.db(ctx).First(&thread, id).Error; err != nil {
 		return nil, err
 	}
 	if thread.CreatorID == userID {
 		thread.IsClosed = true
-		if err := r.Db.Save(&thread).Error; err != nil {
+		if err := r.db(ctx).Save(&thread).Error; err != nil {
 			return nil, err
 		}
 		return &thread, nil
@@ -149,7 +155,7 @@ func (r *ThreadRepo) GetThreadByID(ctx context.Context, threadID uint) (*gdomain
 // WHERE is_member = true;
 func (r *ThreadRepo) CheckRightsUserOnThreadRoom(ctx context.Context, threadID uint, userID uint) (bool, error) {
 	var count int64
-	err := r.Db.WithContext(ctx).
+	err := r.db(ctx).
 		Table("thread_users").
 		Where("user_id = ? AND thread_id = ? AND is_member = ?", userID, threadID, true).
 		Count(&count).Error
@@ -168,7 +174,7 @@ func (r *ThreadRepo) Update(
 ) (*gdomain.Thread, error) {
 	var thread gdomain.Thread
 
-	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
 		// Проверяем, существует ли тред
 		if err := tx.First(&thread, "id = ?", id).Error; err != nil {
 			return err
@@ -209,7 +215,7 @@ func (r *ThreadRepo) Update(
 
 func (r *ThreadRepo) GetThreadMembers(ctx context.Context, threadID uint) ([]gdomain.ThreadUser, error) {
 	var members []gdomain.ThreadUser
-	if err := r.Db.WithContext(ctx).
+	if err := r.db(ctx).
 		Table("thread_users").
 		Where("thread_id = ? AND is_member = ?", threadID, true).
 		Find(&members).Error; err != nil {
@@ -218,9 +224,81 @@ func (r *ThreadRepo) GetThreadMembers(ctx context.Context, threadID uint) ([]gdo
 	return members, nil
 }
 
+// GetThreadMembersByUsernames возвращает тех из usernames, кто состоит в треде
+func (r *ThreadRepo) GetThreadMembersByUsernames(ctx context.Context, threadID uint, usernames []string) ([]gdomain.User, error) {
+	var users []gdomain.User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	if err := r.db(ctx).
+		Joins("JOIN thread_users tu ON tu.user_id = users.id").
+		Where("tu.thread_id = ? AND tu.is_member = ? AND users.username IN ?", threadID, true, usernames).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// IsThreadModerator — создатель треда или создатель спула, в котором лежит тред
+func (r *ThreadRepo) IsThreadModerator(ctx context.Context, threadID, userID uint) (bool, error) {
+	var count int64
+	err := r.db(ctx).
+		Table("threads AS t").
+		Joins("JOIN spools s ON s.id = t.spool_id").
+		Where("t.id = ? AND (t.creator_id = ? OR s.creator_id = ?)", threadID, userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetSpoolRole — роль пользователя в треде: владелец спула главнее создателя треда
+func (r *ThreadRepo) GetSpoolRole(ctx context.Context, threadID, userID uint) (gdomain.SpoolRole, error) {
+	var row struct {
+		ThreadCreatorID uint
+		SpoolCreatorID  uint
+	}
+	err := r.db(ctx).
+		Table("threads AS t").
+		Select("t.creator_id AS thread_creator_id, s.creator_id AS spool_creator_id").
+		Joins("JOIN spools s ON s.id = t.spool_id").
+		Where("t.id = ?", threadID).
+		Take(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrThreadNotFound
+		}
+		return "", err
+	}
+
+	switch userID {
+	case row.SpoolCreatorID:
+		return gdomain.SpoolRoleOwner, nil
+	case row.ThreadCreatorID:
+		return gdomain.SpoolRoleModerator, nil
+	default:
+		return gdomain.SpoolRoleMember, nil
+	}
+}
+
+func (r *ThreadRepo) SetStageMode(ctx context.Context, threadID uint, enabled bool) error {
+	res := r.db(ctx).
+		Model(&gdomain.Thread{}).
+		Where("id = ?", threadID).
+		Update("stage_mode", enabled)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrThreadNotFound
+	}
+	return nil
+}
+
 func (r *ThreadRepo) GetAccessibleThreadIDs(ctx context.Context, userID uint) ([]uint, error) {
 	var threadIDs []uint
-	err := r.Db.WithContext(ctx).
+	err := r.db(ctx).
 		Table("thread_users").
 		Where("user_id = ? AND is_member = ?", userID, true).
 		Pluck("thread_id", &threadIDs).Error
@@ -231,7 +309,7 @@ func (r *ThreadRepo) GetAccessibleThreadIDs(ctx context.Context, userID uint) ([
 }
 
 func (r *ThreadRepo) InviteToThread(ctx context.Context, inviterID uint, inviteeUsernames []string, threadID uint) error {
-	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return r.db(ctx).Transaction(func(tx *gorm.DB) error {
 		var thread gdomain.Thread
 		if err := tx.First(&thread, threadID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -289,7 +367,7 @@ func (r *ThreadRepo) InviteToThread(ctx context.Context, inviterID uint, invitee
 func (r *ThreadRepo) GetAccessibleThreadIDsBySpool(ctx context.Context, userID, spoolID uint) ([]uint, error) {
 	var threadIDs []uint
 
-	err := r.Db.WithContext(ctx).
+	err := r.db(ctx).
 		Table("thread_users tu").
 		Select("tu.thread_id").
 		Joins("JOIN threads t ON t.id = tu.thread_id").
@@ -302,3 +380,92 @@ func (r *ThreadRepo) GetAccessibleThreadIDsBySpool(ctx context.Context, userID,
 
 	return threadIDs, nil
 }
+
+// GetUnreadCounts считает непрочитанные сообщения и упоминания после маркера.
+// Обе подвыборки идут по индексам (thread_id, id) и message_mentions.user_id.
+func (r *ThreadRepo) GetUnreadCounts(ctx context.Context, userID uint, threadIDs []uint) (map[uint]gdomain.ThreadUnread, error) {
+	result := make(map[uint]gdomain.ThreadUnread, len(threadIDs))
+	if len(threadIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []gdomain.ThreadUnread
+	err := r.db(ctx).
+		Table("thread_users tu").
+		Select(`tu.thread_id,
+			tu.last_read_message_id,
+			(SELECT COUNT(*) FROM messages m
+				WHERE m.thread_id = tu.thread_id AND m.id > tu.last_read_message_id
+				AND m.user_id <> tu.user_id AND m.deleted_at IS NULL) AS unread_count,
+			(SELECT COUNT(*) FROM message_mentions mm
+				JOIN messages m ON m.id = mm.message_id AND m.deleted_at IS NULL
+				WHERE mm.thread_id = tu.thread_id AND mm.user_id = tu.user_id
+				AND mm.message_id > tu.last_read_message_id) AS mention_count`).
+		Where("tu.user_id = ? AND tu.thread_id IN ?", userID, threadIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.ThreadID] = row
+	}
+	return result, nil
+}
+
+// SetLastReadMessage двигает маркер прочтения вперёд и возвращает итоговое значение.
+// Назад не откатываем: устройство с устаревшим состоянием не должно вернуть тред в непрочитанные.
+func (r *ThreadRepo) SetLastReadMessage(ctx context.Context, threadID, userID, messageID uint) (uint, error) {
+	var lastRead uint
+	res := r.db(ctx).
+		Raw(`UPDATE thread_users SET last_read_message_id = GREATEST(last_read_message_id, ?)
+			WHERE thread_id = ? AND user_id = ? AND is_member = ?
+			RETURNING last_read_message_id`, messageID, threadID, userID, true).
+		Scan(&lastRead)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, ErrPermissionDenied
+	}
+	return lastRead, nil
+}
+
+func (r *ThreadRepo) IsUserInSpool(ctx context.Context, userID, spoolID uint) (bool, error) {
+	var count int64
+	err := r.db(ctx).
+		Table("user_spools").
+		Where("user_id = ? AND spool_id = ?", userID, spoolID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsSpoolOwner — пользователь создал спул (он же его администратор)
+func (r *ThreadRepo) IsSpoolOwner(ctx context.Context, spoolID, userID uint) (bool, error) {
+	var count int64
+	err := r.db(ctx).
+		Table("spools").
+		Where("id = ? AND creator_id = ?", spoolID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *ThreadRepo) NextSeq(ctx context.Context, threadID uint) (uint64, error) {
+	var seq uint64
+	res := r.db(ctx).
+		Raw(`UPDATE threads SET last_seq = last_seq + 1 WHERE id = ? RETURNING last_seq`, threadID).
+		Scan(&seq)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, ErrThreadNotFound
+	}
+	return seq, nil
+}
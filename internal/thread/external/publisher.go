@@ -0,0 +1,27 @@
+package external
+
+import "context"
+
+// PublishCommand — одна публикация: один канал — publish, несколько — broadcast одним вызовом
+type PublishCommand struct {
+	Channels       []string
+	Data           []byte
+	IdempotencyKey string // Centrifugo отбросит повтор с тем же ключом в том же канале
+	SkipHistory    bool   // эфемерное, в историю канала не пишем
+}
+
+// PublisherInterface — транспорт до Centrifugo под websocketRepo и релеем outbox.
+// Команды уходят в порядке следования; ответ — ошибка по каждой команде (nil — доставлена),
+// если не дошёл весь запрос, ошибка одна и та же у всех.
+type PublisherInterface interface {
+	Publish(ctx context.Context, cmds []PublishCommand) []error
+}
+
+// fillErrors — один и тот же err на все n команд
+func fillErrors(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
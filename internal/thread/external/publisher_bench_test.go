@@ -0,0 +1,191 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/centrifugal/gocent/v3"
+)
+
+// fakeCentrifugo — HTTP API Centrifugo, который на всё отвечает успехом и считает запросы и публикации
+type fakeCentrifugo struct {
+	server       *httptest.Server
+	requests     atomic.Int64
+	publications atomic.Int64
+}
+
+func newFakeCentrifugo(tb testing.TB) *fakeCentrifugo {
+	f := &fakeCentrifugo{}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.requests.Add(1)
+		enc := json.NewEncoder(w)
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var cmd struct {
+				Method string `json:"method"`
+				Params struct {
+					Channels []string `json:"channels"`
+				} `json:"params"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &cmd); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if cmd.Method == "broadcast" {
+				f.publications.Add(int64(len(cmd.Params.Channels)))
+				responses := make([]map[string]any, len(cmd.Params.Channels))
+				for i := range responses {
+					responses[i] = map[string]any{"result": map[string]any{}}
+				}
+				_ = enc.Encode(map[string]any{"result": map[string]any{"responses": responses}})
+				continue
+			}
+			f.publications.Add(1)
+			_ = enc.Encode(map[string]any{"result": map[string]any{}})
+		}
+	}))
+	tb.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeCentrifugo) client() *gocent.Client {
+	return gocent.New(gocent.Config{
+		Addr:       f.server.URL,
+		HTTPClient: &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 256}, Timeout: 5 * time.Second},
+	})
+}
+
+func (f *fakeCentrifugo) report(b *testing.B) {
+	b.ReportMetric(float64(f.requests.Load())/float64(b.N), "http_req/op")
+	b.ReportMetric(float64(f.publications.Load())/float64(b.N), "publications/op")
+}
+
+const benchMembers = 200
+
+func benchMemberIDs() []uint {
+	ids := make([]uint, benchMembers)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+	}
+	return ids
+}
+
+var benchEvent = map[string]any{
+	"type":    "message.created",
+	"payload": map[string]any{"thread_id": 1, "content": "привет, тред"},
+}
+
+// Старое поведение SendMessage: одно и то же событие в канал треда на каждого участника
+func BenchmarkFanOutThreadPerMember(b *testing.B) {
+	f := newFakeCentrifugo(b)
+	repo := NewWebsocketRepo(NewCentrifugoPublisher(f.client(), 0), "secret", "bench")
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for range benchMembers {
+			if err := repo.PublishToThread(ctx, 1, benchEvent); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	f.report(b)
+}
+
+func BenchmarkFanOutThreadOnce(b *testing.B) {
+	f := newFakeCentrifugo(b)
+	repo := NewWebsocketRepo(NewCentrifugoPublisher(f.client(), 0), "secret", "bench")
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := repo.PublishToThread(ctx, 1, benchEvent); err != nil {
+			b.Fatal(err)
+		}
+	}
+	f.report(b)
+}
+
+// ThreadCreated/ThreadUpdated по личным каналам: цикл PublishToUser против одного broadcast
+func BenchmarkFanOutUsersPerMember(b *testing.B) {
+	f := newFakeCentrifugo(b)
+	repo := NewWebsocketRepo(NewCentrifugoPublisher(f.client(), 0), "secret", "bench")
+	ctx := context.Background()
+	ids := benchMemberIDs()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			if err := repo.PublishToUser(ctx, id, benchEvent); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	f.report(b)
+}
+
+func BenchmarkFanOutUsersBroadcast(b *testing.B) {
+	f := newFakeCentrifugo(b)
+	repo := NewWebsocketRepo(NewCentrifugoPublisher(f.client(), 0), "secret", "bench")
+	ctx := context.Background()
+	ids := benchMemberIDs()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := repo.PublishToUsers(ctx, ids, benchEvent); err != nil {
+			b.Fatal(err)
+		}
+	}
+	f.report(b)
+}
+
+// Параллельные одиночные публикации (typing, presence): по запросу на каждую против склейки в pipe.
+// benchParallelism горутин на ядро — как много одновременных HTTP-запросов к API.
+const benchParallelism = 32
+
+func BenchmarkParallelPublishDirect(b *testing.B) {
+	f := newFakeCentrifugo(b)
+	repo := NewWebsocketRepo(NewCentrifugoPublisher(f.client(), 0), "secret", "bench")
+	ctx := context.Background()
+
+	b.SetParallelism(benchParallelism)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := repo.PublishToThreadEphemeral(ctx, 1, benchEvent); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	f.report(b)
+}
+
+func BenchmarkParallelPublishBatching(b *testing.B) {
+	f := newFakeCentrifugo(b)
+	publisher := NewBatchingPublisher(NewCentrifugoPublisher(f.client(), 0), 0, time.Millisecond)
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Run(runCtx)
+	repo := NewWebsocketRepo(publisher, "secret", "bench")
+	ctx := context.Background()
+
+	b.SetParallelism(benchParallelism)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := repo.PublishToThreadEphemeral(ctx, 1, benchEvent); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	f.report(b)
+}
@@ -0,0 +1,17 @@
+package external
+
+import (
+	"context"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+type RecordingRepoInterface interface {
+	// Create — в треде уже идёт запись: ErrRecordingExists
+	Create(ctx context.Context, rec *gdomain.VoiceRecording) error
+	// GetActiveByThreadID — незавершённая запись треда или nil
+	GetActiveByThreadID(ctx context.Context, threadID uint) (*gdomain.VoiceRecording, error)
+	// GetByEgressIDForUpdate — запись по id egress или nil; строка блокируется до конца транзакции
+	GetByEgressIDForUpdate(ctx context.Context, egressID string) (*gdomain.VoiceRecording, error)
+	Update(ctx context.Context, rec *gdomain.VoiceRecording) error
+}
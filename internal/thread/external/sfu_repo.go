@@ -1,7 +1,44 @@
 package external
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type SFUInterface interface {
 	EnsureRoom(ctx context.Context, roomName string) error
+	ListParticipants(ctx context.Context, roomName string) ([]SFUParticipant, error)
+	// MuteParticipant глушит все опубликованные треки участника, возвращает сколько заглушили
+	MuteParticipant(ctx context.Context, roomName, identity string) (int, error)
+	RemoveParticipant(ctx context.Context, roomName, identity string) error
+	GetParticipant(ctx context.Context, roomName, identity string) (*SFUParticipant, error)
+	// UpdateParticipant меняет права участника на лету (perm == nil — не трогаем)
+	// и атрибуты (пустое значение удаляет атрибут)
+	UpdateParticipant(ctx context.Context, roomName, identity string, perm *SFUPermission, attrs map[string]string) error
+	DeleteRoom(ctx context.Context, roomName string) error
+}
+
+// SFUParticipant — участник комнаты, как его видит сама SFU прямо сейчас
+type SFUParticipant struct {
+	Identity string // у нас это username
+	SID      string
+	UserID   uint // из атрибута user_id, 0 если не проставлен
+	JoinedAt time.Time
+	Tracks   []SFUTrack
+
+	CanPublish bool
+	Attributes map[string]string
+}
+
+type SFUPermission struct {
+	CanSubscribe   bool
+	CanPublish     bool
+	CanPublishData bool
+	Sources        []string // camera, microphone, screen_share, screen_share_audio
+}
+
+type SFUTrack struct {
+	SID    string
+	Source string // microphone, camera, screen_share...
+	Muted  bool
 }
@@ -9,13 +9,26 @@ import (
 type ThreadRepoInterface interface {
 	Create(ctx context.Context, creatorID, spoolID uint, title, threadType string) (*gdomain.Thread, error)
 	GetBySpoolID(ctx context.Context, userID, spoolID uint) ([]*gdomain.Thread, error)
-	CloseThread(id, userID uint) (*gdomain.Thread, error)
+	CloseThread(ctx context.Context, id, userID uint) (*gdomain.Thread, error)
 	InviteToThread(ctx context.Context, inviterID uint, inviteeUsernames []string, threadID uint) error
 	Update(ctx context.Context, id, editorID uint, title *string, threadType *string) (*gdomain.Thread, error)
 	GetThreadByID(ctx context.Context, threadID uint) (*gdomain.Thread, error)
 
 	CheckRightsUserOnThreadRoom(ctx context.Context, threadID, userID uint) (bool, error)
 	GetThreadMembers(ctx context.Context, threadID uint) ([]gdomain.ThreadUser, error)
+	GetThreadMembersByUsernames(ctx context.Context, threadID uint, usernames []string) ([]gdomain.User, error)
+	IsThreadModerator(ctx context.Context, threadID, userID uint) (bool, error)
+	GetSpoolRole(ctx context.Context, threadID, userID uint) (gdomain.SpoolRole, error)
+	SetStageMode(ctx context.Context, threadID uint, enabled bool) error
 	GetAccessibleThreadIDs(ctx context.Context, userID uint) ([]uint, error)
 	GetAccessibleThreadIDsBySpool(ctx context.Context, userID, spoolID uint) ([]uint, error)
+	IsUserInSpool(ctx context.Context, userID, spoolID uint) (bool, error)
+	IsSpoolOwner(ctx context.Context, spoolID, userID uint) (bool, error)
+
+	// NextSeq выдаёт следующий seq журнала треда. Строка треда блокируется до конца транзакции,
+	// поэтому seq внутри треда идут без дыр в порядке коммитов
+	NextSeq(ctx context.Context, threadID uint) (uint64, error)
+
+	GetUnreadCounts(ctx context.Context, userID uint, threadIDs []uint) (map[uint]gdomain.ThreadUnread, error)
+	SetLastReadMessage(ctx context.Context, threadID, userID, messageID uint) (uint, error)
 }
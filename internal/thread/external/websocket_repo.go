@@ -7,7 +7,12 @@ import (
 
 type WebsocketRepoInterface interface {
 	PublishToUser(ctx context.Context, userID uint, data any) error
+	// PublishToUsers — одно и то же событие в user#<id> каждого, одним broadcast'ом
+	PublishToUsers(ctx context.Context, userIDs []uint, data any) error
 	PublishToThread(ctx context.Context, threadID uint, data any) error
+	PublishToSpool(ctx context.Context, spoolID uint, data any) error
+	// PublishToThreadEphemeral — без истории канала (typing и прочее одноразовое)
+	PublishToThreadEphemeral(ctx context.Context, threadID uint, data any) error
 	GenerateConnectToken(ctx context.Context, userID uint, ttl time.Duration) (string, error)
 	GenerateSubscribeToken(ctx context.Context, userID uint, channel string, ttl time.Duration) (string, error)
 }
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+// Докачиваемые вложения: большие файлы заливаются частями, оборванную загрузку можно продолжить.
+// Итог тот же, что у UploadAttachment — неприкреплённая запись, её id уходит в attachment_ids
+
+// InitAttachmentUpload — размер и формат проверяет хендлер, место под файл — файловый модуль
+func (uc *MessageUsecase) InitAttachmentUpload(ctx context.Context, input InitAttachmentUploadInput) (*gdomain.UploadSession, error) {
+	if input.Filename == "" || input.Size <= 0 {
+		return nil, ErrInvalidInput
+	}
+	thread, err := uc.writableThread(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.fileUC.InitUpload(ctx, fileUsecase.InitUploadInput{
+		UserID:   input.UserID,
+		Filename: input.Filename,
+		Size:     input.Size,
+		Kind:     gdomain.FileKindAttachment,
+		SpoolID:  &thread.SpoolID, // место вложения считается и в спул
+		ThreadID: &input.ThreadID,
+	})
+}
+
+func (uc *MessageUsecase) UploadAttachmentPart(ctx context.Context, input AttachmentPartInput) (*gdomain.UploadSession, error) {
+	if _, err := uc.attachmentUpload(ctx, input.ThreadID, input.UserID, input.UploadID); err != nil {
+		return nil, err
+	}
+	return uc.fileUC.UploadPart(ctx, fileUsecase.UploadPartInput{
+		UserID:   input.UserID,
+		UploadID: input.UploadID,
+		Offset:   input.Offset,
+		Body:     input.Body,
+		Size:     input.Size,
+	})
+}
+
+func (uc *MessageUsecase) GetAttachmentUpload(ctx context.Context, input AttachmentUploadInput) (*gdomain.UploadSession, error) {
+	return uc.attachmentUpload(ctx, input.ThreadID, input.UserID, input.UploadID)
+}
+
+// CompleteAttachmentUpload — за время загрузки тред могли закрыть или выгнать из него, права проверяем заново
+func (uc *MessageUsecase) CompleteAttachmentUpload(ctx context.Context, input AttachmentUploadInput) (*gdomain.MessagePayload, error) {
+	if _, err := uc.writableThread(ctx, input.ThreadID, input.UserID); err != nil {
+		return nil, err
+	}
+	if _, err := uc.attachmentUpload(ctx, input.ThreadID, input.UserID, input.UploadID); err != nil {
+		return nil, err
+	}
+
+	s, err := uc.fileUC.CompleteUpload(ctx, fileUsecase.UploadRefInput{UserID: input.UserID, UploadID: input.UploadID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete attachment upload: %w", err)
+	}
+
+	return uc.createPayload(ctx, &gdomain.MessagePayload{
+		ThreadID:    input.ThreadID,
+		UploaderID:  input.UserID,
+		Bucket:      s.Bucket,
+		FileLink:    s.ObjectKey,
+		Filename:    s.Filename,
+		ContentType: s.ContentType,
+		Size:        s.Size,
+	})
+}
+
+func (uc *MessageUsecase) AbortAttachmentUpload(ctx context.Context, input AttachmentUploadInput) error {
+	if _, err := uc.attachmentUpload(ctx, input.ThreadID, input.UserID, input.UploadID); err != nil {
+		return err
+	}
+	return uc.fileUC.AbortUpload(ctx, fileUsecase.UploadRefInput{UserID: input.UserID, UploadID: input.UploadID})
+}
+
+// attachmentUpload — загрузка пользователя, начатая именно в этом треде
+func (uc *MessageUsecase) attachmentUpload(ctx context.Context, threadID, userID uint, uploadID string) (*gdomain.UploadSession, error) {
+	s, err := uc.fileUC.GetUpload(ctx, fileUsecase.UploadRefInput{UserID: userID, UploadID: uploadID})
+	if err != nil {
+		return nil, err
+	}
+	if s.ThreadID == nil || *s.ThreadID != threadID {
+		return nil, fileUsecase.ErrUploadNotFound
+	}
+	return s, nil
+}
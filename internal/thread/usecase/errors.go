@@ -9,5 +9,24 @@ var (
 	ErrFaildToEnsureRoom = errors.New("faild to ensure room")
 
 	ErrNoRightsOnJoinRoom = errors.New("no rights to join thread room")
-	ErrWrognTypeThread    = errors.New("wrong type of thread")
+	ErrNotThreadModerator = errors.New("only thread or spool creator can moderate the room")
+	ErrNotInVoiceRoom     = errors.New("user is not in the voice room")
+
+	ErrRecordingInProgress  = errors.New("thread is already being recorded")
+	ErrNoActiveRecording    = errors.New("thread is not being recorded")
+	ErrVoiceRoomEmpty       = errors.New("voice room is empty")
+	ErrRecordingUnavailable = errors.New("recording is not available with this storage backend")
+	ErrWrognTypeThread      = errors.New("wrong type of thread")
+
+	ErrReplyTargetNotFound  = errors.New("reply target message not found")
+	ErrReplyToAnotherThread = errors.New("reply target belongs to another thread")
+
+	ErrNoAccessToThread   = errors.New("user has no access to this thread")
+	ErrThreadClosed       = errors.New("thread is closed")
+	ErrEmptyMessage       = errors.New("message has no content and no attachments")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrTooManyAttachments = errors.New("too many attachments")
+
+	ErrMessageNotFound = errors.New("message not found")
+	ErrInvalidCursor   = errors.New("invalid cursor")
 )
@@ -1,6 +1,11 @@
 package usecase
 
-import "github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+import (
+	"io"
+	"time"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
 
 // ---------- CreateThread ----------
 type CreateThreadInput struct {
@@ -46,18 +51,152 @@ type GetVoiceTokenInput struct {
 
 // ---------- SendMessage ----------
 type SendMessageInput struct {
+	UserID        uint
+	Username      string
+	ThreadID      uint
+	Content       string
+	ReplyToID     *uint
+	AttachmentIDs []uint
+	Payloads      []gdomain.MessagePayload
+}
+
+// ---------- UploadAttachment ----------
+type UploadAttachmentInput struct {
+	UserID      uint
+	ThreadID    uint
+	File        io.Reader
+	Size        int64
+	Filename    string
+	ContentType string
+}
+
+// ---------- Докачиваемые вложения ----------
+type InitAttachmentUploadInput struct {
+	UserID   uint
+	ThreadID uint
+	Filename string
+	Size     int64
+}
+
+type AttachmentPartInput struct {
+	UserID   uint
+	ThreadID uint
+	UploadID string
+	Offset   int64
+	Body     io.Reader
+	Size     int64
+}
+
+type AttachmentUploadInput struct {
 	UserID   uint
-	Username string
 	ThreadID uint
-	Content  string
-	Payloads []gdomain.MessagePayload
+	UploadID string
 }
 
 // ---------- GetMessages ----------
+// Задаётся максимум одно из Cursor/BeforeID/AfterID/AroundID; ничего — последние сообщения треда
 type GetMessagesInput struct {
+	UserID   uint
+	ThreadID uint
+	Limit    int
+	Cursor   string
+	BeforeID uint
+	AfterID  uint
+	AroundID uint // переход к сообщению из поиска или упоминания
+}
+
+// ---------- GetThreadEvents ----------
+type GetThreadEventsInput struct {
+	UserID   uint
+	ThreadID uint
+	SinceSeq uint64 // последний seq, который клиент уже видел; 0 — с начала треда
+	Limit    int
+}
+
+// ---------- SearchMessages ----------
+type SearchMessagesInput struct {
+	UserID        uint
+	Query         string
+	SpoolID       uint
+	ThreadID      uint
+	Author        string
+	From          *time.Time
+	To            *time.Time
+	HasAttachment *bool
+	Limit         int
+	Offset        int
+}
+
+// ---------- MarkRead ----------
+type MarkReadInput struct {
+	UserID    uint
+	ThreadID  uint
+	MessageID uint // 0 — прочитано всё до последнего сообщения
+}
+
+// ---------- Typing ----------
+type TypingInput struct {
+	UserID   uint
+	Username string
 	ThreadID uint
+}
+
+// ---------- GetMentions ----------
+type GetMentionsInput struct {
+	UserID   uint
+	BeforeID uint
 	Limit    int
-	Offset   int
+}
+
+// ---------- VoiceRoom ----------
+type VoiceRoomInput struct {
+	UserID   uint
+	ThreadID uint
+}
+
+// ---------- VoiceParticipant ----------
+type VoiceParticipantInput struct {
+	UserID   uint
+	ThreadID uint
+	Username string // identity участника в LiveKit
+}
+
+// ---------- SetStageMode ----------
+type SetStageModeInput struct {
+	UserID   uint
+	ThreadID uint
+	Enabled  bool
+}
+
+// ---------- RaiseHand ----------
+type RaiseHandInput struct {
+	UserID   uint
+	Username string
+	ThreadID uint
+	Raised   bool
+}
+
+// ---------- StartRecording ----------
+type StartRecordingInput struct {
+	UserID   uint
+	Username string
+	ThreadID uint
+}
+
+// ---------- StopRecording ----------
+type StopRecordingInput struct {
+	UserID   uint
+	ThreadID uint
+}
+
+// ---------- HandleEgressEnded ----------
+type EgressEndedInput struct {
+	EgressID   string
+	Completed  bool   // EGRESS_COMPLETE; всё остальное — провал
+	Error      string // от LiveKit, если не получилось
+	ObjectKey  string // где файл оказался в бакете
+	Size       int64
+	DurationMs int64
 }
 
 // ---------- GetSubscribeToken ----------
@@ -66,6 +205,15 @@ type GetSubscribeTokenInput struct {
 	ThreadID uint
 }
 
+// ---------- GetVoiceToken ----------
+type VoiceToken struct {
+	Token     string
+	Role      gdomain.SpoolRole
+	Speaker   bool // false — зашёл на сцену слушателем
+	Stage     bool
+	ExpiresAt time.Time
+}
+
 // ---------- GetConnectAndSubscribeTokens ----------
 type ConnectAndSubscribeTokens struct {
 	ConnectToken  string
@@ -4,10 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
 	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+	presenceExternal "github.com/onionfriend2004/threadbook_backend/internal/presence/external"
 	"github.com/onionfriend2004/threadbook_backend/internal/thread/external"
 	"go.uber.org/zap"
 )
@@ -16,7 +23,11 @@ type MessageUsecase struct {
 	msgRepo    external.MessageRepoInterface
 	wsRepo     external.WebsocketRepoInterface
 	threadRepo external.ThreadRepoInterface
+	tx         dbtx.TransactorInterface         // сообщение и его события в outbox пишутся одной транзакцией
+	fileUC     fileUsecase.FileUsecaseInterface // бакет вложений
+	presence   presenceExternal.PresenceRepoInterface
 	tokenTTL   time.Duration
+	typingTTL  time.Duration
 	logger     *zap.Logger
 }
 
@@ -24,85 +35,647 @@ func NewMessageUsecase(
 	msgRepo external.MessageRepoInterface,
 	wsRepo external.WebsocketRepoInterface,
 	threadRepo external.ThreadRepoInterface,
+	tx dbtx.TransactorInterface,
+	fileUC fileUsecase.FileUsecaseInterface,
+	presence presenceExternal.PresenceRepoInterface,
 	tokenTTL time.Duration,
+	typingTTL time.Duration,
 	logger *zap.Logger) *MessageUsecase {
 	return &MessageUsecase{
 		msgRepo:    msgRepo,
 		wsRepo:     wsRepo,
 		threadRepo: threadRepo,
+		tx:         tx,
+		fileUC:     fileUC,
+		presence:   presence,
 		tokenTTL:   tokenTTL,
+		typingTTL:  typingTTL,
 		logger:     logger,
 	}
 }
 
-func (uc *MessageUsecase) SendMessage(ctx context.Context, input SendMessageInput) (*gdomain.Message, error) {
+// writableThread проверяет, что пользователь участник треда и тред не закрыт
+func (uc *MessageUsecase) writableThread(ctx context.Context, threadID, userID uint) (*gdomain.Thread, error) {
 	// Проверяем права пользователя на тред
-	hasRights, err := uc.threadRepo.CheckRightsUserOnThreadRoom(ctx, input.ThreadID, input.UserID)
+	hasRights, err := uc.threadRepo.CheckRightsUserOnThreadRoom(ctx, threadID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check rights: %w", err)
 	}
 	if !hasRights {
-		return nil, errors.New("user has no access to this thread")
+		return nil, ErrNoAccessToThread
 	}
 
 	// Проверяем, что тред не закрыт
-	thread, err := uc.threadRepo.GetThreadByID(ctx, input.ThreadID)
+	thread, err := uc.threadRepo.GetThreadByID(ctx, threadID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get thread: %w", err)
 	}
 	if thread.IsClosed {
-		return nil, errors.New("cannot send message: thread is closed")
+		return nil, ErrThreadClosed
 	}
+	return thread, nil
+}
 
-	// Создаём сообщение
-	msg := &gdomain.Message{
-		ThreadID: input.ThreadID,
-		UserID:   input.UserID,
-		Content:  input.Content,
-		Payloads: input.Payloads,
+func (uc *MessageUsecase) SendMessage(ctx context.Context, input SendMessageInput) (*gdomain.Message, error) {
+	thread, err := uc.writableThread(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Если это ответ — родитель должен существовать и лежать в том же треде
+	var replyTo *gdomain.Message
+	if input.ReplyToID != nil {
+		replyTo, err = uc.msgRepo.GetByID(ctx, *input.ReplyToID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reply target: %w", err)
+		}
+		if replyTo == nil {
+			return nil, ErrReplyTargetNotFound
+		}
+		if replyTo.ThreadID != input.ThreadID {
+			return nil, ErrReplyToAnotherThread
+		}
 	}
 
-	// Сохраняем сообщение
-	if err := uc.msgRepo.CreateWithPayloads(ctx, msg); err != nil {
-		return nil, fmt.Errorf("failed to save message: %w", err)
+	// Вложения должны быть загружены этим же пользователем в этот же тред и ещё никуда не прикреплены
+	attachments, err := uc.resolveAttachments(ctx, input)
+	if err != nil {
+		return nil, err
 	}
+	return uc.createMessage(ctx, thread, input, replyTo, append(input.Payloads, attachments...))
+}
 
-	// Получаем участников треда
-	members, err := uc.threadRepo.GetThreadMembers(ctx, input.ThreadID)
+// SendRecording приходит от вебхука egress, а не от пользователя: автор — тот, кто запускал запись,
+// права уже проверили на старте. Тред может быть уже закрыт — комнату сносят как раз при закрытии.
+func (uc *MessageUsecase) SendRecording(ctx context.Context, input SendMessageInput) (*gdomain.Message, error) {
+	thread, err := uc.threadRepo.GetThreadByID(ctx, input.ThreadID)
 	if err != nil {
-		return msg, fmt.Errorf("failed to get thread members: %w", err)
+		return nil, fmt.Errorf("failed to get thread: %w", err)
 	}
+	return uc.createMessage(ctx, thread, input, nil, input.Payloads)
+}
 
-	// Готовим событие
-	ev := event.Event{
-		Type: event.MessageCreated,
-		Payload: event.MessageCreatedPayload{
-			MessageID: msg.ID,
-			ThreadID:  input.ThreadID,
-			Content:   input.Content,
-			Username:  input.Username,
-			CreatedAt: time.Now().Unix(),
-		},
+// createMessage сохраняет сообщение, пишет его в журнал треда и рассылает события
+func (uc *MessageUsecase) createMessage(ctx context.Context, thread *gdomain.Thread, input SendMessageInput, replyTo *gdomain.Message, payloads []gdomain.MessagePayload) (*gdomain.Message, error) {
+	if strings.TrimSpace(input.Content) == "" && len(payloads) == 0 {
+		return nil, ErrEmptyMessage
+	}
+
+	// Разбираем упоминания, пока не сохранили — строки упоминаний пишутся вместе с сообщением
+	mentions, err := uc.resolveMentions(ctx, thread, input.UserID, input.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mentions: %w", err)
 	}
 
-	// Рассылаем событие всем участникам
-	for _, member := range members {
+	// Создаём сообщение
+	msg := &gdomain.Message{
+		ThreadID:  input.ThreadID,
+		UserID:    input.UserID,
+		ReplyToID: input.ReplyToID,
+		Content:   input.Content,
+		Payloads:  payloads,
+		Mentions:  mentions,
+	}
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Номер в журнале треда; строка треда держится заблокированной до коммита
+		seq, err := uc.threadRepo.NextSeq(ctx, input.ThreadID)
+		if err != nil {
+			return fmt.Errorf("failed to allocate seq: %w", err)
+		}
+		msg.Seq = seq
+
+		// Сохраняем сообщение
+		if err := uc.msgRepo.CreateWithPayloads(ctx, msg); err != nil {
+			// Вложение успели прикрепить к другому сообщению между проверкой и сохранением
+			if errors.Is(err, external.ErrPayloadNotPending) {
+				return ErrAttachmentNotFound
+			}
+			return fmt.Errorf("failed to save message: %w", err)
+		}
+		if len(msg.Payloads) > 0 {
+			links := make([]string, 0, len(msg.Payloads))
+			for _, p := range msg.Payloads {
+				links = append(links, p.FileLink)
+			}
+			if err := uc.fileUC.AttachToMessage(ctx, fileUsecase.AttachToMessageInput{Filenames: links, MessageID: msg.ID}); err != nil {
+				return fmt.Errorf("failed to attach files to message: %w", err)
+			}
+		}
+
+		// Готовим событие
+		ev := event.New(event.MessageCreatedPayload{
+			MessageID:   msg.ID,
+			ThreadID:    input.ThreadID,
+			Seq:         seq,
+			Content:     input.Content,
+			Username:    input.Username,
+			ReplyTo:     replyPayload(replyTo),
+			Attachments: attachmentPayloads(msg.Payloads),
+			CreatedAt:   time.Now().Unix(),
+		}, threadScope(thread), event.UserActor(input.UserID, input.Username))
+
+		// Само сообщение подгрузится из messages, payload в журнале не дублируем
+		if err := uc.msgRepo.AppendThreadEvent(ctx, &gdomain.ThreadEvent{
+			ThreadID:  input.ThreadID,
+			Seq:       seq,
+			Type:      string(ev.Type),
+			MessageID: &msg.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to append thread event: %w", err)
+		}
+
+		// Канал треда один на всех участников — публикуем один раз
 		if err := uc.wsRepo.PublishToThread(ctx, input.ThreadID, ev); err != nil {
-			uc.logger.Warn("failed to publish message event to user",
-				zap.Uint("userID", member.UserID),
-				zap.Error(err))
+			return fmt.Errorf("failed to enqueue message event: %w", err)
 		}
+
+		return uc.notifyMentioned(ctx, thread, msg, input.UserID, input.Username)
+	})
+	if err != nil {
+		return nil, err
 	}
+	msg.User = gdomain.User{ID: input.UserID, Username: input.Username}
+	msg.ReplyTo = replyTo
 
 	return msg, nil
 }
 
-func (uc *MessageUsecase) GetMessages(ctx context.Context, input GetMessagesInput) ([]gdomain.Message, error) {
-	msgs, err := uc.msgRepo.GetByThreadID(ctx, input.ThreadID, input.Limit, input.Offset)
+// resolveAttachments достаёт загруженные заранее вложения по их ID
+func (uc *MessageUsecase) resolveAttachments(ctx context.Context, input SendMessageInput) ([]gdomain.MessagePayload, error) {
+	if len(input.AttachmentIDs) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[uint]struct{}, len(input.AttachmentIDs))
+	ids := make([]uint, 0, len(input.AttachmentIDs))
+	for _, id := range input.AttachmentIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids)+len(input.Payloads) > gdomain.MaxAttachmentsPerMessage {
+		return nil, ErrTooManyAttachments
+	}
+
+	payloads, err := uc.msgRepo.GetPendingPayloads(ctx, ids, input.UserID, input.ThreadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	if len(payloads) != len(ids) {
+		return nil, ErrAttachmentNotFound
+	}
+	return payloads, nil
+}
+
+// UploadAttachment кладёт файл в бакет вложений и создаёт неприкреплённую запись.
+// Размер и формат проверяет хендлер по FileConfig, здесь — только права на тред.
+func (uc *MessageUsecase) UploadAttachment(ctx context.Context, input UploadAttachmentInput) (*gdomain.MessagePayload, error) {
+	if input.File == nil || input.Size <= 0 || input.Filename == "" {
+		return nil, ErrInvalidInput
+	}
+	thread, err := uc.writableThread(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileLink, err := uc.fileUC.SaveFile(ctx, fileUsecase.SaveFile{
+		File:        input.File,
+		Size:        input.Size,
+		Filename:    input.Filename,
+		ContentType: input.ContentType,
+		UserID:      strconv.FormatUint(uint64(input.UserID), 10),
+		FileType:    gdomain.FileKindAttachment,
+		SpoolID:     &thread.SpoolID, // место вложения считается и в спул
+		ThreadID:    &input.ThreadID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	return uc.createPayload(ctx, &gdomain.MessagePayload{
+		ThreadID:    input.ThreadID,
+		UploaderID:  input.UserID,
+		Bucket:      uc.fileUC.GetBucketName(),
+		FileLink:    fileLink,
+		Filename:    filepath.Base(input.Filename),
+		ContentType: input.ContentType,
+		Size:        input.Size,
+	})
+}
+
+// createPayload — запись о неприкреплённом вложении для уже сохранённого файла
+func (uc *MessageUsecase) createPayload(ctx context.Context, payload *gdomain.MessagePayload) (*gdomain.MessagePayload, error) {
+	if err := uc.msgRepo.CreatePayload(ctx, payload); err != nil {
+		// Без записи в БД файл никто не найдёт — удаляем
+		if delErr := uc.fileUC.DeleteFile(ctx, fileUsecase.DeleteFileInput{Filename: payload.FileLink}); delErr != nil {
+			uc.logger.Error("failed to cleanup attachment after error",
+				zap.Error(delErr),
+				zap.String("file_link", payload.FileLink))
+		}
+		return nil, fmt.Errorf("failed to save attachment record: %w", err)
+	}
+	return payload, nil
+}
+
+// resolveMentions сопоставляет @username с участниками треда.
+// @here/@thread раскрываются в участников только у модераторов треда, у остальных это просто текст.
+// @here — только тем, кто сейчас онлайн, @thread — всем.
+func (uc *MessageUsecase) resolveMentions(ctx context.Context, thread *gdomain.Thread, authorID uint, content string) ([]gdomain.MessageMention, error) {
+	parsed := gdomain.ParseMentions(content)
+	kinds := make(map[uint]string)
+
+	if parsed.Here || parsed.Thread {
+		canGroup, err := uc.threadRepo.IsThreadModerator(ctx, thread.ID, authorID)
+		if err != nil {
+			return nil, err
+		}
+		if canGroup {
+			kind := gdomain.MentionHere
+			if parsed.Thread {
+				kind = gdomain.MentionThread
+			}
+			members, err := uc.threadRepo.GetThreadMembers(ctx, thread.ID)
+			if err != nil {
+				return nil, err
+			}
+			var online map[uint]bool
+			if kind == gdomain.MentionHere {
+				ids := make([]uint, 0, len(members))
+				for _, member := range members {
+					ids = append(ids, member.UserID)
+				}
+				if online, err = uc.presence.GetOnline(ctx, ids); err != nil {
+					// присутствие — best effort: без Redis @here просто никого не зовёт, сообщение уходит
+					uc.logger.Warn("failed to get online members, skipping @here",
+						zap.Error(err),
+						zap.Uint("thread_id", thread.ID))
+					online = nil
+				}
+			}
+			for _, member := range members {
+				if kind == gdomain.MentionHere && !online[member.UserID] {
+					continue
+				}
+				kinds[member.UserID] = kind
+			}
+		}
+	}
+
+	// Личное упоминание важнее группового
+	users, err := uc.threadRepo.GetThreadMembersByUsernames(ctx, thread.ID, parsed.Usernames)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		kinds[user.ID] = gdomain.MentionUser
+	}
+
+	// Себя не уведомляем
+	delete(kinds, authorID)
+
+	mentions := make([]gdomain.MessageMention, 0, len(kinds))
+	for userID, kind := range kinds {
+		mentions = append(mentions, gdomain.MessageMention{
+			UserID:   userID,
+			ThreadID: thread.ID,
+			AuthorID: authorID,
+			Kind:     kind,
+		})
+	}
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].UserID < mentions[j].UserID })
+
+	return mentions, nil
+}
+
+// notifyMentioned шлёт упомянутым событие в их личный канал user#<id>.
+// Личный канал не зависит от подписки на тред, поэтому упоминание дойдёт, даже если тред заглушен.
+// Payload отличается только kind, так что на каждый kind — один broadcast.
+func (uc *MessageUsecase) notifyMentioned(ctx context.Context, thread *gdomain.Thread, msg *gdomain.Message, authorID uint, author string) error {
+	byKind := make(map[string][]uint)
+	var kinds []string
+	for _, mention := range msg.Mentions {
+		if _, ok := byKind[mention.Kind]; !ok {
+			kinds = append(kinds, mention.Kind)
+		}
+		byKind[mention.Kind] = append(byKind[mention.Kind], mention.UserID)
+	}
+
+	for _, kind := range kinds {
+		ev := event.New(event.MessageMentionedPayload{
+			MessageID:   msg.ID,
+			ThreadID:    thread.ID,
+			SpoolID:     thread.SpoolID,
+			ThreadTitle: thread.Title,
+			Username:    author,
+			Content:     msg.Preview(gdomain.ReplyPreviewLen),
+			Kind:        kind,
+			CreatedAt:   msg.CreatedAt.Unix(),
+		}, threadScope(thread), event.UserActor(authorID, author))
+		if err := uc.wsRepo.PublishToUsers(ctx, byKind[kind], ev); err != nil {
+			return fmt.Errorf("failed to enqueue mention event: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarkRead двигает маркер прочтения и рассылает его на все устройства пользователя через user#<id>
+func (uc *MessageUsecase) MarkRead(ctx context.Context, input MarkReadInput) (*gdomain.ThreadUnread, error) {
+	if input.UserID == 0 || input.ThreadID == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	// сначала членство: чужому не отвечаем ни про тред, ни про его сообщения
+	isMember, err := uc.threadRepo.CheckRightsUserOnThreadRoom(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rights: %w", err)
+	}
+	if !isMember {
+		return nil, ErrNoAccessToThread
+	}
+	thread, err := uc.threadRepo.GetThreadByID(ctx, input.ThreadID)
+	if err != nil {
+		if errors.Is(err, external.ErrThreadNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	messageID := input.MessageID
+	if messageID == 0 {
+		latest, err := uc.msgRepo.GetBeforeID(ctx, input.ThreadID, 0, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest message: %w", err)
+		}
+		if len(latest) > 0 {
+			messageID = latest[0].ID
+		}
+	} else {
+		msg, err := uc.msgRepo.GetByID(ctx, messageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message: %w", err)
+		}
+		if msg == nil || msg.ThreadID != input.ThreadID {
+			return nil, ErrMessageNotFound
+		}
+	}
+
+	var unread gdomain.ThreadUnread
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Строка thread_users есть только у участника — заодно это проверка доступа
+		lastRead, err := uc.threadRepo.SetLastReadMessage(ctx, input.ThreadID, input.UserID, messageID)
+		if err != nil {
+			if errors.Is(err, external.ErrPermissionDenied) {
+				return ErrNoAccessToThread
+			}
+			return fmt.Errorf("failed to set read marker: %w", err)
+		}
+
+		counts, err := uc.threadRepo.GetUnreadCounts(ctx, input.UserID, []uint{input.ThreadID})
+		if err != nil {
+			return fmt.Errorf("failed to get unread counts: %w", err)
+		}
+		unread = counts[input.ThreadID]
+		unread.ThreadID = input.ThreadID
+		unread.LastReadMessageID = lastRead
+
+		ev := event.New(event.ThreadReadPayload{
+			ThreadID:          input.ThreadID,
+			SpoolID:           thread.SpoolID,
+			LastReadMessageID: unread.LastReadMessageID,
+			UnreadCount:       unread.UnreadCount,
+			MentionCount:      unread.MentionCount,
+		}, threadScope(thread), event.UserActor(input.UserID, ""))
+		if err := uc.wsRepo.PublishToUser(ctx, input.UserID, ev); err != nil {
+			return fmt.Errorf("failed to enqueue read marker event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &unread, nil
+}
+
+// Typing шлёт в тред "печатает…". Событие эфемерное: не сохраняется и идёт без истории канала,
+// а повторные вызовы чаще typingTTL просто отбрасываются.
+func (uc *MessageUsecase) Typing(ctx context.Context, input TypingInput) error {
+	if input.UserID == 0 || input.ThreadID == 0 {
+		return ErrInvalidInput
+	}
+
+	hasRights, err := uc.threadRepo.CheckRightsUserOnThreadRoom(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check rights: %w", err)
+	}
+	if !hasRights {
+		return ErrNoAccessToThread
+	}
+
+	allowed, err := uc.presence.TryTyping(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return nil
+	}
+
+	ev := event.New(event.TypingPayload{
+		ThreadID:  input.ThreadID,
+		UserID:    input.UserID,
+		Username:  input.Username,
+		ExpiresIn: int(uc.typingTTL.Seconds()) * 2, // с запасом: повтор приходит не чаще раза в typingTTL
+	}, event.Scope{ThreadID: input.ThreadID}, event.UserActor(input.UserID, input.Username))
+	if err := uc.wsRepo.PublishToThreadEphemeral(ctx, input.ThreadID, ev); err != nil {
+		return fmt.Errorf("failed to publish typing event: %w", err)
+	}
+	return nil
+}
+
+// GetMentions — лента "меня упомянули" по всем спулам
+func (uc *MessageUsecase) GetMentions(ctx context.Context, input GetMentionsInput) ([]gdomain.MessageMention, error) {
+	if input.UserID == 0 {
+		return nil, ErrInvalidInput
+	}
+	mentions, err := uc.msgRepo.GetMentionsByUserID(ctx, input.UserID, input.BeforeID, input.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch mentions: %w", err)
+	}
+	return mentions, nil
+}
+
+// SearchMessages — полнотекстовый поиск по всем тредам, где пользователь участник
+func (uc *MessageUsecase) SearchMessages(ctx context.Context, input SearchMessagesInput) ([]gdomain.MessageSearchHit, error) {
+	query := strings.TrimSpace(input.Query)
+	if input.UserID == 0 || query == "" || input.Limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if input.From != nil && input.To != nil && !input.From.Before(*input.To) {
+		return nil, ErrInvalidInput
+	}
+
+	hits, err := uc.msgRepo.Search(ctx, external.MessageSearchFilter{
+		UserID:        input.UserID,
+		Query:         query,
+		SpoolID:       input.SpoolID,
+		ThreadID:      input.ThreadID,
+		Author:        gdomain.NormalizeUsername(strings.TrimPrefix(strings.TrimSpace(input.Author), "@")),
+		From:          input.From,
+		To:            input.To,
+		HasAttachment: input.HasAttachment,
+		Limit:         input.Limit,
+		Offset:        input.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	return hits, nil
+}
+
+// replyPayload собирает цитату родительского сообщения для события
+func replyPayload(parent *gdomain.Message) *event.MessageReplyPayload {
+	if parent == nil {
+		return nil
+	}
+	if parent.IsDeleted() {
+		return &event.MessageReplyPayload{MessageID: parent.ID, Deleted: true}
+	}
+	return &event.MessageReplyPayload{
+		MessageID: parent.ID,
+		Username:  parent.User.Username,
+		Content:   parent.Preview(gdomain.ReplyPreviewLen),
+	}
+}
+
+// attachmentPayloads собирает метаданные вложений для события
+func attachmentPayloads(payloads []gdomain.MessagePayload) []event.MessageAttachmentPayload {
+	if len(payloads) == 0 {
+		return nil
+	}
+	res := make([]event.MessageAttachmentPayload, 0, len(payloads))
+	for _, p := range payloads {
+		res = append(res, event.MessageAttachmentPayload{
+			ID:          p.ID,
+			Bucket:      p.Bucket,
+			FileLink:    p.FileLink,
+			Filename:    p.Filename,
+			ContentType: p.ContentType,
+			Size:        p.Size,
+		})
+	}
+	return res
+}
+
+// GetMessages отдаёт страницу истории по курсору (keyset по id, без OFFSET).
+// Новые сообщения не сдвигают уже полученные страницы.
+func (uc *MessageUsecase) GetMessages(ctx context.Context, input GetMessagesInput) (*MessagesPage, error) {
+	if input.Limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	if input.Cursor != "" {
+		if input.BeforeID != 0 || input.AfterID != 0 || input.AroundID != 0 {
+			return nil, ErrInvalidInput
+		}
+		direction, id, err := decodeCursor(input.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		if direction == cursorBefore {
+			input.BeforeID = id
+		} else {
+			input.AfterID = id
+		}
+	}
+
+	anchors := 0
+	for _, id := range []uint{input.BeforeID, input.AfterID, input.AroundID} {
+		if id != 0 {
+			anchors++
+		}
+	}
+	if anchors > 1 {
+		return nil, ErrInvalidInput
+	}
+
+	hasRights, err := uc.threadRepo.CheckRightsUserOnThreadRoom(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rights: %w", err)
+	}
+	if !hasRights {
+		return nil, ErrNoAccessToThread
+	}
+
+	switch {
+	case input.AroundID != 0:
+		return uc.getMessagesAround(ctx, input)
+	case input.AfterID != 0:
+		// Берём на одно больше, чтобы понять, есть ли что-то новее
+		msgs, err := uc.msgRepo.GetAfterID(ctx, input.ThreadID, input.AfterID, input.Limit+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch messages: %w", err)
+		}
+		hasNewer := len(msgs) > input.Limit
+		if hasNewer {
+			msgs = msgs[:input.Limit]
+		}
+		page := &MessagesPage{Messages: msgs}
+		// Старше якоря сообщения точно есть — сам якорь
+		pageCursors(page, true, hasNewer)
+		return page, nil
+	default:
+		msgs, err := uc.msgRepo.GetBeforeID(ctx, input.ThreadID, input.BeforeID, input.Limit+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch messages: %w", err)
+		}
+		hasOlder := len(msgs) > input.Limit
+		if hasOlder {
+			msgs = msgs[1:]
+		}
+		page := &MessagesPage{Messages: msgs}
+		// Без якоря это последняя страница, новее ничего нет
+		pageCursors(page, hasOlder, input.BeforeID != 0)
+		return page, nil
+	}
+}
+
+// getMessagesAround — окно вокруг сообщения: половина старше, остальное (включая само сообщение) новее
+func (uc *MessageUsecase) getMessagesAround(ctx context.Context, input GetMessagesInput) (*MessagesPage, error) {
+	target, err := uc.msgRepo.GetByID(ctx, input.AroundID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if target == nil || target.ThreadID != input.ThreadID {
+		return nil, ErrMessageNotFound
+	}
+
+	olderLimit := input.Limit / 2
+	newerLimit := input.Limit - olderLimit
+
+	older, err := uc.msgRepo.GetBeforeID(ctx, input.ThreadID, target.ID, olderLimit+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
-	return msgs, nil
+	hasOlder := len(older) > olderLimit
+	if hasOlder {
+		older = older[1:]
+	}
+
+	newer, err := uc.msgRepo.GetAfterID(ctx, input.ThreadID, target.ID-1, newerLimit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	hasNewer := len(newer) > newerLimit
+	if hasNewer {
+		newer = newer[:newerLimit]
+	}
+
+	page := &MessagesPage{Messages: append(older, newer...)}
+	pageCursors(page, hasOlder, hasNewer)
+	return page, nil
 }
 
 func (uc *MessageUsecase) GetConnectToken(ctx context.Context, userID uint) (string, error) {
@@ -154,6 +727,20 @@ func (uc *MessageUsecase) GetTokensBySpool(ctx context.Context, userID, spoolID
 	}
 	channels[userChannel] = userSub
 
+	// spool channel (присутствие участников)
+	inSpool, err := uc.threadRepo.IsUserInSpool(ctx, userID, spoolID)
+	if err != nil {
+		return ConnectAndSubscribeTokens{}, err
+	}
+	if inSpool {
+		spoolChannel := fmt.Sprintf("spool#%d", spoolID)
+		spoolSub, err := uc.wsRepo.GenerateSubscribeToken(ctx, userID, spoolChannel, uc.tokenTTL)
+		if err != nil {
+			return ConnectAndSubscribeTokens{}, err
+		}
+		channels[spoolChannel] = spoolSub
+	}
+
 	// thread channels in this spool
 	for _, id := range threads {
 		channel := fmt.Sprintf("thread#%d", id)
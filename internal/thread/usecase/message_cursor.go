@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+)
+
+// Курсор для клиента непрозрачный: внутри base64("before:<id>") или base64("after:<id>")
+const (
+	cursorBefore = "before"
+	cursorAfter  = "after"
+)
+
+// MessagesPage — страница истории, сообщения идут по возрастанию id.
+// PrevCursor ведёт к более старым сообщениям, NextCursor — к более новым; пустой — дальше ничего нет.
+type MessagesPage struct {
+	Messages   []gdomain.Message
+	PrevCursor string
+	NextCursor string
+}
+
+func encodeCursor(direction string, id uint) string {
+	raw := direction + ":" + strconv.FormatUint(uint64(id), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (string, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	direction, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok || (direction != cursorBefore && direction != cursorAfter) {
+		return "", 0, fmt.Errorf("unknown cursor %q", raw)
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		return "", 0, fmt.Errorf("bad cursor id %q", idStr)
+	}
+	return direction, uint(id), nil
+}
+
+// pageCursors проставляет курсоры по крайним сообщениям страницы
+func pageCursors(page *MessagesPage, hasOlder, hasNewer bool) {
+	if len(page.Messages) == 0 {
+		return
+	}
+	if hasOlder {
+		page.PrevCursor = encodeCursor(cursorBefore, page.Messages[0].ID)
+	}
+	if hasNewer {
+		page.NextCursor = encodeCursor(cursorAfter, page.Messages[len(page.Messages)-1].ID)
+	}
+}
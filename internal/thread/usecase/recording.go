@@ -0,0 +1,355 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	fileUsecase "github.com/onionfriend2004/threadbook_backend/internal/file/usecase"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
+	repo "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+// recordingMessage — текст сообщения, к которому прикладывается готовая запись
+const recordingMessage = "Запись голосового канала"
+
+type RecordingUsecaseInterface interface {
+	StartRecording(ctx context.Context, input StartRecordingInput) (*gdomain.VoiceRecording, error)
+	StopRecording(ctx context.Context, input StopRecordingInput) (*gdomain.VoiceRecording, error)
+	// HandleEgressEnded — вебхук egress_ended: прикладываем файл в тред или помечаем запись проваленной
+	HandleEgressEnded(ctx context.Context, input EgressEndedInput) error
+}
+
+type RecordingUsecase struct {
+	recordingRepo repo.RecordingRepoInterface
+	threadRepo    repo.ThreadRepoInterface
+	sfuRepo       repo.SFUInterface
+	egressRepo    repo.EgressInterface
+	wsRepo        repo.WebsocketRepoInterface
+	messageUC     *MessageUsecase
+	fileUC        fileUsecase.FileUsecaseInterface // бакет вложений: запись становится обычным вложением сообщения
+	tx            dbtx.TransactorInterface
+	logger        *zap.Logger
+}
+
+func NewRecordingUsecase(
+	recordingRepo repo.RecordingRepoInterface,
+	threadRepo repo.ThreadRepoInterface,
+	sfuRepo repo.SFUInterface,
+	egressRepo repo.EgressInterface,
+	wsRepo repo.WebsocketRepoInterface,
+	messageUC *MessageUsecase,
+	fileUC fileUsecase.FileUsecaseInterface,
+	tx dbtx.TransactorInterface,
+	logger *zap.Logger,
+) RecordingUsecaseInterface {
+	return &RecordingUsecase{
+		recordingRepo: recordingRepo,
+		threadRepo:    threadRepo,
+		sfuRepo:       sfuRepo,
+		egressRepo:    egressRepo,
+		wsRepo:        wsRepo,
+		messageUC:     messageUC,
+		fileUC:        fileUC,
+		tx:            tx,
+		logger:        logger,
+	}
+}
+
+func (u *RecordingUsecase) StartRecording(ctx context.Context, input StartRecordingInput) (*gdomain.VoiceRecording, error) {
+	if input.ThreadID == 0 || input.Username == "" {
+		return nil, ErrInvalidInput
+	}
+	// egress умеет лить только в S3 — без MinIO записывать некуда
+	if u.egressRepo == nil {
+		return nil, ErrRecordingUnavailable
+	}
+	thread, err := u.moderatedThread(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.IsClosed {
+		return nil, ErrThreadClosed
+	}
+
+	active, err := u.activeRecording(ctx, thread.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrRecordingInProgress
+	}
+
+	// пустую комнату egress не запишет, а висеть будет
+	roomName := VoiceRoomName(thread.ID)
+	participants, err := u.sfuRepo.ListParticipants(ctx, roomName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list room participants: %w", err)
+	}
+	if len(participants) == 0 {
+		return nil, ErrVoiceRoomEmpty
+	}
+
+	objectKey := fmt.Sprintf("recordings/%s/%s%s", roomName, time.Now().UTC().Format("20060102T150405Z"), recordingExt(u.egressRepo.ContentType()))
+	egressID, err := u.egressRepo.StartRoomRecording(ctx, roomName, objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start egress: %w", err)
+	}
+
+	rec := &gdomain.VoiceRecording{
+		ThreadID:          thread.ID,
+		StartedBy:         input.UserID,
+		StartedByUsername: input.Username,
+		EgressID:          egressID,
+		Status:            gdomain.RecordingActive,
+		Bucket:            u.fileUC.GetBucketName(),
+		FileLink:          objectKey,
+	}
+
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.recordingRepo.Create(ctx, rec); err != nil {
+			return err
+		}
+
+		// Всех в треде предупреждаем, что идёт запись (LiveKit ещё и сам выставит room.active_recording)
+		ev := event.New(event.VoiceRecordingStartedPayload{
+			ThreadID:    thread.ID,
+			RecordingID: rec.ID,
+			StartedBy:   input.Username,
+			StartedAt:   rec.StartedAt.Unix(),
+		}, threadScope(thread), event.UserActor(input.UserID, input.Username))
+		if err := u.wsRepo.PublishToThread(ctx, thread.ID, ev); err != nil {
+			return fmt.Errorf("failed to enqueue recording started event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// параллельный старт успел первым — наш egress никому не нужен
+		if stopErr := u.egressRepo.StopEgress(context.WithoutCancel(ctx), egressID); stopErr != nil && !errors.Is(stopErr, repo.ErrEgressNotActive) {
+			u.logger.Error("failed to stop orphan egress", zap.String("egress_id", egressID), zap.Error(stopErr))
+		}
+		if errors.Is(err, repo.ErrRecordingExists) {
+			return nil, ErrRecordingInProgress
+		}
+		return nil, fmt.Errorf("failed to save recording: %w", err)
+	}
+
+	u.logger.Info("voice recording started",
+		zap.Uint("thread_id", thread.ID),
+		zap.Uint("recording_id", rec.ID),
+		zap.String("egress_id", egressID),
+		zap.Uint("user_id", input.UserID),
+	)
+	return rec, nil
+}
+
+// StopRecording просит egress остановиться. Файл появится в треде, когда придёт egress_ended
+func (u *RecordingUsecase) StopRecording(ctx context.Context, input StopRecordingInput) (*gdomain.VoiceRecording, error) {
+	if input.ThreadID == 0 {
+		return nil, ErrInvalidInput
+	}
+	thread, err := u.moderatedThread(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := u.activeRecording(ctx, thread.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, ErrNoActiveRecording
+	}
+	if rec.Status == gdomain.RecordingStopping {
+		return rec, nil
+	}
+
+	if err := u.egressRepo.StopEgress(ctx, rec.EgressID); err != nil && !errors.Is(err, repo.ErrEgressNotActive) {
+		return nil, fmt.Errorf("failed to stop egress: %w", err)
+	}
+
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		rec.Status = gdomain.RecordingStopping
+		if err := u.recordingRepo.Update(ctx, rec); err != nil {
+			return fmt.Errorf("failed to update recording: %w", err)
+		}
+
+		ev := event.New(event.VoiceRecordingStoppedPayload{
+			ThreadID:    thread.ID,
+			RecordingID: rec.ID,
+			Status:      rec.Status,
+		}, threadScope(thread), event.UserActor(input.UserID, ""))
+		if err := u.wsRepo.PublishToThread(ctx, thread.ID, ev); err != nil {
+			return fmt.Errorf("failed to enqueue recording stopped event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (u *RecordingUsecase) HandleEgressEnded(ctx context.Context, input EgressEndedInput) error {
+	if input.EgressID == "" {
+		return ErrInvalidInput
+	}
+
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		rec, err := u.recordingRepo.GetByEgressIDForUpdate(ctx, input.EgressID)
+		if err != nil {
+			return fmt.Errorf("failed to get recording: %w", err)
+		}
+		// чужой egress или повтор вебхука
+		if rec == nil || rec.IsFinished() {
+			return nil
+		}
+
+		now := time.Now()
+		rec.EndedAt = &now
+		rec.Size = input.Size
+		rec.DurationMs = input.DurationMs
+		if input.ObjectKey != "" {
+			rec.FileLink = input.ObjectKey
+		}
+
+		completed := input.Completed && input.Size > 0
+		if completed {
+			// Объект положил egress, а не SaveFile — метаданные заводим сами, иначе файл никто не скачает
+			err := u.fileUC.RegisterFile(ctx, fileUsecase.RegisterFileInput{
+				Filename:    rec.FileLink,
+				OwnerID:     rec.StartedBy,
+				Kind:        gdomain.FileKindRecording,
+				ThreadID:    &rec.ThreadID,
+				ContentType: u.egressRepo.ContentType(),
+				Size:        rec.Size,
+			})
+			switch {
+			case errors.Is(err, fileUsecase.ErrQuotaExceeded):
+				// файл уже удалён, запись заканчивается неудачей, а не повторами вебхука
+				completed = false
+				input.Error = err.Error()
+			case err != nil:
+				return fmt.Errorf("failed to register recording file: %w", err)
+			}
+		}
+
+		if completed {
+			msg, err := u.messageUC.SendRecording(ctx, SendMessageInput{
+				UserID:   rec.StartedBy,
+				Username: rec.StartedByUsername,
+				ThreadID: rec.ThreadID,
+				Content:  recordingMessage,
+				Payloads: []gdomain.MessagePayload{{
+					ThreadID:    rec.ThreadID,
+					UploaderID:  rec.StartedBy,
+					Bucket:      rec.Bucket,
+					FileLink:    rec.FileLink,
+					Filename:    path.Base(rec.FileLink),
+					ContentType: u.egressRepo.ContentType(),
+					Size:        rec.Size,
+				}},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to attach recording: %w", err)
+			}
+			rec.Status = gdomain.RecordingCompleted
+			rec.MessageID = &msg.ID
+		} else {
+			rec.Status = gdomain.RecordingFailed
+			rec.Error = input.Error
+		}
+
+		if err := u.recordingRepo.Update(ctx, rec); err != nil {
+			return fmt.Errorf("failed to update recording: %w", err)
+		}
+
+		thread, err := u.threadRepo.GetThreadByID(ctx, rec.ThreadID)
+		if err != nil {
+			return fmt.Errorf("failed to get thread: %w", err)
+		}
+		payload := event.VoiceRecordingStoppedPayload{
+			ThreadID:    rec.ThreadID,
+			RecordingID: rec.ID,
+			Status:      rec.Status,
+		}
+		if rec.MessageID != nil {
+			payload.MessageID = *rec.MessageID
+		}
+		if err := u.wsRepo.PublishToThread(ctx, rec.ThreadID, event.New(payload, threadScope(thread), nil)); err != nil {
+			return fmt.Errorf("failed to enqueue recording stopped event: %w", err)
+		}
+
+		u.logger.Info("voice recording finished",
+			zap.Uint("thread_id", rec.ThreadID),
+			zap.Uint("recording_id", rec.ID),
+			zap.String("status", rec.Status),
+			zap.String("error", rec.Error),
+		)
+		return nil
+	})
+}
+
+// activeRecording — незавершённая запись треда или nil.
+// Вебхук egress_ended мог потеряться, тогда запись висела бы активной вечно:
+// сверяемся с LiveKit и доводим такую запись до провала сами
+func (u *RecordingUsecase) activeRecording(ctx context.Context, threadID uint) (*gdomain.VoiceRecording, error) {
+	rec, err := u.recordingRepo.GetActiveByThreadID(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active recording: %w", err)
+	}
+	if rec == nil || rec.EgressID == "" || u.egressRepo == nil {
+		return rec, nil
+	}
+
+	active, err := u.egressRepo.IsEgressActive(ctx, rec.EgressID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check egress: %w", err)
+	}
+	if active {
+		return rec, nil
+	}
+
+	u.logger.Warn("egress is gone without egress_ended, failing recording",
+		zap.Uint("thread_id", threadID),
+		zap.Uint("recording_id", rec.ID),
+		zap.String("egress_id", rec.EgressID),
+	)
+	// вебхук мог прийти прямо сейчас — HandleEgressEnded под блокировкой строки это переживёт
+	err = u.HandleEgressEnded(ctx, EgressEndedInput{EgressID: rec.EgressID, Error: "egress_ended webhook was lost"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail stale recording: %w", err)
+	}
+	return nil, nil
+}
+
+// moderatedThread — запись включают и выключают только создатель треда или спула
+func (u *RecordingUsecase) moderatedThread(ctx context.Context, threadID, userID uint) (*gdomain.Thread, error) {
+	thread, err := u.threadRepo.GetThreadByID(ctx, threadID)
+	if err != nil {
+		if errors.Is(err, repo.ErrThreadNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+	ok, err := u.threadRepo.IsThreadModerator(ctx, threadID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check moderator rights: %w", err)
+	}
+	if !ok {
+		return nil, ErrNotThreadModerator
+	}
+	return thread, nil
+}
+
+func recordingExt(contentType string) string {
+	if contentType == "audio/ogg" {
+		return ".ogg"
+	}
+	return ".mp4"
+}
@@ -3,28 +3,59 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	liveKitAuth "github.com/livekit/protocol/auth"
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
 	repo "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
 	"go.uber.org/zap"
 )
 
-var (
-	CanPublish     = true
-	CanSubscribe   = true
-	CanPublishData = true
+// Атрибуты участника в LiveKit, их видят все в комнате
+const (
+	attrUserID     = "user_id"     // по нему вебхуки LiveKit связывают участника с пользователем (см. presence VoiceHandler)
+	attrSpoolRole  = "spool_role"  // owner / moderator / member
+	attrVoiceRole  = "voice_role"  // speaker / listener
+	attrHandRaised = "hand_raised" // "true", пока слушатель просит слово
+)
+
+const (
+	voiceRoleSpeaker  = "speaker"
+	voiceRoleListener = "listener"
 )
 
 type RoomUsecaseInterface interface {
-	GetVoiceToken(ctx context.Context, input GetVoiceTokenInput) (string, error)
+	GetVoiceToken(ctx context.Context, input GetVoiceTokenInput) (*VoiceToken, error)
+	ListVoiceParticipants(ctx context.Context, input VoiceRoomInput) ([]repo.SFUParticipant, error)
+	MuteVoiceParticipant(ctx context.Context, input VoiceParticipantInput) (int, error)
+	KickVoiceParticipant(ctx context.Context, input VoiceParticipantInput) error
+	EndVoiceRoom(ctx context.Context, input VoiceRoomInput) error
+
+	SetStageMode(ctx context.Context, input SetStageModeInput) error
+	RaiseHand(ctx context.Context, input RaiseHandInput) error
+	PromoteVoiceParticipant(ctx context.Context, input VoiceParticipantInput) error
+	DemoteVoiceParticipant(ctx context.Context, input VoiceParticipantInput) error
+}
+
+// VoiceRoleGrant — что роли можно публиковать и сколько живёт её токен
+type VoiceRoleGrant struct {
+	TokenTTL time.Duration
+	Sources  []string
 }
+
+type VoiceGrants struct {
+	Roles           map[gdomain.SpoolRole]VoiceRoleGrant
+	StageMinMembers int // 0 — сцену включают только руками
+}
+
 type RoomUsecase struct {
 	threadRepo  repo.ThreadRepoInterface
 	liveKitRepo repo.SFUInterface
 	liveKitURL  string
 	apiKey      string
 	apiSecret   string
+	grants      VoiceGrants
 	logger      *zap.Logger
 }
 
@@ -32,6 +63,7 @@ func NewRoomUsecase(
 	threadRepo repo.ThreadRepoInterface,
 	liveKitRepo repo.SFUInterface,
 	liveKitURL, apiKey, apiSecret string,
+	grants VoiceGrants,
 	logger *zap.Logger,
 ) RoomUsecaseInterface {
 	return &RoomUsecase{
@@ -40,46 +72,120 @@ func NewRoomUsecase(
 		liveKitURL:  liveKitURL,
 		apiKey:      apiKey,
 		apiSecret:   apiSecret,
+		grants:      grants,
 		logger:      logger,
 	}
 }
 
-func (u *RoomUsecase) GetVoiceToken(ctx context.Context, input GetVoiceTokenInput) (string, error) {
+// VoiceRoomName — имя комнаты LiveKit для треда, на него же завязан разбор вебхуков в presence
+func VoiceRoomName(threadID uint) string {
+	return fmt.Sprintf("thread_%d", threadID)
+}
+
+func (u *RoomUsecase) GetVoiceToken(ctx context.Context, input GetVoiceTokenInput) (*VoiceToken, error) {
 	if input.Username == "" || input.ThreadID <= 0 {
-		return "", ErrInvalidInput
+		return nil, ErrInvalidInput
 	}
 
 	thread, err := u.threadRepo.GetThreadByID(ctx, input.ThreadID)
 	if err != nil {
-		return "", ErrThreadNotFound
+		return nil, ErrThreadNotFound
 	}
 
 	hasRights, err := u.threadRepo.CheckRightsUserOnThreadRoom(ctx, thread.ID, input.UserID)
 	if !hasRights || err != nil {
-		return "", ErrNoRightsOnJoinRoom
+		return nil, ErrNoRightsOnJoinRoom
+	}
+	// комнату закрытого треда уже снесли при закрытии, не даём поднять её заново
+	if thread.IsClosed {
+		return nil, ErrThreadClosed
+	}
+
+	role, err := u.threadRepo.GetSpoolRole(ctx, thread.ID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spool role: %w", err)
+	}
+	stage, err := u.isStage(ctx, thread)
+	if err != nil {
+		return nil, err
 	}
+	// на сцене участники заходят слушателями, говорить им разрешает модератор
+	speaker := !stage || role != gdomain.SpoolRoleMember
 
-	roomName := fmt.Sprintf("thread_%d", input.ThreadID)
+	roomName := VoiceRoomName(input.ThreadID)
 
 	if err := u.liveKitRepo.EnsureRoom(ctx, roomName); err != nil {
-		return "", ErrFaildToEnsureRoom
+		return nil, ErrFaildToEnsureRoom
 	}
 
+	roleGrant := u.grants.Roles[role]
+	perm := u.permissionFor(role, speaker)
+
 	token := liveKitAuth.NewAccessToken(u.apiKey, u.apiSecret)
 
 	grant := &liveKitAuth.VideoGrant{
 		RoomJoin:          true,
 		Room:              roomName,
-		CanPublish:        &CanPublish,
-		CanPublishData:    &CanPublishData,
-		CanSubscribe:      &CanSubscribe,
-		CanPublishSources: []string{"camera", "microphone", "screen"},
+		CanPublish:        &perm.CanPublish,
+		CanPublishData:    &perm.CanPublishData, // чат/реакции в комнате; руку поднимают через RaiseHand, свои атрибуты клиент не пишет
+		CanSubscribe:      &perm.CanSubscribe,
+		CanPublishSources: perm.Sources,
 	}
 
-	// TODO: подумать над длительностью токена, захардкожу 15 минут
 	token.SetVideoGrant(grant).
 		SetIdentity(input.Username).
-		SetValidFor(15 * time.Minute)
+		SetAttributes(map[string]string{
+			attrUserID:    strconv.FormatUint(uint64(input.UserID), 10),
+			attrSpoolRole: string(role),
+			attrVoiceRole: voiceRoleName(speaker),
+		}).
+		SetValidFor(roleGrant.TokenTTL)
 
-	return token.ToJWT()
+	jwt, err := token.ToJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoiceToken{
+		Token:     jwt,
+		Role:      role,
+		Speaker:   speaker,
+		Stage:     stage,
+		ExpiresAt: time.Now().Add(roleGrant.TokenTTL),
+	}, nil
+}
+
+// isStage — сцена включена руками или тред разросся до порога из конфига
+func (u *RoomUsecase) isStage(ctx context.Context, thread *gdomain.Thread) (bool, error) {
+	if thread.StageMode {
+		return true, nil
+	}
+	if u.grants.StageMinMembers <= 0 {
+		return false, nil
+	}
+	members, err := u.threadRepo.GetThreadMembers(ctx, thread.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get thread members: %w", err)
+	}
+	return len(members) >= u.grants.StageMinMembers, nil
+}
+
+// permissionFor — права в комнате: спикер публикует то, что разрешено его роли, слушатель только слушает
+func (u *RoomUsecase) permissionFor(role gdomain.SpoolRole, speaker bool) *repo.SFUPermission {
+	perm := &repo.SFUPermission{
+		CanSubscribe:   true,
+		CanPublishData: true,
+		CanPublish:     speaker,
+	}
+	if speaker {
+		perm.Sources = u.grants.Roles[role].Sources
+	}
+	return perm
+}
+
+func voiceRoleName(speaker bool) string {
+	if speaker {
+		return voiceRoleSpeaker
+	}
+	return voiceRoleListener
 }
@@ -8,6 +8,7 @@ import (
 
 	userexternal "github.com/onionfriend2004/threadbook_backend/internal/auth/external"
 	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/lib/dbtx"
 	"github.com/onionfriend2004/threadbook_backend/internal/lib/event"
 	"github.com/onionfriend2004/threadbook_backend/internal/thread/external"
 	"go.uber.org/zap"
@@ -15,7 +16,7 @@ import (
 
 type ThreadUsecaseInterface interface {
 	CreateThread(ctx context.Context, input CreateThreadInput) (*gdomain.Thread, error)
-	GetBySpoolID(ctx context.Context, input GetBySpoolIDInput) ([]*gdomain.Thread, error)
+	GetBySpoolID(ctx context.Context, input GetBySpoolIDInput) ([]gdomain.ThreadWithUnread, error)
 	CloseThread(ctx context.Context, input CloseThreadInput) (*gdomain.Thread, error)
 	InviteToThread(ctx context.Context, input InviteToThreadInput) error
 	UpdateThread(ctx context.Context, input UpdateThreadInput) (*gdomain.Thread, error)
@@ -25,6 +26,8 @@ type ThreadUsecase struct {
 	threadRepo external.ThreadRepoInterface
 	wsRepo     external.WebsocketRepoInterface
 	userRepo   userexternal.UserRepoInterface
+	sfuRepo    external.SFUInterface    // при закрытии треда сносим его голосовую комнату
+	tx         dbtx.TransactorInterface // события пишутся в outbox в той же транзакции, что и изменения
 	tokenTTL   time.Duration
 	logger     *zap.Logger
 }
@@ -33,6 +36,8 @@ func NewThreadUsecase(
 	threadRepo external.ThreadRepoInterface,
 	wsRepo external.WebsocketRepoInterface,
 	userRepo userexternal.UserRepoInterface,
+	sfuRepo external.SFUInterface,
+	tx dbtx.TransactorInterface,
 	tokenTTL time.Duration,
 	logger *zap.Logger,
 ) ThreadUsecaseInterface {
@@ -40,6 +45,8 @@ func NewThreadUsecase(
 		threadRepo: threadRepo,
 		wsRepo:     wsRepo,
 		userRepo:   userRepo,
+		sfuRepo:    sfuRepo,
+		tx:         tx,
 		tokenTTL:   tokenTTL,
 		logger:     logger,
 	}
@@ -50,118 +57,163 @@ func (u *ThreadUsecase) CreateThread(ctx context.Context, input CreateThreadInpu
 		return nil, ErrWrognTypeThread
 	}
 
-	newThread, err := u.threadRepo.Create(ctx, input.OwnerID, input.SpoolID, input.Title, input.TypeThread)
-	if err != nil {
-		return nil, err
-	}
-
-	threadChannel := fmt.Sprintf("thread#%d", newThread.ID)
+	var newThread *gdomain.Thread
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		newThread, err = u.threadRepo.Create(ctx, input.OwnerID, input.SpoolID, input.Title, input.TypeThread)
+		if err != nil {
+			return err
+		}
 
-	subToken, err := u.wsRepo.GenerateSubscribeToken(ctx, input.OwnerID, threadChannel, u.tokenTTL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate subscribe token: %w", err)
-	}
+		threadChannel := fmt.Sprintf("thread#%d", newThread.ID)
 
-	members, err := u.threadRepo.GetThreadMembers(ctx, newThread.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get thread members: %w", err)
-	}
-
-	eventPayload := event.ThreadCreatedPayload{
-		ThreadID:       newThread.ID,
-		Title:          newThread.Title,
-		CreatedAt:      newThread.CreatedAt.Unix(),
-		Channel:        threadChannel,
-		Token:          subToken,
-		SubscribeToken: subToken,
-	}
+		subToken, err := u.wsRepo.GenerateSubscribeToken(ctx, input.OwnerID, threadChannel, u.tokenTTL)
+		if err != nil {
+			return fmt.Errorf("failed to generate subscribe token: %w", err)
+		}
 
-	for _, member := range members {
-		if err := u.wsRepo.PublishToUser(ctx, member.UserID, event.Event{
-			Type:    event.ThreadCreated,
-			Payload: eventPayload,
-		}); err != nil {
-			u.logger.Warn("failed to publish thread created event", zap.Uint("userID", member.UserID), zap.Error(err))
+		members, err := u.threadRepo.GetThreadMembers(ctx, newThread.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get thread members: %w", err)
 		}
-	}
 
-	return newThread, nil
-}
+		eventPayload := event.ThreadCreatedPayload{
+			ThreadID:       newThread.ID,
+			Title:          newThread.Title,
+			CreatedAt:      newThread.CreatedAt.Unix(),
+			Channel:        threadChannel,
+			Token:          subToken,
+			SubscribeToken: subToken,
+		}
 
-func (u *ThreadUsecase) GetBySpoolID(ctx context.Context, input GetBySpoolIDInput) ([]*gdomain.Thread, error) {
-	newThread, err := u.threadRepo.GetBySpoolID(ctx, input.UserID, input.SpoolID)
+		ev := event.New(eventPayload, threadScope(newThread), event.UserActor(input.OwnerID, ""))
+		if err := u.wsRepo.PublishToUsers(ctx, memberIDs(members), ev); err != nil {
+			return fmt.Errorf("failed to enqueue thread created event: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
 	return newThread, nil
 }
 
-func (u *ThreadUsecase) CloseThread(ctx context.Context, input CloseThreadInput) (*gdomain.Thread, error) {
-	thread, err := u.threadRepo.CloseThread(input.ThreadID, input.UserID)
+func (u *ThreadUsecase) GetBySpoolID(ctx context.Context, input GetBySpoolIDInput) ([]gdomain.ThreadWithUnread, error) {
+	threads, err := u.threadRepo.GetBySpoolID(ctx, input.UserID, input.SpoolID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Получаем участников треда
-	members, err := u.threadRepo.GetThreadMembers(ctx, thread.ID)
+	// Счётчики непрочитанного одним запросом на весь список
+	ids := make([]uint, 0, len(threads))
+	for _, t := range threads {
+		ids = append(ids, t.ID)
+	}
+	unread, err := u.threadRepo.GetUnreadCounts(ctx, input.UserID, ids)
 	if err != nil {
-		u.logger.Warn("failed to get thread members for CloseThread event", zap.Error(err))
-		return thread, nil // возвращаем закрытый тред даже если событие не отправилось
+		return nil, fmt.Errorf("failed to get unread counts: %w", err)
 	}
 
-	// Подготавливаем payload события
-	payload := event.ThreadClosedPayload{
-		ThreadID: thread.ID,
+	result := make([]gdomain.ThreadWithUnread, 0, len(threads))
+	for _, t := range threads {
+		result = append(result, gdomain.ThreadWithUnread{
+			Thread: t,
+			Unread: unread[t.ID],
+		})
 	}
+	return result, nil
+}
 
-	// Рассылаем событие всем участникам
-	for _, member := range members {
-		if err := u.wsRepo.PublishToUser(ctx, member.UserID, event.Event{
-			Type:    event.ThreadDeleted,
-			Payload: payload,
-		}); err != nil {
-			u.logger.Warn("failed to publish ThreadDeleted event", zap.Uint("userID", member.UserID), zap.Error(err))
+func (u *ThreadUsecase) CloseThread(ctx context.Context, input CloseThreadInput) (*gdomain.Thread, error) {
+	var thread *gdomain.Thread
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		thread, err = u.threadRepo.CloseThread(ctx, input.ThreadID, input.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Получаем участников треда
+		members, err := u.threadRepo.GetThreadMembers(ctx, thread.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get thread members: %w", err)
+		}
+
+		// Подготавливаем payload события
+		payload := event.ThreadClosedPayload{
+			ThreadID: thread.ID,
 		}
+
+		// Рассылаем событие всем участникам одним broadcast'ом
+		ev := event.New(payload, threadScope(thread), event.UserActor(input.UserID, ""))
+		if err := u.wsRepo.PublishToUsers(ctx, memberIDs(members), ev); err != nil {
+			return fmt.Errorf("failed to enqueue ThreadClosed event: %w", err)
+		}
+
+		// комнату трогаем только когда закрытие точно закоммичено
+		threadID := thread.ID
+		dbtx.AfterCommit(ctx, func() {
+			u.teardownVoiceRoom(ctx, threadID)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return thread, nil
 }
 
-func (u *ThreadUsecase) InviteToThread(ctx context.Context, input InviteToThreadInput) error {
-	// Добавляем пользователей в тред через репозиторий
-	if err := u.threadRepo.InviteToThread(ctx, input.InviterID, input.InviteeUsernames, input.ThreadID); err != nil {
-		return err
+// teardownVoiceRoom выкидывает всех из комнаты закрытого треда.
+// Best effort: тред уже закрыт, а пустую комнату LiveKit всё равно прибьёт по EmptyTimeout
+func (u *ThreadUsecase) teardownVoiceRoom(ctx context.Context, threadID uint) {
+	err := u.sfuRepo.DeleteRoom(context.WithoutCancel(ctx), VoiceRoomName(threadID))
+	if err != nil && !errors.Is(err, external.ErrRoomNotFound) {
+		u.logger.Warn("failed to delete voice room of closed thread", zap.Uint("thread_id", threadID), zap.Error(err))
 	}
+}
 
-	threadChannel := fmt.Sprintf("thread#%d", input.ThreadID)
-
-	for _, username := range input.InviteeUsernames {
-		user, err := u.userRepo.GetUserByUsername(ctx, username)
-		if err != nil {
-			u.logger.Warn("failed to get user ID by username", zap.String("username", username), zap.Error(err))
-			continue // не блокируем остальных пользователей
+func (u *ThreadUsecase) InviteToThread(ctx context.Context, input InviteToThreadInput) error {
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Добавляем пользователей в тред через репозиторий
+		if err := u.threadRepo.InviteToThread(ctx, input.InviterID, input.InviteeUsernames, input.ThreadID); err != nil {
+			return err
 		}
 
-		subToken, err := u.wsRepo.GenerateSubscribeToken(ctx, user.ID, threadChannel, u.tokenTTL)
+		thread, err := u.threadRepo.GetThreadByID(ctx, input.ThreadID)
 		if err != nil {
-			u.logger.Warn("failed to generate subscribe token for invited user", zap.String("username", username), zap.Error(err))
-			continue
-		}
-
-		payload := event.ThreadSubTokenPayload{
-			Channel: threadChannel,
-			Token:   subToken,
+			return fmt.Errorf("failed to get thread: %w", err)
 		}
-
-		if err := u.wsRepo.PublishToUser(ctx, user.ID, event.Event{
-			Type:    event.ThreadInvited,
-			Payload: payload,
-		}); err != nil {
-			u.logger.Warn("failed to publish ThreadInvited event", zap.String("username", username), zap.Error(err))
+		threadChannel := fmt.Sprintf("thread#%d", input.ThreadID)
+
+		for _, username := range input.InviteeUsernames {
+			user, err := u.userRepo.GetUserByUsername(ctx, username)
+			if err != nil {
+				u.logger.Warn("failed to get user ID by username", zap.String("username", username), zap.Error(err))
+				continue // не блокируем остальных пользователей
+			}
+
+			subToken, err := u.wsRepo.GenerateSubscribeToken(ctx, user.ID, threadChannel, u.tokenTTL)
+			if err != nil {
+				u.logger.Warn("failed to generate subscribe token for invited user", zap.String("username", username), zap.Error(err))
+				continue
+			}
+
+			payload := event.ThreadInvitePayload{
+				ThreadID: thread.ID,
+				Title:    thread.Title,
+				Channel:  threadChannel,
+				Token:    subToken,
+			}
+
+			ev := event.New(payload, threadScope(thread), event.UserActor(input.InviterID, ""))
+			if err := u.wsRepo.PublishToUser(ctx, user.ID, ev); err != nil {
+				return fmt.Errorf("failed to enqueue ThreadInvited event: %w", err)
+			}
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func (u *ThreadUsecase) UpdateThread(ctx context.Context, input UpdateThreadInput) (*gdomain.Thread, error) {
@@ -172,34 +224,51 @@ func (u *ThreadUsecase) UpdateThread(ctx context.Context, input UpdateThreadInpu
 		return nil, errors.New("editor id is required")
 	}
 
-	updatedThread, err := u.threadRepo.Update(ctx, input.ID, input.EditorID, input.Title, input.ThreadType)
-	if err != nil {
-		return nil, err
-	}
+	var updatedThread *gdomain.Thread
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		updatedThread, err = u.threadRepo.Update(ctx, input.ID, input.EditorID, input.Title, input.ThreadType)
+		if err != nil {
+			return err
+		}
+
+		// Получаем участников треда
+		members, err := u.threadRepo.GetThreadMembers(ctx, updatedThread.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get thread members: %w", err)
+		}
+
+		// Подготавливаем payload события
+		payload := event.ThreadUpdatedPayload{
+			ThreadID:  updatedThread.ID,
+			Title:     updatedThread.Title,
+			UpdatedAt: updatedThread.UpdatedAt.Unix(),
+		}
 
-	// Получаем участников треда
-	members, err := u.threadRepo.GetThreadMembers(ctx, updatedThread.ID)
+		// Рассылаем событие всем участникам одним broadcast'ом
+		ev := event.New(payload, threadScope(updatedThread), event.UserActor(input.EditorID, ""))
+		if err := u.wsRepo.PublishToUsers(ctx, memberIDs(members), ev); err != nil {
+			return fmt.Errorf("failed to enqueue ThreadUpdated event: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		u.logger.Warn("failed to get thread members for ThreadUpdated event", zap.Error(err))
-		return updatedThread, nil
+		return nil, err
 	}
 
-	// Подготавливаем payload события
-	payload := event.ThreadUpdatedPayload{
-		ThreadID:  updatedThread.ID,
-		Title:     updatedThread.Title,
-		UpdatedAt: updatedThread.UpdatedAt.Unix(),
-	}
+	return updatedThread, nil
+}
 
-	// Рассылаем событие всем участникам
+// memberIDs — id участников для рассылки по личным каналам
+func memberIDs(members []gdomain.ThreadUser) []uint {
+	ids := make([]uint, 0, len(members))
 	for _, member := range members {
-		if err := u.wsRepo.PublishToUser(ctx, member.UserID, event.Event{
-			Type:    event.ThreadUpdated,
-			Payload: payload,
-		}); err != nil {
-			u.logger.Warn("failed to publish ThreadUpdated event", zap.Uint("userID", member.UserID), zap.Error(err))
-		}
+		ids = append(ids, member.UserID)
 	}
+	return ids
+}
 
-	return updatedThread, nil
+// threadScope — scope событий треда
+func threadScope(thread *gdomain.Thread) event.Scope {
+	return event.Scope{SpoolID: thread.SpoolID, ThreadID: thread.ID}
 }
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	"github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+)
+
+// ThreadEventsPage — кусок журнала треда по возрастанию seq.
+// LastSeq — последний seq треда на момент запроса; HasMore — за страницей есть ещё события.
+type ThreadEventsPage struct {
+	Events  []gdomain.ThreadEvent
+	LastSeq uint64
+	HasMore bool
+}
+
+// GetThreadEvents — догонялка после реконнекта: всё, что случилось в треде после SinceSeq.
+// Клиент повторяет запрос с seq последнего события, пока HasMore.
+func (uc *MessageUsecase) GetThreadEvents(ctx context.Context, input GetThreadEventsInput) (*ThreadEventsPage, error) {
+	if input.Limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	hasRights, err := uc.threadRepo.CheckRightsUserOnThreadRoom(ctx, input.ThreadID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rights: %w", err)
+	}
+	if !hasRights {
+		return nil, ErrNoAccessToThread
+	}
+
+	// LastSeq читаем до событий: всё, что закоммитят между запросами, клиент заберёт следующим
+	thread, err := uc.threadRepo.GetThreadByID(ctx, input.ThreadID)
+	if err != nil {
+		if errors.Is(err, external.ErrThreadNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	events, err := uc.msgRepo.GetThreadEventsSince(ctx, input.ThreadID, input.SinceSeq, input.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch thread events: %w", err)
+	}
+	hasMore := len(events) > input.Limit
+	if hasMore {
+		events = events[:input.Limit]
+	}
+
+	lastSeq := thread.LastSeq
+	if n := len(events); n > 0 && events[n-1].Seq > lastSeq {
+		lastSeq = events[n-1].Seq
+	}
+
+	return &ThreadEventsPage{Events: events, LastSeq: lastSeq, HasMore: hasMore}, nil
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	repo "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+// ListVoiceParticipants — живой состав комнаты прямо из LiveKit, вместе с треками.
+// Смотреть может любой, кто может зайти в комнату
+func (u *RoomUsecase) ListVoiceParticipants(ctx context.Context, input VoiceRoomInput) ([]repo.SFUParticipant, error) {
+	if input.ThreadID == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	thread, err := u.threadRepo.GetThreadByID(ctx, input.ThreadID)
+	if err != nil {
+		if errors.Is(err, repo.ErrThreadNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	hasRights, err := u.threadRepo.CheckRightsUserOnThreadRoom(ctx, thread.ID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check room rights: %w", err)
+	}
+	if !hasRights {
+		return nil, ErrNoRightsOnJoinRoom
+	}
+
+	participants, err := u.liveKitRepo.ListParticipants(ctx, VoiceRoomName(thread.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list room participants: %w", err)
+	}
+	return participants, nil
+}
+
+func (u *RoomUsecase) MuteVoiceParticipant(ctx context.Context, input VoiceParticipantInput) (int, error) {
+	if input.ThreadID == 0 || input.Username == "" {
+		return 0, ErrInvalidInput
+	}
+	if err := u.checkModerator(ctx, input.ThreadID, input.UserID); err != nil {
+		return 0, err
+	}
+
+	muted, err := u.liveKitRepo.MuteParticipant(ctx, VoiceRoomName(input.ThreadID), input.Username)
+	if err != nil {
+		if errors.Is(err, repo.ErrParticipantNotFound) {
+			return 0, ErrNotInVoiceRoom
+		}
+		return 0, fmt.Errorf("failed to mute participant: %w", err)
+	}
+
+	u.logger.Info("voice participant muted",
+		zap.Uint("thread_id", input.ThreadID),
+		zap.Uint("moderator_id", input.UserID),
+		zap.String("username", input.Username),
+		zap.Int("tracks", muted),
+	)
+	return muted, nil
+}
+
+func (u *RoomUsecase) KickVoiceParticipant(ctx context.Context, input VoiceParticipantInput) error {
+	if input.ThreadID == 0 || input.Username == "" {
+		return ErrInvalidInput
+	}
+	if err := u.checkModerator(ctx, input.ThreadID, input.UserID); err != nil {
+		return err
+	}
+
+	if err := u.liveKitRepo.RemoveParticipant(ctx, VoiceRoomName(input.ThreadID), input.Username); err != nil {
+		if errors.Is(err, repo.ErrParticipantNotFound) {
+			return ErrNotInVoiceRoom
+		}
+		return fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	u.logger.Info("voice participant kicked",
+		zap.Uint("thread_id", input.ThreadID),
+		zap.Uint("moderator_id", input.UserID),
+		zap.String("username", input.Username),
+	)
+	return nil
+}
+
+// EndVoiceRoom выкидывает всех и закрывает комнату. Комнаты и так нет — тоже ок
+func (u *RoomUsecase) EndVoiceRoom(ctx context.Context, input VoiceRoomInput) error {
+	if input.ThreadID == 0 {
+		return ErrInvalidInput
+	}
+	if err := u.checkModerator(ctx, input.ThreadID, input.UserID); err != nil {
+		return err
+	}
+
+	err := u.liveKitRepo.DeleteRoom(ctx, VoiceRoomName(input.ThreadID))
+	if err != nil && !errors.Is(err, repo.ErrRoomNotFound) {
+		return fmt.Errorf("failed to delete room: %w", err)
+	}
+
+	u.logger.Info("voice room ended",
+		zap.Uint("thread_id", input.ThreadID),
+		zap.Uint("moderator_id", input.UserID),
+	)
+	return nil
+}
+
+// checkModerator — модерировать комнату может создатель треда или создатель спула
+func (u *RoomUsecase) checkModerator(ctx context.Context, threadID, userID uint) error {
+	if _, err := u.threadRepo.GetThreadByID(ctx, threadID); err != nil {
+		if errors.Is(err, repo.ErrThreadNotFound) {
+			return ErrThreadNotFound
+		}
+		return fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	ok, err := u.threadRepo.IsThreadModerator(ctx, threadID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check moderator rights: %w", err)
+	}
+	if !ok {
+		return ErrNotThreadModerator
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/onionfriend2004/threadbook_backend/internal/gdomain"
+	repo "github.com/onionfriend2004/threadbook_backend/internal/thread/external"
+	"go.uber.org/zap"
+)
+
+// SetStageMode включает/выключает сцену и сразу перевешивает права тем, кто уже сидит в комнате
+func (u *RoomUsecase) SetStageMode(ctx context.Context, input SetStageModeInput) error {
+	if input.ThreadID == 0 {
+		return ErrInvalidInput
+	}
+	if err := u.checkModerator(ctx, input.ThreadID, input.UserID); err != nil {
+		return err
+	}
+
+	if err := u.threadRepo.SetStageMode(ctx, input.ThreadID, input.Enabled); err != nil {
+		if errors.Is(err, repo.ErrThreadNotFound) {
+			return ErrThreadNotFound
+		}
+		return fmt.Errorf("failed to set stage mode: %w", err)
+	}
+
+	thread, err := u.threadRepo.GetThreadByID(ctx, input.ThreadID)
+	if err != nil {
+		if errors.Is(err, repo.ErrThreadNotFound) {
+			return ErrThreadNotFound
+		}
+		return fmt.Errorf("failed to get thread: %w", err)
+	}
+	// выключили руками, а тред всё ещё больше порога — сцена остаётся
+	stage, err := u.isStage(ctx, thread)
+	if err != nil {
+		return err
+	}
+
+	roomName := VoiceRoomName(thread.ID)
+	participants, err := u.liveKitRepo.ListParticipants(ctx, roomName)
+	if err != nil {
+		// флаг уже сохранён, новые токены выдадутся правильно; текущих перевесить не смогли — не страшно
+		u.logger.Warn("failed to list participants for stage resync", zap.Uint("thread_id", thread.ID), zap.Error(err))
+		return nil
+	}
+
+	for _, p := range participants {
+		role := gdomain.SpoolRole(p.Attributes[attrSpoolRole])
+		if role != gdomain.SpoolRoleMember {
+			continue // владельцы и модераторы говорят всегда
+		}
+		speaker := !stage
+		attrs := map[string]string{attrVoiceRole: voiceRoleName(speaker)}
+		if speaker {
+			attrs[attrHandRaised] = ""
+		}
+		if err := u.liveKitRepo.UpdateParticipant(ctx, roomName, p.Identity, u.permissionFor(role, speaker), attrs); err != nil &&
+			!errors.Is(err, repo.ErrParticipantNotFound) {
+			u.logger.Warn("failed to resync participant permissions",
+				zap.Uint("thread_id", thread.ID),
+				zap.String("username", p.Identity),
+				zap.Error(err),
+			)
+		}
+	}
+
+	u.logger.Info("voice stage mode changed",
+		zap.Uint("thread_id", thread.ID),
+		zap.Uint("moderator_id", input.UserID),
+		zap.Bool("stage", stage),
+	)
+	return nil
+}
+
+// RaiseHand — слушатель просит слово. Это просто атрибут участника, LiveKit сам разошлёт его всем в комнате
+func (u *RoomUsecase) RaiseHand(ctx context.Context, input RaiseHandInput) error {
+	if input.ThreadID == 0 || input.Username == "" {
+		return ErrInvalidInput
+	}
+
+	hasRights, err := u.threadRepo.CheckRightsUserOnThreadRoom(ctx, input.ThreadID, input.UserID)
+	if !hasRights || err != nil {
+		return ErrNoRightsOnJoinRoom
+	}
+
+	value := ""
+	if input.Raised {
+		value = "true"
+	}
+
+	err = u.liveKitRepo.UpdateParticipant(ctx, VoiceRoomName(input.ThreadID), input.Username, nil, map[string]string{attrHandRaised: value})
+	if err != nil {
+		if errors.Is(err, repo.ErrParticipantNotFound) {
+			return ErrNotInVoiceRoom
+		}
+		return fmt.Errorf("failed to update hand: %w", err)
+	}
+	return nil
+}
+
+// PromoteVoiceParticipant даёт слушателю слово: права на публикацию по его роли, рука опускается
+func (u *RoomUsecase) PromoteVoiceParticipant(ctx context.Context, input VoiceParticipantInput) error {
+	return u.setSpeaker(ctx, input, true)
+}
+
+// DemoteVoiceParticipant возвращает спикера в слушатели, его треки LiveKit снимет сам
+func (u *RoomUsecase) DemoteVoiceParticipant(ctx context.Context, input VoiceParticipantInput) error {
+	return u.setSpeaker(ctx, input, false)
+}
+
+// Права живут только в текущей сессии LiveKit: перезайдёт с новым токеном — снова будет по правилам сцены
+func (u *RoomUsecase) setSpeaker(ctx context.Context, input VoiceParticipantInput, speaker bool) error {
+	if input.ThreadID == 0 || input.Username == "" {
+		return ErrInvalidInput
+	}
+	if err := u.checkModerator(ctx, input.ThreadID, input.UserID); err != nil {
+		return err
+	}
+
+	roomName := VoiceRoomName(input.ThreadID)
+	p, err := u.liveKitRepo.GetParticipant(ctx, roomName, input.Username)
+	if err != nil {
+		if errors.Is(err, repo.ErrParticipantNotFound) {
+			return ErrNotInVoiceRoom
+		}
+		return fmt.Errorf("failed to get participant: %w", err)
+	}
+
+	// роль берём из базы, а не из атрибутов — источники публикации зависят от неё
+	role := gdomain.SpoolRoleMember
+	if id, err := strconv.ParseUint(p.Attributes[attrUserID], 10, 64); err == nil {
+		if role, err = u.threadRepo.GetSpoolRole(ctx, input.ThreadID, uint(id)); err != nil {
+			return fmt.Errorf("failed to get spool role: %w", err)
+		}
+	}
+
+	attrs := map[string]string{
+		attrVoiceRole:  voiceRoleName(speaker),
+		attrHandRaised: "",
+	}
+	if err := u.liveKitRepo.UpdateParticipant(ctx, roomName, input.Username, u.permissionFor(role, speaker), attrs); err != nil {
+		if errors.Is(err, repo.ErrParticipantNotFound) {
+			return ErrNotInVoiceRoom
+		}
+		return fmt.Errorf("failed to update participant permissions: %w", err)
+	}
+
+	u.logger.Info("voice participant role changed",
+		zap.Uint("thread_id", input.ThreadID),
+		zap.Uint("moderator_id", input.UserID),
+		zap.String("username", input.Username),
+		zap.String("voice_role", voiceRoleName(speaker)),
+	)
+	return nil
+}